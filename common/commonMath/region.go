@@ -1,6 +1,8 @@
 package commonMath
 
 import (
+	"math"
+
 	"github.com/go-gl/mathgl/mgl32"
 )
 
@@ -28,10 +30,29 @@ func (r Region) IterateIntWithEarlyExit(iterate func(x, y int) bool) bool {
 
 	switch r.RegionType {
 	case SquareRegion:
-		// TODO: Account for orientation.
-		for i := int(r.Position.X() - r.Scale/2); i <= int(r.Position.X()+r.Scale/2); i++ {
-			for j := int(r.Position.Y() - r.Scale/2); j <= int(r.Position.Y()+r.Scale/2); j++ {
-				if iterate(i, j) {
+		if r.Orientation == 0 {
+			for i := int(r.Position.X() - r.Scale/2); i <= int(r.Position.X()+r.Scale/2); i++ {
+				for j := int(r.Position.Y() - r.Scale/2); j <= int(r.Position.Y()+r.Scale/2); j++ {
+					if iterate(i, j) {
+						return true
+					}
+				}
+			}
+
+			return false
+		}
+
+		// Iterate over the bounds of the rotated square, skipping positions outside of it.
+		sin, cos := math.Sincos(float64(r.Orientation))
+		halfScale := float64(r.Scale / 2)
+		halfExtent := float32(halfScale * (math.Abs(cos) + math.Abs(sin)))
+		for i := int(r.Position.X() - halfExtent); i <= int(r.Position.X()+halfExtent); i++ {
+			for j := int(r.Position.Y() - halfExtent); j <= int(r.Position.Y()+halfExtent); j++ {
+				offsetX := float64(float32(i) - r.Position.X())
+				offsetY := float64(float32(j) - r.Position.Y())
+				localX := offsetX*cos + offsetY*sin
+				localY := -offsetX*sin + offsetY*cos
+				if math.Abs(localX) <= halfScale && math.Abs(localY) <= halfScale && iterate(i, j) {
 					return true
 				}
 			}
@@ -3,6 +3,7 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"common/commonconfig"
 	"common/commonio"
 )
@@ -30,28 +31,50 @@ type Ui struct {
 type DrawConfig struct {
 	SnapNodeCount       int
 	MinSnapNodeDistance float32
+	RotationStep        float32 // Degrees items rotate by when placing them
 }
 
 type SnapConfig struct {
 	SnapAngleDivision  int
 	SnapGridResolution int
+
+	// If true, angles are snapped relative to the previous segment instead of the X axis
+	SnapAngleToPreviousSegment bool
 }
 
 type SimConfig struct {
 	SecondsPerDay   float32
 	StartingSavings float32
 	MaxDebt         float32
+
+	// Fraction of the cost refunded when demolishing something
+	DemolitionRefund float32
+}
+
+// Defines a phase of a traffic signal cycle, which gives a green light to roads approaching closest to its axis
+type SignalPhase struct {
+	Axis      float32 // Degrees from the X axis, in either direction
+	GreenTime float32 // Seconds
+}
+
+type IntersectionConfig struct {
+	StopTime      float32 // Seconds vehicles stop for at all-way stops, taking turns to cross
+	ClearanceTime float32 // Seconds all signals are red between phases
+	SignalPhases  []SignalPhase
 }
 
 type Configuration struct {
 	Terrain Terrain
 	Power   Power
+	Road    Road
 
 	Ui   Ui
 	Draw DrawConfig
 	Snap SnapConfig
 	Sim  SimConfig
 
+	Intersection IntersectionConfig
+
 	Buildings []Building
 	Resources []Resource
 	Vehicles  []Vehicle
@@ -71,11 +94,29 @@ func loadSubConfigs(configFolder string) {
 		panic(err)
 	}
 
-	i := 0
+	if err := Config.Power.validate(); err != nil {
+		panic(fmt.Errorf("invalid power config: %w", err))
+	}
+
+	// Plant types are ordered by name, so each sub-selection always picks the same plant type
+	plantNames := make([]string, 0, len(Config.Power.PowerPlantTypes))
+	for key := range Config.Power.PowerPlantTypes {
+		plantNames = append(plantNames, key)
+	}
+
+	sort.Strings(plantNames)
 	Config.Power.IdToNameMap = make(map[int]string)
-	for key, _ := range Config.Power.PowerPlantTypes {
+	for i, key := range plantNames {
 		Config.Power.IdToNameMap[i] = key
-		i++
+	}
+
+	bytes = commonIo.ReadFileAsBytes(configFolder + "road.json")
+	if err := json.Unmarshal(bytes, &Config.Road); err != nil {
+		panic(err)
+	}
+
+	if err := Config.Road.validate(); err != nil {
+		panic(fmt.Errorf("invalid road config: %w", err))
 	}
 
 	bytes = commonIo.ReadFileAsBytes(configFolder + "building.json")
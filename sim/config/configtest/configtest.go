@@ -0,0 +1,12 @@
+package configtest
+
+import "testing"
+
+// Changes a config setting for the test, restoring its previous value when the test ends
+func Override[T any](t testing.TB, setting *T, value T) {
+	previous := *setting
+	*setting = value
+	t.Cleanup(func() {
+		*setting = previous
+	})
+}
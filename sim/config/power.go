@@ -1,5 +1,10 @@
 package config
 
+import (
+	"errors"
+	"fmt"
+)
+
 type PowerPlant struct {
 	SmallOutput int
 	SmallSize   int
@@ -8,12 +13,57 @@ type PowerPlant struct {
 	LargeSize   int
 
 	Cost float32
+
+	// The fraction of the output available on average.
+	// For plants with variable output, this is the fraction available at peak sun or wind.
+	CapacityFactor float32
+	Variability    string // "solar" or "wind" if the output varies with the time of day or wind
+
+	// Fuel resource consumed, per kW of output per day
+	Fuel       string
+	FuelPerDay float32
+
+	// Placement prerequisites
+	RequiredWaterDistance float32 // If non-zero, the plant must be this close to water for cooling
+	RequiresGeothermal    bool    // If true, the plant must be on a geothermal deposit
+}
+
+// Defines a powerline capacity that can be built
+type PowerLineTier struct {
+	Name       string
+	Capacity   int64   // kW
+	CostFactor float32 // Multiplies the cost per unit
 }
 
 type Power struct {
 	PowerPlantTypes map[string]PowerPlant
-	PowerLineCost   float32 // Cost per unit
+	PowerLineTiers  []PowerLineTier // Ordered by capacity
+	PowerLineCost   float32         // Cost per unit
+	PowerLineWidth  float32         // Width of the corridor a line takes up, in units
+
+	// Multiplies the cost per unit of powerlines crossing each terrain type, by name. Unlisted terrain types cost the base amount.
+	PowerLineTerrainCosts map[string]float32
 
-	// Generated at run-time as ordering of maps is not guaranteed
+	MaxPowerLineSpan float32 // Longest distance between pylons, in units. Longer lines have pylons added along them.
+	PylonCost        float32 // Cost of each pylon added along a line
+
+	ConsumerConnectionDistance float32 // How far consumers automatically connect to the grid, in units
+
+	// Generated at run-time, ordered by name, as ordering of maps is not guaranteed
 	IdToNameMap map[int]string
 }
+
+// Checks the power config can be used, returning an error describing the first problem found
+func (p Power) validate() error {
+	if len(p.PowerLineTiers) == 0 {
+		return errors.New("there must be at least one powerline tier")
+	}
+
+	for idx, tier := range p.PowerLineTiers {
+		if idx > 0 && tier.Capacity < p.PowerLineTiers[idx-1].Capacity {
+			return fmt.Errorf("powerline tier '%v' must be listed before the lower capacity tier '%v'", p.PowerLineTiers[idx-1].Name, tier.Name)
+		}
+	}
+
+	return nil
+}
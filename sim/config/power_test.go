@@ -0,0 +1,20 @@
+package config
+
+import "testing"
+
+func TestValidatePower(t *testing.T) {
+	low := PowerLineTier{Name: "low", Capacity: 100, CostFactor: 1}
+	high := PowerLineTier{Name: "high", Capacity: 1000, CostFactor: 3}
+
+	if err := (Power{PowerLineTiers: []PowerLineTier{low, high}}).validate(); err != nil {
+		t.Errorf("Valid powerline tiers should be accepted, found %v", err)
+	}
+
+	for name, tiers := range map[string][]PowerLineTier{
+		"no tiers":         {},
+		"descending order": {high, low}} {
+		if err := (Power{PowerLineTiers: tiers}).validate(); err == nil {
+			t.Errorf("Powerline tiers with %v should be rejected", name)
+		}
+	}
+}
@@ -27,6 +27,9 @@ type Resource struct {
 	// For example, a vehicle of capacity 10 can carry 10*ResourceFactor units of this resource.
 	ResourceFactor float32
 
+	// Cost per unit when bought, such as fuel for power plants
+	Price float32
+
 	// What types of vehicles can carry this resource
 	AllowedVehicleTypes []string
 
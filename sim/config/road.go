@@ -0,0 +1,45 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Defines a class of road that can be built
+type RoadClass struct {
+	Name        string
+	Lanes       int     // Split evenly between both directions
+	SpeedLimit  float32 // units / second
+	Capacity    int64   // Vehicles each road line carries, in both directions
+	CostPerUnit float32
+	Upkeep      float32 // Cost per unit per day
+	RenderWidth float32 // Width of the road on the map, and the corridor it takes up, in units
+}
+
+type Road struct {
+	RoadClasses  []RoadClass // Ordered by capacity, from the smallest road to the largest
+	JunctionCost float32     // Cost of each junction made where a new road crosses an existing one
+}
+
+// Checks the road config can be used, returning an error describing the first problem found
+func (r Road) validate() error {
+	if len(r.RoadClasses) == 0 {
+		return errors.New("there must be at least one road class")
+	}
+
+	for idx, class := range r.RoadClasses {
+		if class.Lanes <= 0 {
+			return fmt.Errorf("road class '%v' must have at least one lane, found %v", class.Name, class.Lanes)
+		}
+
+		if class.SpeedLimit <= 0 {
+			return fmt.Errorf("road class '%v' must have a positive speed limit, found %v", class.Name, class.SpeedLimit)
+		}
+
+		if idx > 0 && class.Capacity < r.RoadClasses[idx-1].Capacity {
+			return fmt.Errorf("road class '%v' must be listed before the smaller road class '%v'", r.RoadClasses[idx-1].Name, class.Name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package config
+
+import "testing"
+
+func TestValidateRoad(t *testing.T) {
+	street := RoadClass{Name: "street", Lanes: 2, SpeedLimit: 10, Capacity: 10}
+	highway := RoadClass{Name: "highway", Lanes: 4, SpeedLimit: 30, Capacity: 40}
+
+	if err := (Road{RoadClasses: []RoadClass{street, highway}}).validate(); err != nil {
+		t.Errorf("Valid road classes should be accepted, found %v", err)
+	}
+
+	stopped := street
+	stopped.SpeedLimit = 0
+	noLanes := street
+	noLanes.Lanes = 0
+	for name, classes := range map[string][]RoadClass{
+		"no classes":       {},
+		"no speed limit":   {stopped},
+		"no lanes":         {noLanes},
+		"descending order": {highway, street}} {
+		if err := (Road{RoadClasses: classes}).validate(); err == nil {
+			t.Errorf("Road classes with %v should be rejected", name)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package broadcast
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Defines what happens when a subscriber's channel is full
+type OverflowPolicy int
+
+const (
+	// Discards the oldest pending item to make room for the new one. Suitable for event streams where recent events matter most.
+	DropOldest OverflowPolicy = iota
+
+	// Discards all pending items, leaving only the latest. Suitable for state updates (positions, scales, times).
+	CoalesceLatest
+
+	// Waits up to the timeout for the subscriber to make room, then drops the new item. Suitable for events that should not be lost.
+	BlockWithTimeout
+
+	// Queues every item for the subscriber, forwarding them in order from a goroutine per subscriber, so a slow subscriber only delays itself.
+	// Suitable for edits subscribers must see every one of to stay consistent, at the cost of memory while a subscriber lags.
+	QueueAll
+)
+
+const DefaultTimeout = 100 * time.Millisecond
+
+// Defines how far behind a subscriber is
+type SubscriberMetrics struct {
+	Delivered int64
+	Dropped   int64 // Items discarded by DropOldest
+	Coalesced int64 // Items superseded by CoalesceLatest
+	TimedOut  int64 // Items lost after BlockWithTimeout expired
+
+	Pending    int // Items currently waiting in the subscriber's channel and queue
+	MaxPending int // The most items ever seen waiting in the subscriber's channel and queue
+	Capacity   int
+}
+
+type subscriber[T any] struct {
+	channel chan T
+	metrics SubscriberMetrics
+
+	// Items waiting to be forwarded to the channel with QueueAll, in the order they were sent
+	queue  []T
+	signal chan bool
+	stop   chan bool
+}
+
+// Gets the number of items waiting for the subscriber
+func (s *subscriber[T]) getPending() int {
+	return len(s.channel) + len(s.queue)
+}
+
+// Defines a thread-safe fan-out of items to registered channels.
+// Sending never blocks indefinitely, no matter how slow a subscriber is.
+// Items are queued without blocking at all with QueueAll.
+type Broadcaster[T any] struct {
+	name         string
+	registryName string
+	policy       OverflowPolicy
+	timeout      time.Duration
+
+	lock        sync.Mutex
+	subscribers []*subscriber[T]
+}
+
+var registryLock sync.Mutex
+var registry = make(map[string]func() []SubscriberMetrics)
+
+// Creates a new broadcaster. The timeout only applies to BlockWithTimeout,
+// and to unbuffered subscriber channels, which cannot drop or coalesce items.
+func NewBroadcaster[T any](name string, policy OverflowPolicy, timeout time.Duration) *Broadcaster[T] {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	broadcaster := Broadcaster[T]{
+		name:        name,
+		policy:      policy,
+		timeout:     timeout,
+		subscribers: make([]*subscriber[T], 0)}
+
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	// Multiple agents of the same type may broadcast, so keep registry names unique.
+	broadcaster.registryName = name
+	for i := 2; registry[broadcaster.registryName] != nil; i++ {
+		broadcaster.registryName = fmt.Sprintf("%v (%v)", name, i)
+	}
+
+	registry[broadcaster.registryName] = broadcaster.GetMetrics
+
+	return &broadcaster
+}
+
+// Removes the broadcaster from the metrics registry, and stops forwarding queued items
+func (b *Broadcaster[T]) Close() {
+	b.lock.Lock()
+	for _, sub := range b.subscribers {
+		b.stopForwarding(sub)
+	}
+	b.lock.Unlock()
+
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	delete(registry, b.registryName)
+}
+
+// Adds a channel that will receive all future items
+func (b *Broadcaster[T]) Register(channel chan T) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	sub := subscriber[T]{
+		channel: channel,
+		metrics: SubscriberMetrics{Capacity: cap(channel)}}
+
+	if b.policy == QueueAll {
+		sub.queue = make([]T, 0)
+		sub.signal = make(chan bool, 1)
+		sub.stop = make(chan bool)
+		go b.forward(&sub)
+	}
+
+	b.subscribers = append(b.subscribers, &sub)
+}
+
+// Removes a channel so it receives no future items, returning true if it was registered
+func (b *Broadcaster[T]) Unregister(channel chan T) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for idx, sub := range b.subscribers {
+		if sub.channel == channel {
+			b.stopForwarding(sub)
+			b.subscribers = append(b.subscribers[:idx], b.subscribers[idx+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// Sends an item to every subscriber, following the overflow policy for full subscribers.
+func (b *Broadcaster[T]) Send(item T) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for idx, sub := range b.subscribers {
+		if b.policy == QueueAll {
+			b.enqueue(sub, item)
+			continue
+		}
+
+		sub.metrics.MaxPending = max(sub.metrics.MaxPending, len(sub.channel))
+
+		select {
+		case sub.channel <- item:
+			sub.metrics.Delivered++
+			continue
+		default:
+		}
+
+		// Unbuffered channels have nothing to drop or coalesce, so they can only wait.
+		if b.policy == BlockWithTimeout || cap(sub.channel) == 0 {
+			b.sendWithTimeout(idx, sub, item)
+		} else if b.policy == DropOldest {
+			select {
+			case <-sub.channel:
+				sub.metrics.Dropped++
+			default:
+			}
+
+			b.trySend(sub, item)
+		} else {
+			drained := false
+			for !drained {
+				select {
+				case <-sub.channel:
+					sub.metrics.Coalesced++
+				default:
+					drained = true
+				}
+			}
+
+			b.trySend(sub, item)
+		}
+	}
+}
+
+// Sends without blocking, counting the item as dropped if the subscriber filled up again.
+func (b *Broadcaster[T]) trySend(sub *subscriber[T], item T) {
+	select {
+	case sub.channel <- item:
+		sub.metrics.Delivered++
+	default:
+		sub.metrics.Dropped++
+	}
+}
+
+func (b *Broadcaster[T]) sendWithTimeout(idx int, sub *subscriber[T], item T) {
+	timer := time.NewTimer(b.timeout)
+	defer timer.Stop()
+
+	select {
+	case sub.channel <- item:
+		sub.metrics.Delivered++
+	case <-timer.C:
+		sub.metrics.TimedOut++
+		fmt.Printf("Broadcast '%v' timed out sending to subscriber %v after %v.\n", b.name, idx, b.timeout)
+	}
+}
+
+// Adds an item to the subscriber's queue, waking up its forwarder
+func (b *Broadcaster[T]) enqueue(sub *subscriber[T], item T) {
+	sub.queue = append(sub.queue, item)
+	sub.metrics.MaxPending = max(sub.metrics.MaxPending, sub.getPending())
+
+	select {
+	case sub.signal <- true:
+	default:
+		// The forwarder already has a pending signal and will forward this item too.
+	}
+}
+
+// Forwards queued items to the subscriber's channel in order, until the subscriber is stopped
+func (b *Broadcaster[T]) forward(sub *subscriber[T]) {
+	for {
+		b.lock.Lock()
+		if len(sub.queue) == 0 {
+			b.lock.Unlock()
+			select {
+			case <-sub.signal:
+				continue
+			case <-sub.stop:
+				return
+			}
+		}
+
+		item := sub.queue[0]
+		b.lock.Unlock()
+
+		select {
+		case sub.channel <- item:
+			b.lock.Lock()
+			var empty T
+			sub.queue[0] = empty
+			sub.queue = sub.queue[1:]
+			sub.metrics.Delivered++
+			b.lock.Unlock()
+		case <-sub.stop:
+			return
+		}
+	}
+}
+
+// Stops the subscriber's forwarder, if it has a running one. Must be called with the lock held.
+func (b *Broadcaster[T]) stopForwarding(sub *subscriber[T]) {
+	if sub.stop == nil {
+		return
+	}
+
+	select {
+	case <-sub.stop:
+	default:
+		close(sub.stop)
+	}
+}
+
+// Returns the number of registered subscribers
+func (b *Broadcaster[T]) Len() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return len(b.subscribers)
+}
+
+// Returns lag metrics for each subscriber, in registration order
+func (b *Broadcaster[T]) GetMetrics() []SubscriberMetrics {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	metrics := make([]SubscriberMetrics, len(b.subscribers))
+	for idx, sub := range b.subscribers {
+		metrics[idx] = sub.metrics
+		metrics[idx].Pending = sub.getPending()
+	}
+
+	return metrics
+}
+
+// Returns lag metrics for every open broadcaster, keyed by broadcaster name
+func GetAllMetrics() map[string][]SubscriberMetrics {
+	registryLock.Lock()
+	getters := make(map[string]func() []SubscriberMetrics, len(registry))
+	for name, getter := range registry {
+		getters[name] = getter
+	}
+	registryLock.Unlock()
+
+	allMetrics := make(map[string][]SubscriberMetrics, len(getters))
+	for name, getter := range getters {
+		allMetrics[name] = getter()
+	}
+
+	return allMetrics
+}
@@ -0,0 +1,139 @@
+package broadcast
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDropOldest(t *testing.T) {
+	b := NewBroadcaster[int]("drop oldest test", DropOldest, DefaultTimeout)
+	defer b.Close()
+
+	channel := make(chan int, 2)
+	b.Register(channel)
+	for i := 0; i < 5; i++ {
+		b.Send(i)
+	}
+
+	if first, second := <-channel, <-channel; first != 3 || second != 4 {
+		t.Errorf("Only the newest items should remain, found %v and %v", first, second)
+	}
+
+	metrics := b.GetMetrics()[0]
+	if metrics.Dropped != 3 || metrics.Delivered != 5 {
+		t.Errorf("Unexpected metrics %+v", metrics)
+	}
+}
+
+func TestCoalesceLatest(t *testing.T) {
+	b := NewBroadcaster[int]("coalesce test", CoalesceLatest, DefaultTimeout)
+	defer b.Close()
+
+	channel := make(chan int, 3)
+	b.Register(channel)
+	for i := 0; i < 5; i++ {
+		b.Send(i)
+	}
+
+	// The first overflow coalesces everything pending into 3, then 4 has room.
+	if first, second := <-channel, <-channel; first != 3 || second != 4 {
+		t.Errorf("Pending items should be coalesced on overflow, found %v and %v", first, second)
+	}
+
+	if metrics := b.GetMetrics()[0]; metrics.Coalesced != 3 || metrics.MaxPending != 3 {
+		t.Errorf("Unexpected metrics %+v", metrics)
+	}
+}
+
+func TestUnregister(t *testing.T) {
+	b := NewBroadcaster[int]("unregister test", DropOldest, DefaultTimeout)
+	defer b.Close()
+
+	first := make(chan int, 2)
+	second := make(chan int, 2)
+	b.Register(first)
+	b.Register(second)
+
+	if !b.Unregister(first) || b.Unregister(first) {
+		t.Error("Channels should only be unregistered once")
+	}
+
+	b.Send(1)
+	if len(first) != 0 || len(second) != 1 || b.Len() != 1 {
+		t.Errorf("Only the registered channel should receive items, found %v and %v pending", len(first), len(second))
+	}
+}
+
+func TestBlockWithTimeout(t *testing.T) {
+	b := NewBroadcaster[int]("timeout test", BlockWithTimeout, 10*time.Millisecond)
+	defer b.Close()
+
+	slowChannel := make(chan int)
+	fastChannel := make(chan int, 5)
+	b.Register(slowChannel)
+	b.Register(fastChannel)
+
+	start := time.Now()
+	b.Send(1)
+	if time.Since(start) > time.Second {
+		t.Error("A slow subscriber should not block the broadcaster")
+	}
+
+	if <-fastChannel != 1 {
+		t.Error("Other subscribers should still receive items")
+	}
+
+	metrics := b.GetMetrics()
+	if metrics[0].TimedOut != 1 || metrics[1].Delivered != 1 {
+		t.Errorf("Unexpected metrics %+v", metrics)
+	}
+}
+
+func TestQueueAll(t *testing.T) {
+	b := NewBroadcaster[int]("queue all test", QueueAll, DefaultTimeout)
+	defer b.Close()
+
+	slowChannel := make(chan int)
+	fastChannel := make(chan int, 1)
+	b.Register(slowChannel)
+	b.Register(fastChannel)
+
+	// The slow subscriber reads nothing while the fast one reads everything, in order
+	for i := 0; i < 100; i++ {
+		b.Send(i)
+		if item := <-fastChannel; item != i {
+			t.Fatalf("The fast subscriber should receive item %v, found %v", i, item)
+		}
+	}
+
+	if metrics := b.GetMetrics(); metrics[0].Pending != 100 || metrics[0].Delivered != 0 {
+		t.Errorf("The slow subscriber should have every item pending, found %+v", metrics)
+	}
+
+	for i := 0; i < 100; i++ {
+		select {
+		case item := <-slowChannel:
+			if item != i {
+				t.Fatalf("The slow subscriber should receive item %v, found %v", i, item)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for item %v", i)
+		}
+	}
+}
+
+func TestUniqueRegistryNames(t *testing.T) {
+	first := NewBroadcaster[int]("duplicate", DropOldest, DefaultTimeout)
+	second := NewBroadcaster[int]("duplicate", DropOldest, DefaultTimeout)
+
+	allMetrics := GetAllMetrics()
+	if _, ok := allMetrics["duplicate (2)"]; !ok {
+		t.Error("Duplicate names should be made unique")
+	}
+
+	first.Close()
+	second.Close()
+	if _, ok := GetAllMetrics()["duplicate"]; ok {
+		t.Error("Closed broadcasters should be removed from the registry")
+	}
+}
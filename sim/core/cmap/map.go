@@ -1,30 +1,33 @@
 package cmap
 
-import "sync"
+import (
+	"sort"
+	"sync"
+)
 
-// Defines an int64-indexed simple cooncurrent map.
-// Storing nil entries is undefined.
-type Map struct {
-	data          map[int64]interface{}
+// Defines an int64-indexed simple concurrent map.
+// Iteration works on a snapshot, so the map can be safely modified while iterating.
+type Map[V any] struct {
+	data          map[int64]V
 	lock          sync.Mutex
 	nextItemIndex int64
 }
 
-func NewMap() *Map {
-	return &Map{
-		data:          make(map[int64]interface{}),
+func NewMap[V any]() *Map[V] {
+	return &Map[V]{
+		data:          make(map[int64]V),
 		nextItemIndex: 0}
 }
 
 // Iteratively adds an item to the map, returning the index of the item
-func (m *Map) IterativeAdd(item interface{}) int64 {
-	if item == nil {
-		panic("Storing nil items is disallowed!")
-	}
-
+func (m *Map[V]) IterativeAdd(item V) int64 {
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
+	return m.iterativeAdd(item)
+}
+
+func (m *Map[V]) iterativeAdd(item V) int64 {
 	itemIndex := m.nextItemIndex
 	m.nextItemIndex++
 	m.data[itemIndex] = item
@@ -32,25 +35,147 @@ func (m *Map) IterativeAdd(item interface{}) int64 {
 	return itemIndex
 }
 
-// Gets an item
-func (m *Map) Get(index int64) interface{} {
+// Iteratively adds all items to the map, returning the index of each item in order
+func (m *Map[V]) IterativeAddAll(items []V) []int64 {
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
-	if data, ok := m.data[index]; ok {
-		return data
+	indices := make([]int64, len(items))
+	for idx, item := range items {
+		indices[idx] = m.iterativeAdd(item)
 	}
 
-	return nil
+	return indices
+}
+
+// Gets an item, returning false if there is no item at the index
+func (m *Map[V]) Get(index int64) (V, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	data, ok := m.data[index]
+	return data, ok
 }
 
 // Stores an item
-func (m *Map) Set(index int64, item interface{}) {
-	if item == nil {
-		panic("Storing nil items is disallowed!")
+func (m *Map[V]) Set(index int64, item V) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.data[index] = item
+}
+
+// Stores all items at their indices
+func (m *Map[V]) SetAll(items map[int64]V) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for index, item := range items {
+		m.data[index] = item
 	}
+}
 
+// Deletes an item, returning true if deleted, false if it was already gone
+func (m *Map[V]) Delete(index int64) bool {
 	m.lock.Lock()
 	defer m.lock.Unlock()
-	m.data[index] = item
+
+	if _, ok := m.data[index]; ok {
+		delete(m.data, index)
+		return true
+	}
+
+	return false
+}
+
+// Deletes all items at the indices, returning the number deleted
+func (m *Map[V]) DeleteAll(indices []int64) int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	deleted := 0
+	for _, index := range indices {
+		if _, ok := m.data[index]; ok {
+			delete(m.data, index)
+			deleted++
+		}
+	}
+
+	return deleted
+}
+
+// Deletes all items matching the predicate, returning the deleted indices in ascending order
+func (m *Map[V]) DeleteWhere(predicate func(index int64, item V) bool) []int64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	deleted := make([]int64, 0)
+	for index, item := range m.data {
+		if predicate(index, item) {
+			deleted = append(deleted, index)
+		}
+	}
+
+	for _, index := range deleted {
+		delete(m.data, index)
+	}
+
+	sortIndices(deleted)
+	return deleted
+}
+
+// Returns the number of items in the map
+func (m *Map[V]) Len() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return len(m.data)
+}
+
+// Returns a copy of the map contents
+func (m *Map[V]) Snapshot() map[int64]V {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	snapshot := make(map[int64]V, len(m.data))
+	for index, item := range m.data {
+		snapshot[index] = item
+	}
+
+	return snapshot
+}
+
+// Returns all indices in the map, in ascending order
+func (m *Map[V]) Indices() []int64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	indices := make([]int64, 0, len(m.data))
+	for index := range m.data {
+		indices = append(indices, index)
+	}
+
+	sortIndices(indices)
+	return indices
+}
+
+// Visits each item in ascending index order, stopping early if visit returns false.
+// Items are visited from a snapshot taken when Range is called, so visit may modify the map.
+func (m *Map[V]) Range(visit func(index int64, item V) bool) {
+	snapshot := m.Snapshot()
+
+	indices := make([]int64, 0, len(snapshot))
+	for index := range snapshot {
+		indices = append(indices, index)
+	}
+
+	sortIndices(indices)
+	for _, index := range indices {
+		if !visit(index, snapshot[index]) {
+			return
+		}
+	}
+}
+
+func sortIndices(indices []int64) {
+	sort.Slice(indices, func(i, j int) bool { return indices[i] < indices[j] })
 }
@@ -0,0 +1,86 @@
+package cmap
+
+import (
+	"testing"
+)
+
+func TestAddGetDelete(t *testing.T) {
+	m := NewMap[string]()
+
+	first := m.IterativeAdd("first")
+	second := m.IterativeAdd("second")
+	if first == second {
+		t.Error("Each added item should get a unique index")
+	}
+
+	if item, ok := m.Get(second); !ok || item != "second" {
+		t.Errorf("Expected to get the second item, found '%v'", item)
+	}
+
+	if !m.Delete(first) || m.Delete(first) {
+		t.Error("Items should only be deleted once")
+	}
+
+	if _, ok := m.Get(first); ok || m.Len() != 1 {
+		t.Error("Deleted items should be gone")
+	}
+
+	if third := m.IterativeAdd("third"); third == first {
+		t.Error("Indices should not be reused after deletion")
+	}
+}
+
+func TestRangeUsesSnapshot(t *testing.T) {
+	m := NewMap[int]()
+	m.IterativeAddAll([]int{10, 20, 30})
+
+	visited := make([]int, 0)
+	m.Range(func(index int64, item int) bool {
+		visited = append(visited, item)
+		m.Delete(index)
+		m.IterativeAdd(item + 1)
+		return true
+	})
+
+	if len(visited) != 3 || visited[0] != 10 || visited[1] != 20 || visited[2] != 30 {
+		t.Errorf("Range should visit the snapshot in index order, found %v", visited)
+	}
+
+	if m.Len() != 3 {
+		t.Errorf("Modifications during iteration should apply, found %v items", m.Len())
+	}
+
+	count := 0
+	m.Range(func(index int64, item int) bool {
+		count++
+		return false
+	})
+
+	if count != 1 {
+		t.Error("Range should stop when visit returns false")
+	}
+}
+
+func TestBulkOperations(t *testing.T) {
+	m := NewMap[int]()
+	indices := m.IterativeAddAll([]int{1, 2, 3, 4, 5})
+
+	if deleted := m.DeleteAll([]int64{indices[0], indices[0], 42}); deleted != 1 {
+		t.Errorf("Only existing items should be deleted, deleted %v", deleted)
+	}
+
+	deleted := m.DeleteWhere(func(index int64, item int) bool { return item%2 == 0 })
+	if len(deleted) != 2 || deleted[0] != indices[1] || deleted[1] != indices[3] {
+		t.Errorf("Unexpected deletions %v", deleted)
+	}
+
+	m.SetAll(map[int64]int{100: 100, indices[2]: 33})
+	snapshot := m.Snapshot()
+	if len(snapshot) != 3 || snapshot[100] != 100 || snapshot[indices[2]] != 33 {
+		t.Errorf("Unexpected snapshot %v", snapshot)
+	}
+
+	if keys := m.Indices(); len(keys) != 3 || keys[2] != 100 {
+		t.Errorf("Indices should be sorted, found %v", keys)
+	}
+}
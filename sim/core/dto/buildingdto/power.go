@@ -0,0 +1,32 @@
+package buildingdto
+
+type PowerState int
+
+const (
+	Powered PowerState = iota
+	Brownout
+	Blackout
+)
+
+func (p PowerState) String() string {
+	switch p {
+	case Powered:
+		return "powered"
+	case Brownout:
+		return "brownout"
+	default:
+		return "blackout"
+	}
+}
+
+// Defines a change in how well a building is powered
+type PowerStateUpdate struct {
+	Id    int64
+	State PowerState
+}
+
+func NewPowerStateUpdate(id int64, state PowerState) PowerStateUpdate {
+	return PowerStateUpdate{
+		Id:    id,
+		State: state}
+}
@@ -1,11 +1,14 @@
 package editorengdto
 
+import "common/commonmath"
+
 type EditorMode int
 
 const (
 	Select EditorMode = iota
 	Add
 	Draw
+	Delete
 )
 
 type EditorAddMode int
@@ -14,6 +17,7 @@ const (
 	PowerPlant EditorAddMode = iota
 	PowerLine
 	RoadLine
+	Building
 )
 
 type ItemSubSelection int
@@ -50,3 +54,16 @@ type SnapSetting struct {
 	Setting SnapToggle
 	State   bool
 }
+
+// Defines how items are placed
+type PlacementSetting struct {
+	IsLarge     bool
+	Orientation float32 // Radians
+}
+
+// Defines a preview of where an item would be placed, and if it can be placed there
+type PlacementPreview struct {
+	IsVisible bool
+	IsValid   bool
+	Region    commonMath.Region
+}
@@ -6,6 +6,9 @@ import "github.com/go-gl/mathgl/mgl32"
 type IdLine struct {
 	Id   int64
 	Line [2]mgl32.Vec2
+
+	// Width of the line on the map, in units. Lines without a width are as thin as possible.
+	Width float32
 }
 
 func NewIdLine(id int64, line [2]mgl32.Vec2) IdLine {
@@ -14,6 +17,13 @@ func NewIdLine(id int64, line [2]mgl32.Vec2) IdLine {
 		Line: line}
 }
 
+func NewWideIdLine(id int64, line [2]mgl32.Vec2, width float32) IdLine {
+	return IdLine{
+		Id:    id,
+		Line:  line,
+		Width: width}
+}
+
 // An identifiable line with identifiers for the start and end
 type IdOnlyLine struct {
 	Id    int64
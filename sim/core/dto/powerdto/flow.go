@@ -0,0 +1,48 @@
+package powerdto
+
+// Defines how much of a consumer's demand reaches it
+type ConsumerFlow struct {
+	Id     int64
+	Demand int64 // kW
+	Served int64 // kW
+}
+
+func (c ConsumerFlow) Unserved() int64 {
+	return c.Demand - c.Served
+}
+
+// Defines how much power a plant supplies
+type PlantFlow struct {
+	Id        int64
+	Type      string
+	Available int64 // kW
+	Output    int64 // kW
+}
+
+// Defines how much power flows along a power line
+type LineFlow struct {
+	Id       int64
+	Load     int64 // kW
+	Capacity int64 // kW
+}
+
+// Defines the result of solving the power flow across the power grid
+type PowerFlow struct {
+	Plants    map[int64]PlantFlow
+	Consumers map[int64]ConsumerFlow
+	Lines     map[int64]LineFlow
+
+	Supply int64 // kW
+	Demand int64 // kW
+	Served int64 // kW
+}
+
+func NewPowerFlow() PowerFlow {
+	return PowerFlow{
+		Plants:    make(map[int64]PlantFlow),
+		Consumers: make(map[int64]ConsumerFlow),
+		Lines:     make(map[int64]LineFlow),
+		Supply:    0,
+		Demand:    0,
+		Served:    0}
+}
@@ -0,0 +1,64 @@
+package powerdto
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Summarizes a connected network of the power grid
+type NetworkReport struct {
+	// The lowest node ID in the network, which identifies it
+	Id        int64
+	Plants    int
+	Consumers int
+
+	Capacity  int64 // kW, at full output
+	Available int64 // kW, given the current weather and fuel
+	Demand    int64 // kW
+	Served    int64 // kW
+
+	// The fraction of demand that available output exceeds it by. Zero if the network has no demand.
+	ReserveMargin float32
+
+	// The lines with the highest load relative to their capacity, most loaded first
+	MostLoadedLines []LineFlow
+}
+
+// Summarizes the status of every network of the power grid
+type PowerReport struct {
+	Networks []NetworkReport
+
+	// Plants on networks without any consumers, so their output is never used
+	IsolatedPlants []PlantFlow
+}
+
+// Requests the latest power report, which is sent to the result channel
+type PowerReportQuery struct {
+	Result chan PowerReport
+}
+
+func NewPowerReportQuery() PowerReportQuery {
+	return PowerReportQuery{Result: make(chan PowerReport, 1)}
+}
+
+func (r PowerReport) String() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%v power networks, %v isolated plants\n", len(r.Networks), len(r.IsolatedPlants))
+	for _, network := range r.Networks {
+		fmt.Fprintf(&builder, "\nNetwork %v: %v plants, %v consumers\n", network.Id, network.Plants, network.Consumers)
+		fmt.Fprintf(&builder, "  Capacity %v kW, available %v kW\n", network.Capacity, network.Available)
+		fmt.Fprintf(&builder, "  Demand %v kW, served %v kW, reserve margin %.1f%%\n", network.Demand, network.Served, network.ReserveMargin*100)
+		for _, line := range network.MostLoadedLines {
+			fmt.Fprintf(&builder, "  Line %v: %v of %v kW\n", line.Id, line.Load, line.Capacity)
+		}
+	}
+
+	if len(r.IsolatedPlants) > 0 {
+		builder.WriteString("\nIsolated plants:\n")
+		for _, plant := range r.IsolatedPlants {
+			fmt.Fprintf(&builder, "  Plant %v (%v): %v kW available\n", plant.Id, plant.Type, plant.Available)
+		}
+	}
+
+	return builder.String()
+}
@@ -14,6 +14,25 @@ const (
 	Snow
 )
 
+func (t TerrainType) String() string {
+	switch t {
+	case Water:
+		return "water"
+	case Sand:
+		return "sand"
+	case Grass:
+		return "grass"
+	case Hills:
+		return "hills"
+	case Rocks:
+		return "rocks"
+	case Snow:
+		return "snow"
+	default:
+		return "unknown terrain"
+	}
+}
+
 // Given a height, returns the terrain type and percentage within that level
 func GetTerrainType(height float32) (TerrainType, float32) {
 	if height < config.Config.Terrain.WaterLevel {
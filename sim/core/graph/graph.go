@@ -1,6 +1,9 @@
 package graph
 
 import (
+	"context"
+	"sim/core/broadcast"
+	"sim/core/lifecycle"
 	"sync"
 )
 
@@ -18,7 +21,6 @@ type ConnectionResult struct {
 }
 
 // Defines a thread-safe bi-directional graph data structure, storing arbitrary node / connection data
-// TODO: Implement edit methods and data retrieval methods
 type Graph struct {
 	nodes              map[int64]*Node
 	connections        map[int64]*Connection
@@ -27,55 +29,88 @@ type Graph struct {
 
 	nodesLock sync.Mutex
 
-	connectionEditBuffer chan ConnectionEdit
-	nodeEditBuffer       chan NodeEdit
+	// Edits are queued while nodesLock is held and published from the run loop, after any registrations made before them.
+	// Each subscriber has its own queue, so a slow subscriber only delays itself, never publishing or registration.
+	editLock     sync.Mutex
+	pendingEdits []interface{}
+	editSignal   chan bool
 
-	connectionEditRegistrations []chan ConnectionEdit
-	nodeEditRegistrations       []chan NodeEdit
-	ConnectionEditRegChannel    chan chan ConnectionEdit
-	NodeEditRegChannel          chan chan NodeEdit
-	ControlChannel              chan int64
+	connectionEdits          *broadcast.Broadcaster[ConnectionEdit]
+	nodeEdits                *broadcast.Broadcaster[NodeEdit]
+	ConnectionEditRegChannel chan chan ConnectionEdit
+	NodeEditRegChannel       chan chan NodeEdit
 }
 
-func NewGraph() *Graph {
+func NewGraph(supervisor *lifecycle.Supervisor) *Graph {
 	graph := Graph{
-		nodes:                       make(map[int64]*Node),
-		connections:                 make(map[int64]*Connection),
-		newNodeIndex:                0,
-		newConnectionIndex:          0,
-		connectionEditBuffer:        make(chan ConnectionEdit, 10),
-		nodeEditBuffer:              make(chan NodeEdit, 10),
-		connectionEditRegistrations: make([]chan ConnectionEdit, 0),
-		nodeEditRegistrations:       make([]chan NodeEdit, 0),
-		ConnectionEditRegChannel:    make(chan chan ConnectionEdit),
-		NodeEditRegChannel:          make(chan chan NodeEdit),
-		ControlChannel:              make(chan int64)}
-
-	go graph.run()
+		nodes:                    make(map[int64]*Node),
+		connections:              make(map[int64]*Connection),
+		newNodeIndex:             0,
+		newConnectionIndex:       0,
+		pendingEdits:             make([]interface{}, 0),
+		editSignal:               make(chan bool, 1),
+		connectionEdits:          broadcast.NewBroadcaster[ConnectionEdit]("Graph connection edits", broadcast.QueueAll, broadcast.DefaultTimeout),
+		nodeEdits:                broadcast.NewBroadcaster[NodeEdit]("Graph node edits", broadcast.QueueAll, broadcast.DefaultTimeout),
+		ConnectionEditRegChannel: make(chan chan ConnectionEdit),
+		NodeEditRegChannel:       make(chan chan NodeEdit)}
+
+	supervisor.Go("graph.Graph", graph.run)
 	return &graph
 }
 
-func (d *Graph) run() {
+func (d *Graph) run(ctx context.Context) {
 	for {
 		select {
 		case reg := <-d.ConnectionEditRegChannel:
-			d.connectionEditRegistrations = append(d.connectionEditRegistrations, reg)
+			d.connectionEdits.Register(reg)
 		case reg := <-d.NodeEditRegChannel:
-			d.nodeEditRegistrations = append(d.nodeEditRegistrations, reg)
-		case connectionEdit := <-d.connectionEditBuffer:
-			for _, reg := range d.connectionEditRegistrations {
-				reg <- connectionEdit
-			}
-		case nodeEdit := <-d.nodeEditBuffer:
-			for _, reg := range d.nodeEditRegistrations {
-				reg <- nodeEdit
-			}
-		case _ = <-d.ControlChannel:
+			d.nodeEdits.Register(reg)
+		case _ = <-d.editSignal:
+			d.publishEdits()
+		case _ = <-ctx.Done():
+			d.connectionEdits.Close()
+			d.nodeEdits.Close()
 			return
 		}
 	}
 }
 
+// Queues an edit for publishing without blocking
+func (d *Graph) queueEdit(edit interface{}) {
+	d.editLock.Lock()
+	d.pendingEdits = append(d.pendingEdits, edit)
+	d.editLock.Unlock()
+
+	select {
+	case d.editSignal <- true:
+	default:
+		// The run loop already has a pending signal and will publish this edit too.
+	}
+}
+
+// Publishes all queued edits, in the order they were made.
+// Subscribers such as the element finder and router must see every edit to stay in sync, so edits are queued rather than dropped.
+func (d *Graph) publishEdits() {
+	d.editLock.Lock()
+	edits := d.pendingEdits
+	d.pendingEdits = make([]interface{}, 0)
+	d.editLock.Unlock()
+
+	for _, edit := range edits {
+		switch typedEdit := edit.(type) {
+		case ConnectionEdit:
+			d.connectionEdits.Send(typedEdit)
+		case NodeEdit:
+			d.nodeEdits.Send(typedEdit)
+		}
+	}
+}
+
+// Returns lag metrics for the connection and node edit subscribers, in that order
+func (d *Graph) GetEditMetrics() ([]broadcast.SubscriberMetrics, []broadcast.SubscriberMetrics) {
+	return d.connectionEdits.GetMetrics(), d.nodeEdits.GetMetrics()
+}
+
 // Adds a connection between two nodes, returning the status of the operation
 func (d *Graph) AddConnection(first, second int64, data interface{}) ConnectionResult {
 	d.nodesLock.Lock()
@@ -91,13 +126,13 @@ func (d *Graph) AddConnection(first, second int64, data interface{}) ConnectionR
 				d.nodes[first].connections[second] = nodeInternalConnection{Data: data, Id: connectionIdx}
 				d.nodes[second].connections[first] = nodeInternalConnection{Data: data, Id: connectionIdx}
 
-				d.connectionEditBuffer <- NewConnectionEdit(
+				d.queueEdit(NewConnectionEdit(
 					Add,
 					d.nodes[first].data,
 					connectionIdx,
 					first,
 					second,
-					data)
+					data))
 
 				d.newConnectionIndex++
 				return ConnectionResult{Status: Success, Id: connectionIdx}
@@ -117,13 +152,13 @@ func (d *Graph) DeleteConnection(first, second int64) bool {
 		if _, ok = d.nodes[second]; ok {
 			if _, ok = d.nodes[first].connections[second]; ok {
 				connectionId := d.nodes[first].connections[second].Id
-				d.connectionEditBuffer <- NewConnectionEdit(
+				d.queueEdit(NewConnectionEdit(
 					Delete,
 					d.nodes[first].data,
 					connectionId,
 					first,
 					second,
-					d.nodes[first].connections[second])
+					d.nodes[first].connections[second].Data))
 				delete(d.connections, connectionId)
 				delete(d.nodes[first].connections, second)
 				delete(d.nodes[second].connections, first)
@@ -135,18 +170,54 @@ func (d *Graph) DeleteConnection(first, second int64) bool {
 	return false
 }
 
-// Deletes a node, returning the node index if deleted, -1 if it is already gone.
+// Updates the data stored for a connection, returning true if updated, false if the connection does not exist
+func (d *Graph) UpdateConnection(first, second int64, data interface{}) bool {
+	d.nodesLock.Lock()
+	defer d.nodesLock.Unlock()
+
+	if _, ok := d.nodes[first]; ok {
+		if _, ok = d.nodes[second]; ok {
+			if connection, ok := d.nodes[first].connections[second]; ok {
+				connection.Data = data
+				d.nodes[first].connections[second] = connection
+				d.nodes[second].connections[first] = connection
+
+				d.queueEdit(NewConnectionEdit(
+					Edit,
+					d.nodes[first].data,
+					connection.Id,
+					first,
+					second,
+					data))
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Deletes a node and all of its connections, returning the node index if deleted, -1 if it is already gone.
 func (d *Graph) DeleteNode(nodeIdx int64) int64 {
 	d.nodesLock.Lock()
 	defer d.nodesLock.Unlock()
 
 	if _, ok := d.nodes[nodeIdx]; ok {
-		for destinationNode, connectionData := range d.nodes[nodeIdx].connections {
-			delete(d.connections, connectionData.Id)
+		for _, destinationNode := range d.neighborIds(nodeIdx) {
+			connection := d.nodes[nodeIdx].connections[destinationNode]
+			d.queueEdit(NewConnectionEdit(
+				Delete,
+				d.nodes[nodeIdx].data,
+				connection.Id,
+				nodeIdx,
+				destinationNode,
+				connection.Data))
+
+			delete(d.connections, connection.Id)
 			delete(d.nodes[destinationNode].connections, nodeIdx)
 		}
 
-		d.nodeEditBuffer <- NewNodeEdit(Delete, d.nodes[nodeIdx].data, nodeIdx)
+		d.queueEdit(NewNodeEdit(Delete, d.nodes[nodeIdx].data, nodeIdx))
 		delete(d.nodes, nodeIdx)
 		return nodeIdx
 	}
@@ -163,10 +234,24 @@ func (d *Graph) AddNode(data interface{}) int64 {
 	d.newNodeIndex++
 
 	d.nodes[nodeIdx] = NewNode(data)
-	d.nodeEditBuffer <- NewNodeEdit(Add, d.nodes[nodeIdx].data, nodeIdx)
+	d.queueEdit(NewNodeEdit(Add, d.nodes[nodeIdx].data, nodeIdx))
 	return nodeIdx
 }
 
+// Updates the data stored for a node, returning true if updated, false if the node does not exist
+func (d *Graph) UpdateNode(nodeIdx int64, data interface{}) bool {
+	d.nodesLock.Lock()
+	defer d.nodesLock.Unlock()
+
+	if node, ok := d.nodes[nodeIdx]; ok {
+		node.data = data
+		d.queueEdit(NewNodeEdit(Edit, data, nodeIdx))
+		return true
+	}
+
+	return false
+}
+
 func (d *Graph) GetNode(nodeId int64) interface{} {
 	d.nodesLock.Lock()
 	defer d.nodesLock.Unlock()
@@ -0,0 +1,208 @@
+package graph
+
+import (
+	"sim/core/lifecycle/lifecycletest"
+	"testing"
+	"time"
+)
+
+func int64SliceEquals(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Builds 0 - 1 - 2 - 0 and 3 - 4, with 5 isolated
+func newTestGraph(t *testing.T) *Graph {
+	g := NewGraph(lifecycletest.NewSupervisor(t))
+	for i := 0; i < 6; i++ {
+		g.AddNode(i)
+	}
+
+	g.AddConnection(0, 1, "a")
+	g.AddConnection(1, 2, "b")
+	g.AddConnection(2, 0, "c")
+	g.AddConnection(3, 4, "d")
+	return g
+}
+
+func TestNeighbors(t *testing.T) {
+	g := newTestGraph(t)
+
+	neighbors := g.GetNeighbors(1)
+	if len(neighbors) != 2 || neighbors[0].NodeId != 0 || neighbors[1].NodeId != 2 {
+		t.Error("Node 1 should neighbor nodes 0 and 2")
+	}
+
+	if neighbors[1].ConnectionData != "b" {
+		t.Error("Neighbor connection data should be returned")
+	}
+
+	if g.GetNeighbors(5) == nil || len(g.GetNeighbors(5)) != 0 {
+		t.Error("Isolated nodes have no neighbors")
+	}
+
+	if g.GetNeighbors(42) != nil {
+		t.Error("Missing nodes should return nil")
+	}
+}
+
+func TestConnectedComponents(t *testing.T) {
+	g := newTestGraph(t)
+
+	components := g.GetConnectedComponents()
+	if len(components) != 3 {
+		t.Fatalf("Expected 3 components, found %v", len(components))
+	}
+
+	if !int64SliceEquals(components[0], []int64{0, 1, 2}) ||
+		!int64SliceEquals(components[1], []int64{3, 4}) ||
+		!int64SliceEquals(components[2], []int64{5}) {
+		t.Errorf("Unexpected components %v", components)
+	}
+
+	if !int64SliceEquals(g.GetComponent(4), []int64{3, 4}) {
+		t.Error("Node 4 should be in the 3-4 component")
+	}
+
+	if !g.AreConnected(0, 2) || g.AreConnected(0, 3) {
+		t.Error("Connectivity does not match the components")
+	}
+}
+
+func TestTraversalOrder(t *testing.T) {
+	g := NewGraph(lifecycletest.NewSupervisor(t))
+	for i := 0; i < 5; i++ {
+		g.AddNode(i)
+	}
+
+	// 0 - 1 - 3, 0 - 2 - 4
+	g.AddConnection(0, 1, nil)
+	g.AddConnection(0, 2, nil)
+	g.AddConnection(1, 3, nil)
+	g.AddConnection(2, 4, nil)
+
+	visited := make([]int64, 0)
+	g.Traverse(0, BreadthFirst, func(nodeId int64, data interface{}) bool {
+		visited = append(visited, nodeId)
+		return true
+	})
+
+	if !int64SliceEquals(visited, []int64{0, 1, 2, 3, 4}) {
+		t.Errorf("Unexpected breadth-first order %v", visited)
+	}
+
+	visited = make([]int64, 0)
+	g.Traverse(0, DepthFirst, func(nodeId int64, data interface{}) bool {
+		visited = append(visited, nodeId)
+		return true
+	})
+
+	if !int64SliceEquals(visited, []int64{0, 1, 3, 2, 4}) {
+		t.Errorf("Unexpected depth-first order %v", visited)
+	}
+
+	visited = make([]int64, 0)
+	g.Traverse(0, BreadthFirst, func(nodeId int64, data interface{}) bool {
+		visited = append(visited, nodeId)
+		return len(visited) < 2
+	})
+
+	if len(visited) != 2 {
+		t.Error("Traversal should stop when visit returns false")
+	}
+}
+
+func receiveNodeEdit(t *testing.T, edits chan NodeEdit) NodeEdit {
+	select {
+	case edit := <-edits:
+		return edit
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for a node edit")
+		return NodeEdit{}
+	}
+}
+
+func receiveConnectionEdit(t *testing.T, edits chan ConnectionEdit) ConnectionEdit {
+	select {
+	case edit := <-edits:
+		return edit
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for a connection edit")
+		return ConnectionEdit{}
+	}
+}
+
+func TestEditNotifications(t *testing.T) {
+	g := NewGraph(lifecycletest.NewSupervisor(t))
+	nodeEdits := make(chan NodeEdit, 10)
+	connectionEdits := make(chan ConnectionEdit, 10)
+	g.NodeEditRegChannel <- nodeEdits
+	g.ConnectionEditRegChannel <- connectionEdits
+
+	g.AddNode("first")
+	second := g.AddNode("second")
+	receiveNodeEdit(t, nodeEdits)
+	if edit := receiveNodeEdit(t, nodeEdits); edit.EditType != Add || edit.NodeIdx != second {
+		t.Errorf("Node additions should include the node index, found %v", edit)
+	}
+
+	g.UpdateNode(second, "updated")
+	if edit := receiveNodeEdit(t, nodeEdits); edit.EditType != Edit || edit.NodeIdx != second || edit.Data != "updated" {
+		t.Errorf("Node updates should emit edits, found %v", edit)
+	}
+
+	connectionId := g.AddConnection(0, second, "line").Id
+	receiveConnectionEdit(t, connectionEdits)
+
+	g.UpdateConnection(second, 0, "updated line")
+	if edit := receiveConnectionEdit(t, connectionEdits); edit.EditType != Edit || edit.ConnectionIdx != connectionId {
+		t.Errorf("Connection updates should emit edits, found %v", edit)
+	}
+
+	if g.GetConnection(connectionId) != "updated line" {
+		t.Error("Connection updates should be retrievable")
+	}
+
+	g.DeleteNode(second)
+	if edit := receiveConnectionEdit(t, connectionEdits); edit.EditType != Delete || edit.ConnectionIdx != connectionId || edit.ConnectionData != "updated line" {
+		t.Errorf("Deleting a node should delete its connections, found %v", edit)
+	}
+
+	if edit := receiveNodeEdit(t, nodeEdits); edit.EditType != Delete || edit.NodeIdx != second {
+		t.Errorf("Node deletions should include the node index, found %v", edit)
+	}
+}
+
+func TestSlowEditSubscriber(t *testing.T) {
+	g := NewGraph(lifecycletest.NewSupervisor(t))
+	slowEdits := make(chan NodeEdit)
+	g.NodeEditRegChannel <- slowEdits
+
+	for i := 0; i < 20; i++ {
+		g.AddNode(i)
+	}
+
+	// Subscribers registering later, and their edits, are not held up by one that is not reading
+	fastEdits := make(chan NodeEdit, 1)
+	g.NodeEditRegChannel <- fastEdits
+	node := g.AddNode("fast")
+	if edit := receiveNodeEdit(t, fastEdits); edit.NodeIdx != node {
+		t.Errorf("Expected the edit adding node %v, found %v", node, edit)
+	}
+
+	// Nothing is lost while the slow subscriber lags
+	for i := int64(0); i <= node; i++ {
+		if edit := receiveNodeEdit(t, slowEdits); edit.NodeIdx != i {
+			t.Fatalf("Expected the edit adding node %v, found %v", i, edit)
+		}
+	}
+}
@@ -18,6 +18,7 @@ type NodeEdit struct {
 func NewNodeEdit(editType EditType, data interface{}, nodeIdx int64) NodeEdit {
 	return NodeEdit{
 		EditType: editType,
+		NodeIdx:  nodeIdx,
 		Data:     data}
 }
 
@@ -0,0 +1,216 @@
+package graph
+
+import "sort"
+
+type TraversalOrder int
+
+const (
+	BreadthFirst TraversalOrder = iota
+	DepthFirst
+)
+
+// Defines a connection from a node to one of its neighbors
+type Neighbor struct {
+	NodeId         int64
+	ConnectionId   int64
+	ConnectionData interface{}
+}
+
+func sortIds(ids []int64) []int64 {
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// Returns the neighbor IDs of a node, in ascending order. Assumes nodesLock is held.
+func (d *Graph) neighborIds(nodeId int64) []int64 {
+	neighbors := make([]int64, 0, len(d.nodes[nodeId].connections))
+	for neighborId := range d.nodes[nodeId].connections {
+		neighbors = append(neighbors, neighborId)
+	}
+
+	return sortIds(neighbors)
+}
+
+// Returns the nodes reachable from start in traversal order. Assumes nodesLock is held.
+func (d *Graph) traverse(start int64, order TraversalOrder) []int64 {
+	if _, ok := d.nodes[start]; !ok {
+		return nil
+	}
+
+	visited := make(map[int64]bool)
+	visitOrder := make([]int64, 0)
+
+	if order == DepthFirst {
+		pending := []int64{start}
+		for len(pending) > 0 {
+			nodeId := pending[len(pending)-1]
+			pending = pending[:len(pending)-1]
+			if visited[nodeId] {
+				continue
+			}
+
+			visited[nodeId] = true
+			visitOrder = append(visitOrder, nodeId)
+
+			// Push in reverse so the lowest ID is visited first.
+			neighbors := d.neighborIds(nodeId)
+			for i := len(neighbors) - 1; i >= 0; i-- {
+				if !visited[neighbors[i]] {
+					pending = append(pending, neighbors[i])
+				}
+			}
+		}
+	} else {
+		visited[start] = true
+		pending := []int64{start}
+		for len(pending) > 0 {
+			nodeId := pending[0]
+			pending = pending[1:]
+			visitOrder = append(visitOrder, nodeId)
+
+			for _, neighborId := range d.neighborIds(nodeId) {
+				if !visited[neighborId] {
+					visited[neighborId] = true
+					pending = append(pending, neighborId)
+				}
+			}
+		}
+	}
+
+	return visitOrder
+}
+
+// Returns all node IDs, in ascending order
+func (d *Graph) GetNodeIds() []int64 {
+	d.nodesLock.Lock()
+	defer d.nodesLock.Unlock()
+
+	nodeIds := make([]int64, 0, len(d.nodes))
+	for nodeId := range d.nodes {
+		nodeIds = append(nodeIds, nodeId)
+	}
+
+	return sortIds(nodeIds)
+}
+
+// Returns all connection IDs, in ascending order
+func (d *Graph) GetConnectionIds() []int64 {
+	d.nodesLock.Lock()
+	defer d.nodesLock.Unlock()
+
+	connectionIds := make([]int64, 0, len(d.connections))
+	for connectionId := range d.connections {
+		connectionIds = append(connectionIds, connectionId)
+	}
+
+	return sortIds(connectionIds)
+}
+
+// Returns the nodes on each end of a connection, or false if the connection does not exist
+func (d *Graph) GetConnectionNodes(connectionId int64) (first, second int64, ok bool) {
+	d.nodesLock.Lock()
+	defer d.nodesLock.Unlock()
+
+	connection := d.connections[connectionId]
+	if connection == nil {
+		return -1, -1, false
+	}
+
+	return connection.First, connection.Second, true
+}
+
+// Returns the neighbors of a node, ordered by neighbor ID. Returns nil if the node does not exist.
+func (d *Graph) GetNeighbors(nodeId int64) []Neighbor {
+	d.nodesLock.Lock()
+	defer d.nodesLock.Unlock()
+
+	if _, ok := d.nodes[nodeId]; !ok {
+		return nil
+	}
+
+	neighbors := make([]Neighbor, 0, len(d.nodes[nodeId].connections))
+	for _, neighborId := range d.neighborIds(nodeId) {
+		connection := d.nodes[nodeId].connections[neighborId]
+		neighbors = append(neighbors, Neighbor{
+			NodeId:         neighborId,
+			ConnectionId:   connection.Id,
+			ConnectionData: connection.Data})
+	}
+
+	return neighbors
+}
+
+// Visits every node reachable from start in the given order, stopping early if visit returns false.
+// The traversal is computed against a consistent view of the graph,
+// so visit may safely call back into the graph.
+func (d *Graph) Traverse(start int64, order TraversalOrder, visit func(nodeId int64, data interface{}) bool) {
+	d.nodesLock.Lock()
+	visitOrder := d.traverse(start, order)
+	visitData := make([]interface{}, len(visitOrder))
+	for idx, nodeId := range visitOrder {
+		visitData[idx] = d.nodes[nodeId].data
+	}
+	d.nodesLock.Unlock()
+
+	for idx, nodeId := range visitOrder {
+		if !visit(nodeId, visitData[idx]) {
+			return
+		}
+	}
+}
+
+// Returns the IDs of all nodes in the same connected component as the given node, in ascending order.
+// Returns nil if the node does not exist.
+func (d *Graph) GetComponent(nodeId int64) []int64 {
+	d.nodesLock.Lock()
+	defer d.nodesLock.Unlock()
+
+	return sortIds(d.traverse(nodeId, BreadthFirst))
+}
+
+// Returns true if a path exists between both nodes
+func (d *Graph) AreConnected(first, second int64) bool {
+	d.nodesLock.Lock()
+	defer d.nodesLock.Unlock()
+
+	if _, ok := d.nodes[second]; !ok {
+		return false
+	}
+
+	for _, nodeId := range d.traverse(first, BreadthFirst) {
+		if nodeId == second {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns every connected component in the graph.
+// Each component is in ascending ID order, and components are ordered by their lowest ID.
+func (d *Graph) GetConnectedComponents() [][]int64 {
+	d.nodesLock.Lock()
+	defer d.nodesLock.Unlock()
+
+	nodeIds := make([]int64, 0, len(d.nodes))
+	for nodeId := range d.nodes {
+		nodeIds = append(nodeIds, nodeId)
+	}
+
+	components := make([][]int64, 0)
+	assigned := make(map[int64]bool)
+	for _, nodeId := range sortIds(nodeIds) {
+		if assigned[nodeId] {
+			continue
+		}
+
+		component := sortIds(d.traverse(nodeId, BreadthFirst))
+		for _, componentNodeId := range component {
+			assigned[componentNodeId] = true
+		}
+
+		components = append(components, component)
+	}
+
+	return components
+}
@@ -0,0 +1,20 @@
+package lifecycletest
+
+import (
+	"context"
+	"sim/core/lifecycle"
+	"testing"
+	"time"
+)
+
+// Creates a supervisor that is shut down, and checked for clean shutdown, when the test ends
+func NewSupervisor(t testing.TB) *lifecycle.Supervisor {
+	supervisor := lifecycle.NewSupervisor(context.Background())
+	t.Cleanup(func() {
+		if err := supervisor.Shutdown(time.Second); err != nil {
+			t.Error(err)
+		}
+	})
+
+	return supervisor
+}
@@ -0,0 +1,148 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defines a supervisor that starts agents and shuts them all down together.
+// Each agent runs until its context is done, which happens on shutdown or when the agent is stopped individually.
+type Supervisor struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	lock        sync.Mutex
+	nextAgentId int64
+	running     map[int64]string
+	allStopped  chan bool
+}
+
+func NewSupervisor(parent context.Context) *Supervisor {
+	ctx, cancel := context.WithCancel(parent)
+	return &Supervisor{
+		ctx:     ctx,
+		cancel:  cancel,
+		running: make(map[int64]string)}
+}
+
+// Returns the context shared by every agent, which is done once shutdown starts.
+// Suitable for components that send to agents without being agents themselves.
+func (s *Supervisor) Context() context.Context {
+	return s.ctx
+}
+
+// Starts an agent, returning a function that stops just that agent.
+// Agents started after shutdown receive a context that is already done.
+func (s *Supervisor) Go(name string, run func(ctx context.Context)) context.CancelFunc {
+	ctx, cancel := context.WithCancel(s.ctx)
+
+	s.lock.Lock()
+	agentId := s.nextAgentId
+	s.nextAgentId++
+	s.running[agentId] = name
+	s.lock.Unlock()
+
+	go func() {
+		defer s.finished(agentId)
+		defer cancel()
+		run(ctx)
+	}()
+
+	return cancel
+}
+
+func (s *Supervisor) finished(agentId int64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.running, agentId)
+	if len(s.running) == 0 && s.allStopped != nil {
+		close(s.allStopped)
+		s.allStopped = nil
+	}
+}
+
+// Returns the names of all running agents, sorted and with duplicates counted
+func (s *Supervisor) Running() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.getRunningNames()
+}
+
+func (s *Supervisor) getRunningNames() []string {
+	counts := make(map[string]int)
+	for _, name := range s.running {
+		counts[name]++
+	}
+
+	names := make([]string, 0, len(counts))
+	for name, count := range counts {
+		if count > 1 {
+			names = append(names, fmt.Sprintf("%v (x%v)", name, count))
+		} else {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// Stops every agent, waiting up to the timeout for them to exit.
+// Returns an error naming the agents that did not exit in time.
+func (s *Supervisor) Shutdown(timeout time.Duration) error {
+	s.cancel()
+
+	s.lock.Lock()
+	if len(s.running) == 0 {
+		s.lock.Unlock()
+		return nil
+	}
+
+	if s.allStopped == nil {
+		s.allStopped = make(chan bool)
+	}
+
+	allStopped := s.allStopped
+	s.lock.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-allStopped:
+		return nil
+	case <-timer.C:
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		return fmt.Errorf("agents did not stop within %v: %v", timeout, strings.Join(s.getRunningNames(), ", "))
+	}
+}
+
+// Sends an item to a channel, giving up if the context is done first.
+// Returns true if the item was sent.
+func Send[T any](ctx context.Context, channel chan<- T, item T) bool {
+	select {
+	case channel <- item:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Receives an item from a channel, giving up if the context is done first.
+// Returns false if nothing was received.
+func Receive[T any](ctx context.Context, channel <-chan T) (T, bool) {
+	select {
+	case item, ok := <-channel:
+		return item, ok
+	case <-ctx.Done():
+		var empty T
+		return empty, false
+	}
+}
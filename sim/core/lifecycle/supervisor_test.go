@@ -0,0 +1,94 @@
+package lifecycle
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestShutdownStopsAgents(t *testing.T) {
+	s := NewSupervisor(context.Background())
+
+	stopped := make(chan bool, 10)
+	for i := 0; i < 10; i++ {
+		s.Go("agent", func(ctx context.Context) {
+			<-ctx.Done()
+			stopped <- true
+		})
+	}
+
+	if running := s.Running(); len(running) != 1 || running[0] != "agent (x10)" {
+		t.Errorf("Unexpected running agents %v", running)
+	}
+
+	if err := s.Shutdown(time.Second); err != nil {
+		t.Fatalf("All agents should stop, found '%v'", err)
+	}
+
+	if len(stopped) != 10 || len(s.Running()) != 0 {
+		t.Error("Shutdown should wait for every agent to exit")
+	}
+}
+
+func TestShutdownReportsStuckAgents(t *testing.T) {
+	s := NewSupervisor(context.Background())
+
+	release := make(chan bool)
+	defer close(release)
+
+	s.Go("stuck", func(ctx context.Context) {
+		<-release
+	})
+	s.Go("well-behaved", func(ctx context.Context) {
+		<-ctx.Done()
+	})
+
+	err := s.Shutdown(10 * time.Millisecond)
+	if err == nil || err.Error() != "agents did not stop within 10ms: stuck" {
+		t.Errorf("Stuck agents should be reported, found '%v'", err)
+	}
+}
+
+func TestStopSingleAgent(t *testing.T) {
+	s := NewSupervisor(context.Background())
+	defer s.Shutdown(time.Second)
+
+	stopped := make(chan bool)
+	stop := s.Go("single", func(ctx context.Context) {
+		<-ctx.Done()
+		close(stopped)
+	})
+	s.Go("other", func(ctx context.Context) {
+		<-ctx.Done()
+	})
+
+	stop()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Stopping an agent should cancel its context")
+	}
+
+	// The agent is only removed once its run function has returned
+	deadline := time.Now().Add(time.Second)
+	for len(s.Running()) != 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	if running := s.Running(); len(running) != 1 || running[0] != "other" {
+		t.Errorf("Other agents should keep running, found %v", running)
+	}
+}
+
+func TestSendGivesUpOnShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if Send(ctx, make(chan int), 1) {
+		t.Error("Sends should not complete once the context is done")
+	}
+
+	if !Send(context.Background(), make(chan int, 1), 1) {
+		t.Error("Sends with room should complete")
+	}
+}
@@ -0,0 +1,257 @@
+package mailroom
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sim/core/lifecycle"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How long a send waits for a missing provider before complaining about it
+const providerWaitWarning = time.Second
+
+// Defines a named channel in the mailroom, provided by one component and used by any number of others.
+type Mailbox[T any] struct {
+	name     string
+	optional bool
+
+	lock     sync.Mutex
+	provider string
+	channel  chan T
+	provided chan bool
+	clients  map[string]int
+}
+
+// Defines how a single mailbox is wired up
+type Wiring struct {
+	Name     string
+	Type     string
+	Optional bool
+	Provider string
+	Clients  map[string]int // Client name to the number of instances that declared it
+}
+
+type wiringSource interface {
+	getWiring() Wiring
+}
+
+type registry struct {
+	lock      sync.Mutex
+	mailboxes []wiringSource
+}
+
+var defaultRegistry registry
+
+func newMailboxIn[T any](r *registry, name string, optional bool) *Mailbox[T] {
+	mailbox := Mailbox[T]{
+		name:     name,
+		optional: optional,
+		provided: make(chan bool),
+		clients:  make(map[string]int)}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.mailboxes = append(r.mailboxes, &mailbox)
+
+	return &mailbox
+}
+
+func newMailbox[T any](name string) *Mailbox[T] {
+	return newMailboxIn[T](&defaultRegistry, name, false)
+}
+
+// Creates a mailbox that is not required to have any clients
+func newOptionalMailbox[T any](name string) *Mailbox[T] {
+	return newMailboxIn[T](&defaultRegistry, name, true)
+}
+
+// Provides the channel behind this mailbox. Each mailbox can only be provided once.
+func (m *Mailbox[T]) Provide(provider string, channel chan T) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.channel != nil {
+		panic(fmt.Sprintf("Mailbox '%v' is provided by '%v', it cannot also be provided by '%v'.", m.name, m.provider, provider))
+	}
+
+	if channel == nil {
+		panic(fmt.Sprintf("Mailbox '%v' cannot be provided with a nil channel by '%v'.", m.name, provider))
+	}
+
+	m.provider = provider
+	m.channel = channel
+	close(m.provided)
+}
+
+// Declares that a client uses this mailbox, returning the mailbox for convenience.
+// Clients should declare themselves when they are created, so that wiring can be validated at startup.
+func (m *Mailbox[T]) Use(client string) *Mailbox[T] {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.clients[client]++
+	return m
+}
+
+// Returns the channel behind this mailbox, waiting for it to be provided if necessary.
+func (m *Mailbox[T]) Channel() chan T {
+	m.waitForProvider(context.Background())
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.channel
+}
+
+// Waits for the mailbox to be provided, returning false if the context is done first.
+func (m *Mailbox[T]) waitForProvider(ctx context.Context) bool {
+	select {
+	case <-m.provided:
+		return true
+	default:
+	}
+
+	timer := time.NewTimer(providerWaitWarning)
+	defer timer.Stop()
+
+	select {
+	case <-m.provided:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		fmt.Printf("Mailbox '%v' has not been provided after %v, waiting for a provider...\n", m.name, providerWaitWarning)
+	}
+
+	select {
+	case <-m.provided:
+		fmt.Printf("Mailbox '%v' was provided by '%v'.\n", m.name, m.getProvider())
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (m *Mailbox[T]) getProvider() string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.provider
+}
+
+// Sends an item through this mailbox, waiting for it to be provided if necessary.
+func (m *Mailbox[T]) Send(item T) {
+	m.Channel() <- item
+}
+
+// Sends an item through this mailbox, giving up if the context is done first.
+// Returns true if the item was sent.
+func (m *Mailbox[T]) SendContext(ctx context.Context, item T) bool {
+	if !m.waitForProvider(ctx) {
+		return false
+	}
+
+	return lifecycle.Send(ctx, m.Channel(), item)
+}
+
+func (m *Mailbox[T]) getWiring() Wiring {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	clients := make(map[string]int, len(m.clients))
+	for client, count := range m.clients {
+		clients[client] = count
+	}
+
+	return Wiring{
+		Name:     m.name,
+		Type:     reflect.TypeOf(m.channel).String(),
+		Optional: m.optional,
+		Provider: m.provider,
+		Clients:  clients}
+}
+
+func (r *registry) getWiring() []Wiring {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	wiring := make([]Wiring, len(r.mailboxes))
+	for idx, mailbox := range r.mailboxes {
+		wiring[idx] = mailbox.getWiring()
+	}
+
+	return wiring
+}
+
+func (r *registry) validate() error {
+	problems := make([]string, 0)
+	for _, mailbox := range r.getWiring() {
+		if mailbox.Provider == "" {
+			problems = append(problems, fmt.Sprintf("'%v' has no provider (used by %v)", mailbox.Name, formatClients(mailbox.Clients)))
+		} else if len(mailbox.Clients) == 0 && !mailbox.Optional {
+			problems = append(problems, fmt.Sprintf("'%v' has no clients (provided by %v)", mailbox.Name, mailbox.Provider))
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New("invalid mailroom wiring: " + strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+func (r *registry) dump() string {
+	var builder strings.Builder
+	for _, mailbox := range r.getWiring() {
+		provider := mailbox.Provider
+		if provider == "" {
+			provider = "(none)"
+		}
+
+		fmt.Fprintf(&builder, "%v %v\n", mailbox.Name, mailbox.Type)
+		fmt.Fprintf(&builder, "  provided by: %v\n", provider)
+		fmt.Fprintf(&builder, "  used by: %v\n", formatClients(mailbox.Clients))
+		if mailbox.Optional {
+			fmt.Fprintf(&builder, "  (optional)\n")
+		}
+	}
+
+	return builder.String()
+}
+
+func formatClients(clients map[string]int) string {
+	if len(clients) == 0 {
+		return "(none)"
+	}
+
+	names := make([]string, 0, len(clients))
+	for client, count := range clients {
+		if count > 1 {
+			names = append(names, fmt.Sprintf("%v (x%v)", client, count))
+		} else {
+			names = append(names, client)
+		}
+	}
+
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// Returns how every mailbox is wired up, in declaration order
+func GetWiring() []Wiring {
+	return defaultRegistry.getWiring()
+}
+
+// Validates that every mailbox has a provider and, unless optional, at least one client.
+// Should be called once all components have been created.
+func Validate() error {
+	return defaultRegistry.validate()
+}
+
+// Returns a human-readable dump of the mailroom wiring graph
+func DumpWiring() string {
+	return defaultRegistry.dump()
+}
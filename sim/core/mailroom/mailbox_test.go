@@ -0,0 +1,74 @@
+package mailroom
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateReportsMissingWiring(t *testing.T) {
+	var r registry
+	unprovided := newMailboxIn[int](&r, "Unprovided", false)
+	unused := newMailboxIn[int](&r, "Unused", false)
+	newMailboxIn[int](&r, "Optional", true).Provide("provider", make(chan int))
+
+	unprovided.Use("client")
+	unused.Provide("provider", make(chan int))
+
+	err := r.validate()
+	if err == nil {
+		t.Fatal("Missing providers and clients should fail validation")
+	}
+
+	if !strings.Contains(err.Error(), "'Unprovided' has no provider (used by client)") ||
+		!strings.Contains(err.Error(), "'Unused' has no clients (provided by provider)") {
+		t.Errorf("Unexpected validation error '%v'", err)
+	}
+
+	if strings.Contains(err.Error(), "Optional") {
+		t.Error("Optional mailboxes do not need clients")
+	}
+
+	unprovided.Provide("provider", make(chan int))
+	unused.Use("client")
+	if err := r.validate(); err != nil {
+		t.Errorf("Complete wiring should be valid, found '%v'", err)
+	}
+}
+
+func TestSendWaitsForProvider(t *testing.T) {
+	var r registry
+	mailbox := newMailboxIn[int](&r, "Delayed", false)
+
+	sent := make(chan bool)
+	go func() {
+		mailbox.Use("client").Send(42)
+		sent <- true
+	}()
+
+	channel := make(chan int, 1)
+	mailbox.Provide("provider", channel)
+
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Fatal("Sends should complete once the mailbox is provided")
+	}
+
+	if <-channel != 42 {
+		t.Error("Items should be sent through the provided channel")
+	}
+}
+
+func TestDumpWiring(t *testing.T) {
+	var r registry
+	mailbox := newMailboxIn[chan int](&r, "Registration", false)
+	mailbox.Provide("provider", make(chan chan int))
+	mailbox.Use("client")
+	mailbox.Use("client")
+
+	dump := r.dump()
+	if !strings.Contains(dump, "Registration chan chan int") || !strings.Contains(dump, "used by: client (x2)") {
+		t.Errorf("Unexpected wiring dump '%v'", dump)
+	}
+}
@@ -2,8 +2,10 @@ package mailroom
 
 import (
 	"common/commonmath"
+	"sim/core/dto/buildingdto"
 	"sim/core/dto/editorengdto"
 	"sim/core/dto/geometry"
+	"sim/core/dto/powerdto"
 	"sim/core/dto/terraindto"
 	"sim/core/dto/vehicledto"
 	"sim/engine/core/dto"
@@ -13,51 +15,64 @@ import (
 )
 
 // Defines the mailroom designed to easily connect the core channels making up the game engine
-// This does make it slightly harder to ensure proper first-time setup, but significantly simplifies doing that setup.
+// Each mailbox is provided by one component and used by others, in any order. Call Validate once setup is complete.
 
 // Generic input
-var MousePressedRegChannel chan chan glfw.MouseButton
-var MouseReleasedRegChannel chan chan glfw.MouseButton
+var MousePressedRegChannel = newMailbox[chan glfw.MouseButton]("MousePressedRegChannel")
+var MouseReleasedRegChannel = newMailbox[chan glfw.MouseButton]("MouseReleasedRegChannel")
 
 // Camera
-var CameraOffsetRegChannel chan chan mgl32.Vec2
-var CameraScaleRegChannel chan chan float32
-var BoardPosChangeRegChannel chan chan mgl32.Vec2
+var CameraOffsetRegChannel = newMailbox[chan mgl32.Vec2]("CameraOffsetRegChannel")
+var CameraScaleRegChannel = newMailbox[chan float32]("CameraScaleRegChannel")
+var BoardPosChangeRegChannel = newMailbox[chan mgl32.Vec2]("BoardPosChangeRegChannel")
 
 // Terrain
-var NewTerrainRegChannel chan chan *terraindto.TerrainUpdate
-var NewRegionRegChannel chan chan commonMath.IntVec2
+var NewTerrainRegChannel = newMailbox[chan *terraindto.TerrainUpdate]("NewTerrainRegChannel")
+var NewRegionRegChannel = newMailbox[chan commonMath.IntVec2]("NewRegionRegChannel")
 
 // Editor engine
-var EngineModeRegChannel chan chan editorengdto.EditorMode
-var EngineAddModeRegChannel chan chan editorengdto.EditorAddMode
-var EngineDrawModeRegChannel chan chan editorengdto.EditorDrawMode
-var SnapSettingsRegChannel chan chan editorengdto.SnapSetting
-var EngineCancelChannel chan chan bool
+var EngineModeRegChannel = newMailbox[chan editorengdto.EditorMode]("EngineModeRegChannel")
+var EngineAddModeRegChannel = newMailbox[chan editorengdto.EditorAddMode]("EngineAddModeRegChannel")
+var EngineDrawModeRegChannel = newMailbox[chan editorengdto.EditorDrawMode]("EngineDrawModeRegChannel")
+var EngineItemSubSelectionRegChannel = newMailbox[chan editorengdto.ItemSubSelection]("EngineItemSubSelectionRegChannel")
+var SnapSettingsRegChannel = newMailbox[chan editorengdto.SnapSetting]("SnapSettingsRegChannel")
+var EnginePlacementRegChannel = newMailbox[chan editorengdto.PlacementSetting]("EnginePlacementRegChannel")
+var EngineOverpassRegChannel = newMailbox[chan bool]("EngineOverpassRegChannel")
+var EngineCancelChannel = newMailbox[chan bool]("EngineCancelChannel")
 
 // Engine temporal updates
-var CoreTimerRegChannel chan chan dto.Time
+var CoreTimerRegChannel = newMailbox[chan dto.Time]("CoreTimerRegChannel")
+var CoreTimerUnregChannel = newMailbox[chan dto.Time]("CoreTimerUnregChannel")
+
+// Power
+var PowerFlowRegChannel = newMailbox[chan powerdto.PowerFlow]("PowerFlowRegChannel")
+var PowerReportChannel = newMailbox[powerdto.PowerReportQuery]("PowerReportChannel")
 
 // --- Rendering ---
 // Power
-var NewPowerLineChannel chan geometry.IdLine
-var DeletePowerLineChannel chan int64
+var NewPowerLineChannel = newMailbox[geometry.IdLine]("NewPowerLineChannel")
+var DeletePowerLineChannel = newMailbox[int64]("DeletePowerLineChannel")
 
-var NewPowerPlantChannel chan geometry.IdRegion
-var DeletePowerPlantChannel chan int64
+var NewPowerPlantChannel = newMailbox[geometry.IdRegion]("NewPowerPlantChannel")
+var DeletePowerPlantChannel = newMailbox[int64]("DeletePowerPlantChannel")
 
 // Road Lines
-var NewRoadLineChannel chan geometry.IdLine
-var DeleteRoadLineChannel chan int64
+var NewRoadLineChannel = newMailbox[geometry.IdLine]("NewRoadLineChannel")
+var DeleteRoadLineChannel = newMailbox[int64]("DeleteRoadLineChannel")
+
+// Buildings
+var NewBuildingChannel = newMailbox[geometry.IdRegion]("NewBuildingChannel")
+var DeleteBuildingChannel = newMailbox[int64]("DeleteBuildingChannel")
+var BuildingPowerStateChannel = newMailbox[buildingdto.PowerStateUpdate]("BuildingPowerStateChannel")
 
 // Vehicles
-var VehicleUpdateChannel chan vehicledto.VehicleUpdate
-var NewRoadLineIdChannel chan geometry.IdOnlyLine
-var NewRoadTerminusChannel chan geometry.IdPoint
+var VehicleUpdateChannel = newMailbox[vehicledto.VehicleUpdate]("VehicleUpdateChannel")
+var VehicleDeletionChannel = newMailbox[int64]("VehicleDeletionChannel")
+var NewRoadLineIdChannel = newMailbox[geometry.IdOnlyLine]("NewRoadLineIdChannel")
+var NewRoadTerminusChannel = newMailbox[geometry.IdPoint]("NewRoadTerminusChannel")
 
-// Snap nodes
-var SnappedNodesUpdateChannel chan []mgl32.Vec2
+// Placement previews
+var PlacementPreviewChannel = newMailbox[editorengdto.PlacementPreview]("PlacementPreviewChannel")
 
-func Init() {
-	// Future implementation
-}
+// Snap nodes
+var SnappedNodesUpdateChannel = newMailbox[[]mgl32.Vec2]("SnappedNodesUpdateChannel")
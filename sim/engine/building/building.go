@@ -1,6 +1,12 @@
 package building
 
 import (
+	"common/commonmath"
+	"sim/config"
+	"sim/core/dto/buildingdto"
+	"sim/core/dto/powerdto"
+	"sim/engine/finder"
+
 	"github.com/go-gl/mathgl/mgl32"
 )
 
@@ -12,5 +18,83 @@ type Building struct {
 	buildingType    string
 	storedResources map[string]float32
 
-	gridId int
+	// The building is a consumer on the power grid with this node ID
+	gridId int64
+
+	powerState  buildingdto.PowerState
+	powerFactor float32 // Fraction of the power demand served
+}
+
+// Gets the region a building would cover if placed at the position
+func GetBuildingRegion(pos mgl32.Vec2, buildingType config.Building, orientation float32) commonMath.Region {
+	return commonMath.Region{
+		RegionType:  commonMath.SquareRegion,
+		Position:    pos,
+		Scale:       float32(buildingType.Size),
+		Orientation: orientation}
+}
+
+func NewBuilding(gridId int64, region commonMath.Region, buildingType config.Building) *Building {
+	return &Building{
+		location:        region.Position,
+		size:            region.Scale,
+		orientation:     region.Orientation,
+		buildingType:    buildingType.Name,
+		storedResources: make(map[string]float32),
+		gridId:          gridId,
+		powerState:      buildingdto.Blackout,
+		powerFactor:     0}
+}
+
+func (b *Building) GetRegion() *commonMath.Region {
+	return &commonMath.Region{
+		RegionType:  commonMath.SquareRegion,
+		Position:    b.location,
+		Scale:       b.size,
+		Orientation: b.orientation}
+}
+
+// Gets the area of the map covered by the building
+func (b *Building) GetFootprint() finder.Footprint {
+	return finder.NewRegionFootprint(*b.GetRegion())
+}
+
+// Updates how well the building is powered, returning true if the power state changed
+func (b *Building) updatePower(flow powerdto.ConsumerFlow) bool {
+	previousState := b.powerState
+	if flow.Demand == 0 || flow.Served >= flow.Demand {
+		b.powerState = buildingdto.Powered
+		b.powerFactor = 1
+	} else if flow.Served == 0 {
+		b.powerState = buildingdto.Blackout
+		b.powerFactor = 0
+	} else {
+		b.powerState = buildingdto.Brownout
+		b.powerFactor = float32(flow.Served) / float32(flow.Demand)
+	}
+
+	return b.powerState != previousState
+}
+
+// Performs a day of production, scaled by how well the building is powered and limited by the inputs stored.
+func (b *Building) produce(buildingType config.Building) {
+	factor := b.powerFactor
+	for _, input := range buildingType.Inputs {
+		if input.Amount > 0 {
+			factor = min(factor, b.storedResources[input.ResourceType]/input.Amount)
+		}
+	}
+
+	for _, input := range buildingType.Inputs {
+		b.storedResources[input.ResourceType] -= input.Amount * factor
+	}
+
+	for _, output := range buildingType.Outputs {
+		stored := b.storedResources[output.ResourceType] + output.Amount*factor
+		if capacity, ok := buildingType.StorageCapacity[output.ResourceType]; ok {
+			stored = min(stored, capacity)
+		}
+
+		b.storedResources[output.ResourceType] = stored
+	}
 }
@@ -0,0 +1,65 @@
+package building
+
+import (
+	"sim/config"
+	"sim/core/dto/buildingdto"
+	"sim/core/dto/powerdto"
+	"sim/engine/resource"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestUpdatePower(t *testing.T) {
+	building := NewBuilding(0, GetBuildingRegion(mgl32.Vec2{0, 0}, config.Building{Name: "Farm", Size: 10}, 0), config.Building{Name: "Farm", Size: 10})
+	if building.powerState != buildingdto.Blackout {
+		t.Errorf("New buildings should have no power, found %v", building.powerState)
+	}
+
+	if !building.updatePower(powerdto.ConsumerFlow{Demand: 100, Served: 100}) || building.powerState != buildingdto.Powered || building.powerFactor != 1 {
+		t.Errorf("Fully served buildings should be powered, found %v", building.powerState)
+	}
+
+	if !building.updatePower(powerdto.ConsumerFlow{Demand: 100, Served: 25}) || building.powerState != buildingdto.Brownout || building.powerFactor != 0.25 {
+		t.Errorf("Partially served buildings should be in a brownout, found %v at %v", building.powerState, building.powerFactor)
+	}
+
+	if building.updatePower(powerdto.ConsumerFlow{Demand: 100, Served: 50}) || building.powerFactor != 0.5 {
+		t.Errorf("Changes within a brownout should not change the power state, found %v at %v", building.powerState, building.powerFactor)
+	}
+
+	if !building.updatePower(powerdto.ConsumerFlow{Demand: 100, Served: 0}) || building.powerState != buildingdto.Blackout {
+		t.Errorf("Unserved buildings should be in a blackout, found %v", building.powerState)
+	}
+}
+
+func TestProduce(t *testing.T) {
+	buildingType := config.Building{
+		Name:            "Store",
+		Size:            10,
+		Inputs:          []resource.ResourceAmount{{ResourceType: "Produce", Amount: 50}},
+		Outputs:         []resource.ResourceAmount{{ResourceType: "Biomass", Amount: 10}},
+		StorageCapacity: map[string]float32{"Produce": 100, "Biomass": 12}}
+
+	building := NewBuilding(0, GetBuildingRegion(mgl32.Vec2{0, 0}, buildingType, 0), buildingType)
+	building.storedResources["Produce"] = 100
+	building.produce(buildingType)
+	if building.storedResources["Biomass"] != 0 || building.storedResources["Produce"] != 100 {
+		t.Errorf("Buildings without power should not produce, found %v", building.storedResources)
+	}
+
+	// A brownout cuts production
+	building.updatePower(powerdto.ConsumerFlow{Demand: 100, Served: 50})
+	building.produce(buildingType)
+	if building.storedResources["Biomass"] != 5 || building.storedResources["Produce"] != 75 {
+		t.Errorf("Buildings in a brownout should produce at half rate, found %v", building.storedResources)
+	}
+
+	// Production is limited by the inputs stored and the storage capacity
+	building.updatePower(powerdto.ConsumerFlow{Demand: 100, Served: 100})
+	building.storedResources["Produce"] = 40
+	building.produce(buildingType)
+	if building.storedResources["Biomass"] != 12 || building.storedResources["Produce"] != 0 {
+		t.Errorf("Production should be limited by inputs and storage, found %v", building.storedResources)
+	}
+}
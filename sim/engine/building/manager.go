@@ -0,0 +1,155 @@
+package building
+
+import (
+	"common/commonmath"
+	"context"
+	"fmt"
+	"sim/config"
+	"sim/core/dto/buildingdto"
+	"sim/core/dto/geometry"
+	"sim/core/dto/powerdto"
+	"sim/core/lifecycle"
+	"sim/core/mailroom"
+	"sim/engine/core/dto"
+	"sim/engine/finder"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Requests removing a building, which is sent on the result channel, or nil if it was already removed
+type buildingDeletion struct {
+	gridId int64
+	Result chan *Building
+}
+
+// Tracks placed buildings, updating how well they are powered and running their daily production
+type BuildingManager struct {
+	supervisor *lifecycle.Supervisor
+	finder     *finder.ElementFinder
+
+	buildings map[int64]*Building
+	lastDay   int
+
+	// Unbuffered, so buildings are always tracked before they can be deleted
+	addChannel         chan *Building
+	deleteChannel      chan buildingDeletion
+	powerFlowChannel   chan powerdto.PowerFlow
+	TimerUpdateChannel chan dto.Time
+}
+
+func NewBuildingManager(supervisor *lifecycle.Supervisor, elementFinder *finder.ElementFinder) *BuildingManager {
+	manager := BuildingManager{
+		supervisor:         supervisor,
+		finder:             elementFinder,
+		buildings:          make(map[int64]*Building),
+		lastDay:            0,
+		addChannel:         make(chan *Building),
+		deleteChannel:      make(chan buildingDeletion),
+		powerFlowChannel:   make(chan powerdto.PowerFlow, 2),
+		TimerUpdateChannel: make(chan dto.Time, 3)}
+
+	mailroom.NewBuildingChannel.Use("building.BuildingManager")
+	mailroom.DeleteBuildingChannel.Use("building.BuildingManager")
+	mailroom.BuildingPowerStateChannel.Use("building.BuildingManager")
+	mailroom.CoreTimerRegChannel.Use("building.BuildingManager")
+	mailroom.PowerFlowRegChannel.Use("building.BuildingManager").Send(manager.powerFlowChannel)
+
+	supervisor.Go("building.BuildingManager", manager.run)
+	mailroom.CoreTimerRegChannel.SendContext(supervisor.Context(), manager.TimerUpdateChannel)
+	return &manager
+}
+
+// Gets the configured building type with the given name
+func GetBuildingType(name string) (config.Building, bool) {
+	for _, buildingType := range config.Config.Buildings {
+		if buildingType.Name == name {
+			return buildingType, true
+		}
+	}
+
+	return config.Building{}, false
+}
+
+// Adds a building covering the region, which is a consumer on the power grid with the given node ID
+func (m *BuildingManager) Add(gridId int64, region commonMath.Region, buildingType config.Building) *Building {
+	building := NewBuilding(gridId, region, buildingType)
+	pos := region.Position
+	fmt.Printf("Added %v '%v'.\n", building.buildingType, gridId)
+
+	ctx := m.supervisor.Context()
+	lifecycle.Send(ctx, m.finder.AddElementChannel, finder.NewElementWithFootprint(gridId, finder.Building, []mgl32.Vec2{pos}, building.GetFootprint()))
+	mailroom.NewBuildingChannel.SendContext(ctx, geometry.NewIdRegion(gridId, *building.GetRegion()))
+	lifecycle.Send(ctx, m.addChannel, building)
+
+	return building
+}
+
+// Removes a building, returning its type, or false if it no longer exists
+func (m *BuildingManager) Delete(gridId int64) (string, bool) {
+	ctx := m.supervisor.Context()
+	result := make(chan *Building)
+	if !lifecycle.Send(ctx, m.deleteChannel, buildingDeletion{gridId: gridId, Result: result}) {
+		return "", false
+	}
+
+	building, ok := lifecycle.Receive(ctx, result)
+	if !ok || building == nil {
+		return "", false
+	}
+
+	fmt.Printf("Removed %v '%v'.\n", building.buildingType, gridId)
+	lifecycle.Send(ctx, m.finder.RemoveElementChannel, finder.ElementKey{Type: finder.Building, Id: gridId})
+	mailroom.DeleteBuildingChannel.SendContext(ctx, gridId)
+	return building.buildingType, true
+}
+
+func (m *BuildingManager) run(ctx context.Context) {
+	for {
+		select {
+		case building := <-m.addChannel:
+			m.buildings[building.gridId] = building
+		case deletion := <-m.deleteChannel:
+			deletion.Result <- m.buildings[deletion.gridId]
+			close(deletion.Result)
+			delete(m.buildings, deletion.gridId)
+		case flow := <-m.powerFlowChannel:
+			m.updatePower(ctx, flow)
+		case time := <-m.TimerUpdateChannel:
+			for m.lastDay < time.Days {
+				m.lastDay++
+				m.produce()
+			}
+		case _ = <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Updates how well each building is powered, notifying when buildings lose or regain power
+func (m *BuildingManager) updatePower(ctx context.Context, flow powerdto.PowerFlow) {
+	for gridId, building := range m.buildings {
+		consumerFlow, ok := flow.Consumers[gridId]
+		if !ok || !building.updatePower(consumerFlow) {
+			continue
+		}
+
+		switch building.powerState {
+		case buildingdto.Powered:
+			fmt.Printf("%v %v is powered.\n", building.buildingType, gridId)
+		case buildingdto.Brownout:
+			fmt.Printf("%v %v is in a brownout, with %v of %v kW served.\n", building.buildingType, gridId, consumerFlow.Served, consumerFlow.Demand)
+		case buildingdto.Blackout:
+			fmt.Printf("%v %v is in a blackout, as the power grid cannot serve it.\n", building.buildingType, gridId)
+		}
+
+		mailroom.BuildingPowerStateChannel.SendContext(ctx, buildingdto.NewPowerStateUpdate(gridId, building.powerState))
+	}
+}
+
+func (m *BuildingManager) produce() {
+	for _, building := range m.buildings {
+		if buildingType, ok := GetBuildingType(building.buildingType); ok {
+			building.produce(buildingType)
+		}
+	}
+}
@@ -7,12 +7,39 @@ type Citizen struct {
 }
 
 type CitizenManager struct {
-	citizens *cmap.Map
+	citizens *cmap.Map[*Citizen]
 }
 
 func NewCitizenManager() *CitizenManager {
 	manager := &CitizenManager{
-		citizens: cmap.NewMap()}
+		citizens: cmap.NewMap[*Citizen]()}
 
 	return manager
 }
+
+// Adds a citizen, returning the citizen's ID
+func (c *CitizenManager) NewCitizen(age int) (*Citizen, int64) {
+	citizen := &Citizen{Age: age}
+	citizenId := c.citizens.IterativeAdd(citizen)
+	return citizen, citizenId
+}
+
+// Gets a citizen, returning false if they do not exist
+func (c *CitizenManager) GetCitizen(citizenId int64) (*Citizen, bool) {
+	return c.citizens.Get(citizenId)
+}
+
+// Removes a citizen, returning true if removed, false if they were already gone
+func (c *CitizenManager) DeleteCitizen(citizenId int64) bool {
+	return c.citizens.Delete(citizenId)
+}
+
+// Returns the number of citizens in the simulation
+func (c *CitizenManager) GetPopulation() int {
+	return c.citizens.Len()
+}
+
+// Visits each citizen in ID order, stopping early if visit returns false
+func (c *CitizenManager) Range(visit func(citizenId int64, citizen *Citizen) bool) {
+	c.citizens.Range(visit)
+}
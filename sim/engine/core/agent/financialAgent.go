@@ -1,28 +1,28 @@
 package agent
 
 import (
+	"context"
 	"fmt"
 	"sim/config"
+	"sim/core/lifecycle"
 	"sim/engine/core/dto"
 )
 
 type FinancialAgent struct {
 	savings            float32
 	TransactionChannel chan dto.Transaction
-	ControlChannel     chan int
 }
 
-func NewFinancialAgent() FinancialAgent {
+func NewFinancialAgent(supervisor *lifecycle.Supervisor) FinancialAgent {
 	agent := FinancialAgent{
 		savings:            config.Config.Sim.StartingSavings,
-		TransactionChannel: make(chan dto.Transaction, 2),
-		ControlChannel:     make(chan int)}
+		TransactionChannel: make(chan dto.Transaction, 2)}
 
-	go agent.Run()
+	supervisor.Go("agent.FinancialAgent", agent.Run)
 	return agent
 }
 
-func (f *FinancialAgent) Run() {
+func (f *FinancialAgent) Run(ctx context.Context) {
 	for {
 		select {
 		case t := <-f.TransactionChannel:
@@ -30,9 +30,13 @@ func (f *FinancialAgent) Run() {
 			if f.savings > -config.Config.Sim.MaxDebt {
 				// TODO: send a message indicating you have lost the game.
 			}
-			fmt.Printf("> Purchased a %v for %.0f. Savings: %.0f\n", t.Name, t.Amount, f.savings)
+			if t.Amount < 0 {
+				fmt.Printf("> Refunded %.0f for a %v. Savings: %.0f\n", -t.Amount, t.Name, f.savings)
+			} else {
+				fmt.Printf("> Purchased a %v for %.0f. Savings: %.0f\n", t.Name, t.Amount, f.savings)
+			}
 			break
-		case _ = <-f.ControlChannel:
+		case _ = <-ctx.Done():
 			return
 		}
 	}
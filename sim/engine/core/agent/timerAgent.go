@@ -1,6 +1,9 @@
 package agent
 
 import (
+	"context"
+	"sim/core/broadcast"
+	"sim/core/lifecycle"
 	"sim/engine/core/dto"
 	"time"
 )
@@ -8,25 +11,27 @@ import (
 // Defines a high-level timer suitable for UI updates
 //  and a low-level timer suitable for simulation updates
 type Timer struct {
-	registeredChannels []chan dto.Time
+	timeUpdates *broadcast.Broadcaster[dto.Time]
 
-	ControlChannel      chan int
-	RegistrationChannel chan chan dto.Time
+	RegistrationChannel   chan chan dto.Time
+	UnregistrationChannel chan chan dto.Time
 }
 
-func NewTimer() Timer {
+func NewTimer(supervisor *lifecycle.Supervisor) Timer {
 	timer := Timer{
-		registeredChannels:  make([]chan dto.Time, 0),
-		ControlChannel:      make(chan int),
-		RegistrationChannel: make(chan chan dto.Time, 10)}
+		timeUpdates:           broadcast.NewBroadcaster[dto.Time]("Core timer", broadcast.CoalesceLatest, broadcast.DefaultTimeout),
+		RegistrationChannel:   make(chan chan dto.Time, 10),
+		UnregistrationChannel: make(chan chan dto.Time, 10)}
 
 	ticker := time.NewTicker(100 * time.Millisecond)
-	go timer.run(ticker)
+	supervisor.Go("agent.Timer", func(ctx context.Context) {
+		timer.run(ctx, ticker)
+	})
 
 	return timer
 }
 
-func (t *Timer) run(ticker *time.Ticker) {
+func (t *Timer) run(ctx context.Context, ticker *time.Ticker) {
 	time := dto.NewTime()
 
 	for {
@@ -35,12 +40,12 @@ func (t *Timer) run(ticker *time.Ticker) {
 			time.Update(0.1)
 
 			// Send the time to everyone!
-			for _, channel := range t.registeredChannels {
-				channel <- time
-			}
+			t.timeUpdates.Send(time)
 		case reg := <-t.RegistrationChannel:
-			t.registeredChannels = append(t.registeredChannels, reg)
-		case _ = <-t.ControlChannel:
+			t.timeUpdates.Register(reg)
+		case reg := <-t.UnregistrationChannel:
+			t.timeUpdates.Unregister(reg)
+		case _ = <-ctx.Done():
 			ticker.Stop()
 			return
 		}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"sim/core/lifecycle"
 	"sim/core/mailroom"
 	"sim/engine/core/agent"
 )
@@ -8,9 +9,10 @@ import (
 var CoreTimer agent.Timer
 var CoreFinances agent.FinancialAgent
 
-func Init() {
-	CoreTimer = agent.NewTimer()
-	mailroom.CoreTimerRegChannel = CoreTimer.RegistrationChannel
+func Init(supervisor *lifecycle.Supervisor) {
+	CoreTimer = agent.NewTimer(supervisor)
+	mailroom.CoreTimerRegChannel.Provide("agent.Timer", CoreTimer.RegistrationChannel)
+	mailroom.CoreTimerUnregChannel.Provide("agent.Timer", CoreTimer.UnregistrationChannel)
 
-	CoreFinances = agent.NewFinancialAgent()
+	CoreFinances = agent.NewFinancialAgent(supervisor)
 }
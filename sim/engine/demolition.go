@@ -0,0 +1,192 @@
+package engine
+
+import (
+	"common/commonmath"
+	"context"
+	"fmt"
+	"sim/config"
+	"sim/core/lifecycle"
+	"sim/core/mailroom"
+	"sim/engine/building"
+	"sim/engine/core"
+	"sim/engine/core/dto"
+	"sim/engine/finder"
+	"sim/engine/power"
+	"sim/engine/road"
+)
+
+// Refunds part of the cost of something that was demolished
+func refund(ctx context.Context, name string, cost float32) {
+	if cost > 0 {
+		lifecycle.Send(ctx, core.CoreFinances.TransactionChannel, dto.NewTransaction(name, -cost*config.Config.Sim.DemolitionRefund))
+	}
+}
+
+// Refunds the removed powerlines, other than service drops which were never paid for
+func (e *Engine) refundPowerLines(ctx context.Context, removedLines []*power.PowerLine) {
+	cost := float32(0)
+	for _, line := range removedLines {
+		if line.IsServiceDrop() {
+			continue
+		}
+
+		ends := line.GetEnds()
+		if lineCost, ok := e.getPowerLineCost(ctx, ends[0], ends[1], power.GetLineTierForCapacity(line.GetCapacity())); ok {
+			cost += lineCost
+		}
+	}
+
+	refund(ctx, "Power Line", cost)
+}
+
+// Refunds the removed roads, despawning the vehicles that were on them
+func (e *Engine) refundRoads(ctx context.Context, removedLines []*road.RoadLine, vehicleIds []int64) {
+	cost := float32(0)
+	for _, line := range removedLines {
+		ends := line.GetEnds()
+		cost += road.GetRoadCost(ends[0], ends[1], line.GetClass())
+	}
+
+	refund(ctx, "Road", cost)
+
+	for _, vehicleId := range vehicleIds {
+		if e.vehicleManager.DeleteVehicle(vehicleId) {
+			mailroom.VehicleDeletionChannel.SendContext(ctx, vehicleId)
+		}
+	}
+
+	if len(vehicleIds) != 0 {
+		fmt.Printf("Despawned %v vehicles from the removed roads.\n", len(vehicleIds))
+	}
+}
+
+// Removes a building and its power grid connection
+func (e *Engine) demolishBuilding(ctx context.Context, buildingId int64) bool {
+	buildingName, ok := e.buildings.Delete(buildingId)
+	if !ok {
+		return false
+	}
+
+	// Buildings share their ID with their consumer on the power grid
+	removedLines, _ := e.powerGrid.DeleteConsumer(buildingId)
+	e.refundPowerLines(ctx, removedLines)
+
+	if buildingType, ok := building.GetBuildingType(buildingName); ok {
+		refund(ctx, buildingType.Name, buildingType.Cost)
+	}
+
+	return true
+}
+
+func (e *Engine) demolishPlant(ctx context.Context, plantId int64) bool {
+	plantType, removedLines, ok := e.powerGrid.DeletePlant(plantId)
+	if !ok {
+		return false
+	}
+
+	e.refundPowerLines(ctx, removedLines)
+	refund(ctx, "Power Plant", power.GetPlantCost(plantType))
+	return true
+}
+
+// Finds the elements under the cursor, in the order they are demolished: plants and buildings, then termini, then lines.
+func (e *Engine) findDemolitionCandidates(ctx context.Context) ([]finder.ElementKey, bool) {
+	pos := e.lastBoardPos
+	radius := config.Config.Draw.MinSnapNodeDistance
+	candidates := make([]finder.ElementKey, 0)
+
+	cursor := commonMath.Region{RegionType: commonMath.CircleRegion, Position: pos, Scale: 1}
+	regionResults := make(chan []finder.ElementKey)
+	if !lifecycle.Send(ctx, e.elementFinder.IntersectionChannel, finder.NewIntersectionQuery(finder.NewRegionFootprint(cursor), []finder.ItemType{finder.PowerPlant, finder.Building}, regionResults)) {
+		return nil, false
+	}
+
+	regions, ok := lifecycle.Receive(ctx, regionResults)
+	if !ok {
+		return nil, false
+	}
+
+	candidates = append(candidates, regions...)
+
+	nodeResults := make(chan []*finder.NodeWithDistance)
+	if !lifecycle.Send(ctx, e.elementFinder.RadiusSearchChannel, finder.RadiusQuery{
+		Pos:     pos,
+		Types:   []finder.ItemType{finder.PowerTerminus, finder.RoadTerminus},
+		Radius:  radius,
+		Results: nodeResults}) {
+		return nil, false
+	}
+
+	nodes, ok := lifecycle.Receive(ctx, nodeResults)
+	if !ok {
+		return nil, false
+	}
+
+	for _, node := range nodes {
+		candidates = append(candidates, finder.ElementKey{Type: node.Type, Id: node.Id})
+	}
+
+	edgeResults := make(chan []*finder.EdgeWithDistance)
+	if !lifecycle.Send(ctx, e.elementFinder.EdgeSearchChannel, finder.NewNearestEdgesQuery(pos, []finder.ItemType{finder.PowerLine, finder.RoadLine}, radius, edgeResults)) {
+		return nil, false
+	}
+
+	edges, ok := lifecycle.Receive(ctx, edgeResults)
+	if !ok {
+		return nil, false
+	}
+
+	for _, edge := range edges {
+		candidates = append(candidates, finder.ElementKey{Type: edge.Type, Id: edge.Id})
+	}
+
+	return candidates, true
+}
+
+// Removes the element under the cursor, along with everything that depends on it, refunding part of the cost
+func (e *Engine) demolishIfPresent(ctx context.Context) {
+	candidates, ok := e.findDemolitionCandidates(ctx)
+	if !ok {
+		return
+	}
+
+	// Candidates may already be gone, or (for consumers) be removed with their building instead
+	for _, candidate := range candidates {
+		demolished := false
+		switch candidate.Type {
+		case finder.PowerPlant:
+			demolished = e.demolishPlant(ctx, candidate.Id)
+		case finder.Building:
+			demolished = e.demolishBuilding(ctx, candidate.Id)
+		case finder.PowerTerminus:
+			var removedLines []*power.PowerLine
+			if removedLines, demolished = e.powerGrid.DeleteTerminus(candidate.Id); demolished {
+				e.refundPowerLines(ctx, removedLines)
+			}
+		case finder.RoadTerminus:
+			var removedLines []*road.RoadLine
+			var vehicleIds []int64
+			if removedLines, vehicleIds, demolished = e.roadGrid.DeleteTerminus(candidate.Id); demolished {
+				e.refundRoads(ctx, removedLines, vehicleIds)
+			}
+		case finder.PowerLine:
+			var line *power.PowerLine
+			if line, demolished = e.powerGrid.DeleteLine(candidate.Id); demolished {
+				e.refundPowerLines(ctx, []*power.PowerLine{line})
+			}
+		case finder.RoadLine:
+			var line *road.RoadLine
+			var vehicleIds []int64
+			if line, vehicleIds, demolished = e.roadGrid.DeleteLine(candidate.Id); demolished {
+				e.refundRoads(ctx, []*road.RoadLine{line}, vehicleIds)
+			}
+		}
+
+		if demolished {
+			fmt.Printf("Demolished %v %v.\n", candidate.Type, candidate.Id)
+			return
+		}
+	}
+
+	fmt.Printf("There is nothing here to demolish.\n")
+}
@@ -9,6 +9,13 @@ type EditState struct {
 	hasFirstNode     bool
 	firstNode        mgl32.Vec2
 	firstNodeElement int64
+
+	// If not -1, the first node is partway along this line, which is split when the segment is drawn
+	firstNodeLine int64
+
+	// The start of the last segment drawn, used to find its direction
+	hasPreviousNode bool
+	previousNode    mgl32.Vec2
 }
 
 func NewEditState() *EditState {
@@ -19,4 +26,29 @@ func NewEditState() *EditState {
 
 func (p *EditState) Reset() {
 	p.hasFirstNode = false
+	p.hasPreviousNode = false
+}
+
+// Moves the first node to the end of a newly-drawn segment
+func (p *EditState) Advance(endNode mgl32.Vec2, endNodeElement int64) {
+	p.previousNode = p.firstNode
+	p.hasPreviousNode = true
+
+	p.firstNode = endNode
+	p.firstNodeElement = endNodeElement
+	p.firstNodeLine = -1
+}
+
+// Gets the anchor new segments are drawn from
+func (p *EditState) GetSnapAnchor() SnapAnchor {
+	anchor := SnapAnchor{
+		HasAnchor:            p.hasFirstNode,
+		Position:             p.firstNode,
+		HasPreviousDirection: p.hasFirstNode && p.hasPreviousNode && p.firstNode != p.previousNode}
+
+	if anchor.HasPreviousDirection {
+		anchor.PreviousDirection = p.firstNode.Sub(p.previousNode).Normalize()
+	}
+
+	return anchor
 }
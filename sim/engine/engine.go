@@ -1,9 +1,15 @@
 package engine
 
 import (
+	"common/commonmath"
+	"context"
+	"fmt"
 	"sim/config"
 	"sim/core/dto/editorengdto"
+	"sim/core/dto/terraindto"
+	"sim/core/lifecycle"
 	"sim/core/mailroom"
+	"sim/engine/building"
 	"sim/engine/core"
 	"sim/engine/core/dto"
 	"sim/engine/finder"
@@ -21,6 +27,7 @@ type Engine struct {
 	terrainMap          *terrain.TerrainMap
 	elementFinder       *finder.ElementFinder
 	powerGrid           *power.PowerGrid
+	buildings           *building.BuildingManager
 	roadGrid            *road.RoadGrid
 	vehicleManager      *vehicle.VehicleManager
 	infiniRoadGenerator *road.InfiniRoadGenerator
@@ -34,10 +41,21 @@ type Engine struct {
 	editorMode     editorengdto.EditorMode
 	editorAddMode  editorengdto.EditorAddMode
 	editorDrawMode editorengdto.EditorDrawMode
+	itemSelection  editorengdto.ItemSubSelection
+	placement      editorengdto.PlacementSetting
+	isOverpass     bool
+
+	isPreviewVisible bool
+
+	// The road terminus selected in selection mode, or -1 if there is none
+	selectedTerminus int64
 
 	editorModeChannel     chan editorengdto.EditorMode
 	editorAddModeChannel  chan editorengdto.EditorAddMode
 	editorDrawModeChannel chan editorengdto.EditorDrawMode
+	itemSelectionChannel  chan editorengdto.ItemSubSelection
+	placementChannel      chan editorengdto.PlacementSetting
+	editorOverpassChannel chan bool
 	editorCancelChannel   chan bool
 
 	Hypotheticals HypotheticalActions
@@ -45,50 +63,63 @@ type Engine struct {
 	mousePressChannel    chan glfw.MouseButton
 	mouseReleaseChannel  chan glfw.MouseButton
 	mouseBoardPosChannel chan mgl32.Vec2
-	ControlChannel       chan int
 }
 
-func NewEngine() *Engine {
+func NewEngine(supervisor *lifecycle.Supervisor) *Engine {
 	terrain.Init(config.Config.Terrain.Generation.Seed)
 
 	engine := Engine{
 		editorMode:            editorengdto.Select,
 		editorAddMode:         editorengdto.PowerPlant,
 		editorDrawMode:        editorengdto.TerrainFlatten,
+		itemSelection:         editorengdto.Item1,
+		placement:             editorengdto.PlacementSetting{IsLarge: false, Orientation: 0},
+		isPreviewVisible:      false,
+		selectedTerminus:      -1,
 		editorModeChannel:     make(chan editorengdto.EditorMode, 3),
 		editorAddModeChannel:  make(chan editorengdto.EditorAddMode, 3),
 		editorDrawModeChannel: make(chan editorengdto.EditorDrawMode, 3),
+		itemSelectionChannel:  make(chan editorengdto.ItemSubSelection, 3),
+		placementChannel:      make(chan editorengdto.PlacementSetting, 3),
+		editorOverpassChannel: make(chan bool, 3),
 		editorCancelChannel:   make(chan bool, 3),
 		mouseBoardPosChannel:  make(chan mgl32.Vec2, 10),
 		mousePressChannel:     make(chan glfw.MouseButton, 10),
-		mouseReleaseChannel:   make(chan glfw.MouseButton, 10),
-		ControlChannel:        make(chan int)}
+		mouseReleaseChannel:   make(chan glfw.MouseButton, 10)}
 
-	engine.terrainMap = terrain.NewTerrainMap()
-	mailroom.NewTerrainRegChannel = engine.terrainMap.NewTerrainRegChannel
-	mailroom.NewRegionRegChannel = engine.terrainMap.NewRegionRegChannel
+	engine.terrainMap = terrain.NewTerrainMap(supervisor)
+	mailroom.NewTerrainRegChannel.Provide("terrain.TerrainMap", engine.terrainMap.NewTerrainRegChannel)
+	mailroom.NewRegionRegChannel.Provide("terrain.TerrainMap", engine.terrainMap.NewRegionRegChannel)
 
-	engine.elementFinder = finder.NewElementFinder()
-	engine.powerGrid = power.NewPowerGrid(engine.elementFinder)
-	engine.roadGrid = road.NewRoadGrid(engine.elementFinder)
+	engine.elementFinder = finder.NewElementFinder(supervisor)
+	engine.powerGrid = power.NewPowerGrid(supervisor, engine.elementFinder)
+	mailroom.PowerFlowRegChannel.Provide("power.PowerGrid", engine.powerGrid.PowerFlowRegChannel)
+	mailroom.PowerReportChannel.Provide("power.PowerGrid", engine.powerGrid.ReportQueryChannel)
+	engine.buildings = building.NewBuildingManager(supervisor, engine.elementFinder)
 	engine.vehicleManager = vehicle.NewVehicleManager()
-	engine.infiniRoadGenerator = road.NewInfiniRoadGenerator(engine.roadGrid, engine.vehicleManager)
+	engine.roadGrid = road.NewRoadGrid(supervisor, engine.elementFinder, engine.vehicleManager)
+	engine.infiniRoadGenerator = road.NewInfiniRoadGenerator(supervisor, engine.roadGrid, engine.vehicleManager)
 	engine.isMousePressed = false
 	engine.powerLineState = NewEditState()
 	engine.roadLineState = NewEditState()
-	engine.snap = NewSnap(engine.elementFinder)
+	engine.snap = NewSnap(supervisor, engine.elementFinder)
 
 	engine.Hypotheticals = NewHypotheticalActions()
 
-	mailroom.MousePressedRegChannel <- engine.mousePressChannel
-	mailroom.MouseReleasedRegChannel <- engine.mouseReleaseChannel
-	mailroom.BoardPosChangeRegChannel <- engine.mouseBoardPosChannel
-	mailroom.EngineModeRegChannel <- engine.editorModeChannel
-	mailroom.EngineAddModeRegChannel <- engine.editorAddModeChannel
-	mailroom.EngineDrawModeRegChannel <- engine.editorDrawModeChannel
-	mailroom.EngineCancelChannel <- engine.editorCancelChannel
-
-	go engine.run()
+	mailroom.MousePressedRegChannel.Use("engine.Engine").Send(engine.mousePressChannel)
+	mailroom.MouseReleasedRegChannel.Use("engine.Engine").Send(engine.mouseReleaseChannel)
+	mailroom.BoardPosChangeRegChannel.Use("engine.Engine").Send(engine.mouseBoardPosChannel)
+	mailroom.EngineModeRegChannel.Use("engine.Engine").Send(engine.editorModeChannel)
+	mailroom.EngineAddModeRegChannel.Use("engine.Engine").Send(engine.editorAddModeChannel)
+	mailroom.EngineDrawModeRegChannel.Use("engine.Engine").Send(engine.editorDrawModeChannel)
+	mailroom.EngineItemSubSelectionRegChannel.Use("engine.Engine").Send(engine.itemSelectionChannel)
+	mailroom.EnginePlacementRegChannel.Use("engine.Engine").Send(engine.placementChannel)
+	mailroom.PlacementPreviewChannel.Use("engine.Engine")
+	mailroom.VehicleDeletionChannel.Use("engine.Engine")
+	mailroom.EngineOverpassRegChannel.Use("engine.Engine").Send(engine.editorOverpassChannel)
+	mailroom.EngineCancelChannel.Use("engine.Engine").Send(engine.editorCancelChannel)
+
+	supervisor.Go("engine.Engine", engine.run)
 	return &engine
 }
 
@@ -96,108 +127,320 @@ func NewEngine() *Engine {
 // 	e.Hypotheticals.ComputeHypotheticalRegion(engine, &editorEngine.EngineState)
 // }
 
-func (e *Engine) run() {
+func (e *Engine) run(ctx context.Context) {
 	for {
 		select {
 		case e.lastBoardPos = <-e.mouseBoardPosChannel:
+			e.updatePlacementPreview(ctx)
 		case e.editorMode = <-e.editorModeChannel:
+			e.updatePlacementPreview(ctx)
 		case e.editorAddMode = <-e.editorAddModeChannel:
+			e.updateSnapAnchor(ctx)
+			e.updatePlacementPreview(ctx)
 		case e.editorDrawMode = <-e.editorDrawModeChannel:
+		case e.itemSelection = <-e.itemSelectionChannel:
+			if e.editorMode == editorengdto.Select {
+				e.setIntersectionControl()
+			}
+
+			e.updatePlacementPreview(ctx)
+		case e.placement = <-e.placementChannel:
+			e.updatePlacementPreview(ctx)
+		case e.isOverpass = <-e.editorOverpassChannel:
 		case _ = <-e.editorCancelChannel:
 			e.powerLineState.Reset()
 			e.roadLineState.Reset()
+			e.updateSnapAnchor(ctx)
 		case _ = <-e.mousePressChannel:
 			e.isMousePressed = true
 
 			if e.editorMode == editorengdto.Add && e.editorAddMode == editorengdto.PowerPlant {
-				e.addPowerPlantIfValid()
+				e.addPowerPlantIfValid(ctx)
+			} else if e.editorMode == editorengdto.Add && e.editorAddMode == editorengdto.Building {
+				e.addBuildingIfValid(ctx)
+			} else if e.editorMode == editorengdto.Delete {
+				e.demolishIfPresent(ctx)
+			} else if e.editorMode == editorengdto.Select {
+				e.selectIntersection(ctx)
 			}
+
+			e.updatePlacementPreview(ctx)
 		case _ = <-e.mouseReleaseChannel:
 			e.isMousePressed = false
 
 			if e.editorMode == editorengdto.Add {
 				if e.editorAddMode == editorengdto.PowerLine {
-					e.updatePowerLineState()
+					e.updatePowerLineState(ctx)
 				} else if e.editorAddMode == editorengdto.RoadLine {
-					e.updateRoadLineState()
+					e.updateRoadLineState(ctx)
 				}
+
+				e.updateSnapAnchor(ctx)
 			}
-		case _ = <-e.ControlChannel:
+		case _ = <-ctx.Done():
 			return
 		}
 	}
 }
 
-func (e *Engine) addPowerPlantIfValid() {
-	intesectsWithElement := false // e.elementFinder.IntersectsWithElement(e.getEffectivePosition(), e.Hypotheticals.Regions[0].Region.Scale)
+// Tells the snap agent where lines of the current add mode are being drawn from
+func (e *Engine) updateSnapAnchor(ctx context.Context) {
+	anchor := SnapAnchor{HasAnchor: false}
+	if e.editorAddMode == editorengdto.PowerLine {
+		anchor = e.powerLineState.GetSnapAnchor()
+	} else if e.editorAddMode == editorengdto.RoadLine {
+		anchor = e.roadLineState.GetSnapAnchor()
+	}
 
-	if !intesectsWithElement {
-		isGroundValid := true // e.terrainMap.ValidateGroundLocation(e.Hypotheticals.Regions[0].Region)
-		if isGroundValid {
-			plantType := power.GetPlantType(editorengdto.Item1) // TODO: EngineState.ItemSubSelection)
-			plantSize := power.Small                            // TODO: Configurable
+	lifecycle.Send(ctx, e.snap.AnchorChannel, anchor)
+}
+
+// Gets the type, size and region of the selected power plant, placed at the cursor
+func (e *Engine) getPlantPlacement() (string, power.PowerPlantSize, commonMath.Region) {
+	plantType := power.GetPlantType(e.itemSelection)
+	plantSize := power.Small
+	if e.placement.IsLarge {
+		plantSize = power.Large
+	}
 
-			_ = e.powerGrid.Add(e.lastBoardPos, plantType, plantSize) // get effective position
-			// e.elementFinder.Add(element)
-			core.CoreFinances.TransactionChannel <- dto.NewTransaction("Power Plant", power.GetPlantCost(plantType))
+	return plantType, plantSize, power.GetPlantRegion(e.lastBoardPos, plantType, plantSize, e.placement.Orientation)
+}
+
+// Gets the selected building type, if there is one
+func (e *Engine) getBuildingType() (config.Building, bool) {
+	if int(e.itemSelection) >= len(config.Config.Buildings) {
+		return config.Building{}, false
+	}
+
+	return config.Config.Buildings[e.itemSelection], true
+}
+
+// Shows where the plant or building being added would go, and if it can be placed there
+func (e *Engine) updatePlacementPreview(ctx context.Context) {
+	preview := editorengdto.PlacementPreview{IsVisible: false}
+	if e.editorMode == editorengdto.Add && e.editorAddMode == editorengdto.PowerPlant {
+		if plantType, _, region := e.getPlantPlacement(); plantType != "" {
+			preview = editorengdto.PlacementPreview{
+				IsVisible: true,
+				IsValid:   e.validatePlantPlacement(ctx, plantType, region) == nil,
+				Region:    region}
+		}
+	} else if e.editorMode == editorengdto.Add && e.editorAddMode == editorengdto.Building {
+		if buildingType, ok := e.getBuildingType(); ok {
+			region := building.GetBuildingRegion(e.lastBoardPos, buildingType, e.placement.Orientation)
+			preview = editorengdto.PlacementPreview{
+				IsVisible: true,
+				IsValid:   e.validateRegionPlacement(ctx, region) == nil,
+				Region:    region}
 		}
 	}
+
+	// Hidden previews only need to be sent once
+	if preview.IsVisible || e.isPreviewVisible {
+		mailroom.PlacementPreviewChannel.SendContext(ctx, preview)
+	}
+
+	e.isPreviewVisible = preview.IsVisible
 }
 
-func (e *Engine) updatePowerLineState() {
-	if !e.powerLineState.hasFirstNode {
-		e.powerLineState.firstNodeElement, e.powerLineState.firstNode = e.getEffectiveElement()
-		e.powerLineState.hasFirstNode = true
+func (e *Engine) addPowerPlantIfValid(ctx context.Context) {
+	plantType, plantSize, region := e.getPlantPlacement()
+	if plantType == "" {
+		fmt.Printf("There is no power plant type %v.\n", int(e.itemSelection)+1)
+		return
+	} else if err := e.validatePlantPlacement(ctx, plantType, region); err != nil {
+		fmt.Printf("Cannot place a %v power plant here, as %v.\n", plantType, err)
+		return
+	}
+
+	_ = e.powerGrid.Add(region.Position, plantType, plantSize, region.Orientation)
+	lifecycle.Send(ctx, core.CoreFinances.TransactionChannel, dto.NewTransaction("Power Plant", power.GetPlantCost(plantType)))
+}
+
+// Adds a building of the selected type, which draws power from the grid nearby if there is any
+func (e *Engine) addBuildingIfValid(ctx context.Context) {
+	buildingType, ok := e.getBuildingType()
+	if !ok {
+		fmt.Printf("There is no building type %v.\n", int(e.itemSelection)+1)
+		return
+	}
+
+	region := building.GetBuildingRegion(e.lastBoardPos, buildingType, e.placement.Orientation)
+	if err := e.validateRegionPlacement(ctx, region); err != nil {
+		fmt.Printf("Cannot place a %v here, as %v.\n", buildingType.Name, err)
+		return
+	}
+
+	// Buildings share their ID with their consumer on the power grid
+	consumerId := e.powerGrid.AddConsumer(region.Position, int(buildingType.RequiredBasics.Power))
+	_ = e.buildings.Add(consumerId, region, buildingType)
+	if !e.powerGrid.ConnectConsumer(consumerId) {
+		fmt.Printf("The %v is not near the power grid, so it will have no power until a powerline reaches it.\n", buildingType.Name)
+	}
+
+	lifecycle.Send(ctx, core.CoreFinances.TransactionChannel, dto.NewTransaction(buildingType.Name, buildingType.Cost))
+}
+
+// Gets the cost of a powerline of the tier from start to end, which depends on the terrain it crosses.
+// Returns false if the simulation is stopping.
+func (e *Engine) getPowerLineCost(ctx context.Context, start, end mgl32.Vec2, tier config.PowerLineTier) (float32, bool) {
+	query := terrain.LineTerrainQuery{
+		Start:  start,
+		End:    end,
+		Result: make(chan map[terraindto.TerrainType]float32)}
+
+	if !lifecycle.Send(ctx, e.terrainMap.LineTerrainChannel, query) {
+		return 0, false
+	}
+
+	terrainLengths, ok := lifecycle.Receive(ctx, query.Result)
+	if !ok {
+		return 0, false
+	}
+
+	return power.GetLineCost(terrainLengths, tier), true
+}
+
+// Splits the line a segment end is partway along, if any, returning the node to connect the segment to
+func resolveSplit(split func(int64, mgl32.Vec2) int64, nodeId int64, pos mgl32.Vec2, lineId int64) (int64, bool) {
+	if lineId == -1 {
+		return nodeId, true
+	}
+
+	splitNode := split(lineId, pos)
+	return splitNode, splitNode != -1
+}
+
+func (e *Engine) updatePowerLineState(ctx context.Context) {
+	state := e.powerLineState
+	if !state.hasFirstNode {
+		state.firstNodeElement, state.firstNode, state.firstNodeLine = e.getEffectiveElement(ctx)
+		state.hasFirstNode = true
 	} else {
-		// TODO: Configurable capacity
-		powerLineEndId, powerLineEnd := e.getEffectiveElement()
-		_, lineId, endLineId := e.powerGrid.AddLine(e.powerLineState.firstNode,
-			powerLineEnd, 1000,
-			e.powerLineState.firstNodeElement, powerLineEndId)
-		if lineId != -1 {
-			powerLineCost := e.powerLineState.firstNode.Sub(powerLineEnd).Len() * config.Config.Power.PowerLineCost
-			core.CoreFinances.TransactionChannel <- dto.NewTransaction("Power Line", powerLineCost)
+		tier, ok := power.GetLineTier(e.itemSelection)
+		if !ok {
+			fmt.Printf("There is no powerline tier %v.\n", int(e.itemSelection)+1)
+			return
+		}
+
+		powerLineEndId, powerLineEnd, powerLineEndLine := e.getEffectiveElement(ctx)
+		if powerLineEndLine != -1 && powerLineEndLine == state.firstNodeLine {
+			fmt.Printf("Cannot place a powerline along the powerline it starts on.\n")
+			return
+		}
+
+		// Lines can run over the plants and buildings they connect to
+		corridor := power.GetLineFootprint(state.firstNode, powerLineEnd)
+		connectedElements := []finder.ElementKey{
+			{Type: finder.PowerPlant, Id: state.firstNodeElement},
+			{Type: finder.PowerPlant, Id: powerLineEndId},
+			{Type: finder.Building, Id: state.firstNodeElement},
+			{Type: finder.Building, Id: powerLineEndId}}
+		if err := e.validateLinePlacement(ctx, corridor, connectedElements...); err != nil {
+			fmt.Printf("Cannot place a powerline here, as %v.\n", err)
+			return
+		}
+
+		powerLineCost, ok := e.getPowerLineCost(ctx, state.firstNode, powerLineEnd, tier)
+		if !ok {
+			return
+		}
+
+		// Connecting partway along existing lines splits them
+		if state.firstNodeElement, ok = resolveSplit(e.powerGrid.SplitLine, state.firstNodeElement, state.firstNode, state.firstNodeLine); !ok {
+			state.Reset()
+			return
+		}
+		state.firstNodeLine = -1
+
+		if powerLineEndId, ok = resolveSplit(e.powerGrid.SplitLine, powerLineEndId, powerLineEnd, powerLineEndLine); !ok {
+			return
+		}
 
-			e.powerLineState.firstNode = powerLineEnd
-			e.powerLineState.firstNodeElement = endLineId
+		// Long lines have pylons added along them. If adding a span fails, only the spans that were added are paid for.
+		_, lineIds, endLineId := e.powerGrid.AddLineWithPylons(state.firstNode,
+			powerLineEnd, tier.Capacity,
+			state.firstNodeElement, powerLineEndId)
+		if len(lineIds) != 0 {
+			spans := power.GetPylonCount(state.firstNode.Sub(powerLineEnd).Len()) + 1
+			paidCost := powerLineCost * float32(len(lineIds)) / float32(spans)
+			lifecycle.Send(ctx, core.CoreFinances.TransactionChannel, dto.NewTransaction(fmt.Sprintf("%v Power Line", tier.Name), paidCost))
+		}
+
+		if endLineId != -1 {
+			state.Advance(powerLineEnd, endLineId)
 		}
 	}
 }
 
-func (e *Engine) updateRoadLineState() {
-	if !e.roadLineState.hasFirstNode {
-		e.roadLineState.firstNodeElement, e.roadLineState.firstNode = e.getEffectiveElement()
-		e.roadLineState.hasFirstNode = true
+func (e *Engine) updateRoadLineState(ctx context.Context) {
+	state := e.roadLineState
+	if !state.hasFirstNode {
+		state.firstNodeElement, state.firstNode, state.firstNodeLine = e.getEffectiveElement(ctx)
+		state.hasFirstNode = true
 	} else {
-		// TODO: Configurable capacity
-		roadLineEndId, roadLineEnd := e.getEffectiveElement()
-		_, lineId, endLineId := e.roadGrid.AddLine(e.roadLineState.firstNode,
-			roadLineEnd, 1000,
-			e.roadLineState.firstNodeElement, roadLineEndId)
-		if lineId != -1 {
-			roadLineCost := e.roadLineState.firstNode.Sub(roadLineEnd).Len() * 3000 // TODO: Configurable
-			core.CoreFinances.TransactionChannel <- dto.NewTransaction("Road", roadLineCost)
+		class, ok := road.GetRoadClass(e.itemSelection)
+		if !ok {
+			fmt.Printf("There is no road class %v.\n", int(e.itemSelection)+1)
+			return
+		}
+
+		roadLineEndId, roadLineEnd, roadLineEndLine := e.getEffectiveElement(ctx)
+		if roadLineEndLine != -1 && roadLineEndLine == state.firstNodeLine {
+			fmt.Printf("Cannot place a road along the road it starts on.\n")
+			return
+		}
+
+		if err := e.validateLinePlacement(ctx, road.GetLineFootprint(state.firstNode, roadLineEnd, class)); err != nil {
+			fmt.Printf("Cannot place a road here, as %v.\n", err)
+			return
+		}
+
+		// Connecting partway along existing roads splits them, making a T-junction.
+		// Roads crossing existing roads also split them, making junctions, unless the road is an overpass.
+		if state.firstNodeElement, ok = resolveSplit(e.roadGrid.SplitLine, state.firstNodeElement, state.firstNode, state.firstNodeLine); !ok {
+			state.Reset()
+			return
+		}
+		state.firstNodeLine = -1
+
+		if roadLineEndId, ok = resolveSplit(e.roadGrid.SplitLine, roadLineEndId, roadLineEnd, roadLineEndLine); !ok {
+			return
+		}
+
+		// If adding a segment fails, only the segments that were added are paid for.
+		_, lineIds, endLineId, junctions := e.roadGrid.AddRoad(state.firstNode,
+			roadLineEnd, class,
+			state.firstNodeElement, roadLineEndId, e.isOverpass)
+		if len(lineIds) != 0 {
+			lifecycle.Send(ctx, core.CoreFinances.TransactionChannel, dto.NewTransaction(class.Name, e.roadGrid.GetLinesCost(lineIds)))
+		}
+
+		if junctions != 0 {
+			lifecycle.Send(ctx, core.CoreFinances.TransactionChannel, dto.NewTransaction("Road Junction", float32(junctions)*config.Config.Road.JunctionCost))
+		}
 
-			e.roadLineState.firstNode = roadLineEnd
-			e.roadLineState.firstNodeElement = endLineId
+		if endLineId != -1 {
+			state.Advance(roadLineEnd, endLineId)
 		}
 	}
 }
 
-// Gets an effective element, returning the ID (if any) and position.
-func (e *Engine) getEffectiveElement() (int64, mgl32.Vec2) {
+// Gets an effective element, returning the ID (if any), position, and the ID of the line the position is partway along (if any).
+func (e *Engine) getEffectiveElement(ctx context.Context) (int64, mgl32.Vec2, int64) {
 	query := SnapQuery{
 		Result: make(chan SnapResult)}
 
-	e.snap.SnapQueryChannel <- query
-	snapResult := <-query.Result
+	lifecycle.Send(ctx, e.snap.SnapQueryChannel, query)
+	snapResult, ok := lifecycle.Receive(ctx, query.Result)
 
-	if !snapResult.IsItemSnapped {
+	if !ok || !snapResult.IsItemSnapped {
 		snapResult.Id = -1
 		snapResult.Position = e.lastBoardPos
+		snapResult.LineId = -1
 	}
 
-	return snapResult.Id, snapResult.Position
+	return snapResult.Id, snapResult.Position, snapResult.LineId
 }
 
 func (e *Engine) applyStepDraw(stepAmount float32, engineState *editorEngine.State) {
@@ -9,13 +9,61 @@ type Element struct {
 	Id    int64
 	Type  ItemType
 	Nodes []mgl32.Vec2
+
+	// The area covered by the element, if it covers any area
+	Footprint *Footprint
+
+	// Line segments that can be snapped to, which must lie within the footprint
+	Edges [][2]mgl32.Vec2
 }
 
 func NewElement(id int64, itemType ItemType, nodes []mgl32.Vec2) Element {
 	return Element{
-		Id:    id,
-		Type:  itemType,
-		Nodes: nodes}
+		Id:        id,
+		Type:      itemType,
+		Nodes:     nodes,
+		Footprint: nil,
+		Edges:     make([][2]mgl32.Vec2, 0)}
+}
+
+func NewElementWithFootprint(id int64, itemType ItemType, nodes []mgl32.Vec2, footprint Footprint) Element {
+	element := NewElement(id, itemType, nodes)
+	element.Footprint = &footprint
+	return element
+}
+
+// Creates an element for a line, which can be snapped to at its ends or along its length
+func NewLineElement(id int64, itemType ItemType, line [2]mgl32.Vec2, footprint Footprint) Element {
+	element := NewElementWithFootprint(id, itemType, []mgl32.Vec2{line[0], line[1]}, footprint)
+	element.Edges = [][2]mgl32.Vec2{line}
+	return element
+}
+
+func (e Element) GetKey() ElementKey {
+	return ElementKey{Type: e.Type, Id: e.Id}
+}
+
+// Uniquely identifies an element in the finder
+type ElementKey struct {
+	Type ItemType
+	Id   int64
+}
+
+// Defines graph node data whose position can be tracked by the finder
+type SnapNodeProvider interface {
+	// Gets positions on the map that can be used to snap to points of the element.
+	GetSnapNodes() []mgl32.Vec2
+}
+
+// Defines graph node data with edges that can be snapped to
+type SnapEdgeProvider interface {
+	// Gets lines on the map that can be used to snap to *edges* of the element
+	GetSnapEdges() [][2]mgl32.Vec2
+}
+
+// Defines graph node data that covers an area of the map
+type FootprintProvider interface {
+	GetFootprint() Footprint
 }
 
 // Defines a query to return the KNearestNodes of the given input types
@@ -36,6 +84,81 @@ func NewKNNQuery(pos mgl32.Vec2, itemType ItemType, count int, results chan []*N
 	return query
 }
 
+// Defines a query to return all nodes of the given input types within a radius
+type RadiusQuery struct {
+	Pos     mgl32.Vec2
+	Types   []ItemType
+	Radius  float32
+	Results chan []*NodeWithDistance
+}
+
+func NewRadiusQuery(pos mgl32.Vec2, itemType ItemType, radius float32, results chan []*NodeWithDistance) RadiusQuery {
+	return RadiusQuery{
+		Pos:     pos,
+		Types:   []ItemType{itemType},
+		Radius:  radius,
+		Results: results}
+}
+
+// Defines a query to return all nodes of the given input types within an axis-aligned rectangle
+type RectQuery struct {
+	Min     mgl32.Vec2
+	Max     mgl32.Vec2
+	Types   []ItemType
+	Results chan []*NodeWithDistance
+}
+
+func NewRectQuery(minPos, maxPos mgl32.Vec2, itemType ItemType, results chan []*NodeWithDistance) RectQuery {
+	return RectQuery{
+		Min:     minPos,
+		Max:     maxPos,
+		Types:   []ItemType{itemType},
+		Results: results}
+}
+
+// Defines a query to return the keys of all elements of the given input types whose footprints overlap a footprint
+type IntersectionQuery struct {
+	Footprint Footprint
+	Types     []ItemType
+	Results   chan []ElementKey
+}
+
+func NewIntersectionQuery(footprint Footprint, itemTypes []ItemType, results chan []ElementKey) IntersectionQuery {
+	return IntersectionQuery{
+		Footprint: footprint,
+		Types:     itemTypes,
+		Results:   results}
+}
+
+// Defines a query to return the edges of the given input types within a distance, nearest first
+type NearestEdgesQuery struct {
+	Pos         mgl32.Vec2
+	Types       []ItemType
+	MaxDistance float32
+	Results     chan []*EdgeWithDistance
+}
+
+func NewNearestEdgesQuery(pos mgl32.Vec2, itemTypes []ItemType, maxDistance float32, results chan []*EdgeWithDistance) NearestEdgesQuery {
+	return NearestEdgesQuery{
+		Pos:         pos,
+		Types:       itemTypes,
+		MaxDistance: maxDistance,
+		Results:     results}
+}
+
+// Defines the closest point of an element edge, a distance away
+type EdgeWithDistance struct {
+	Id   int64
+	Type ItemType
+
+	Edge      [2]mgl32.Vec2
+	EdgeIndex int
+
+	// The closest point on the edge
+	Pos      mgl32.Vec2
+	Distance float32
+}
+
 // Defines a node a distance away
 type NodeWithDistance struct {
 	Id   int64
@@ -1,65 +1,161 @@
 package finder
 
 import (
-	"sim/core"
+	"context"
+	"sim/config"
+	"sim/core/graph"
+	"sim/core/lifecycle"
 )
 
 // Defines how to quickly add, remove, and find points on our gameboard.
 
 type ElementFinder struct {
+	supervisor *lifecycle.Supervisor
+
 	// Maps each element by item type to its Ids, which are guaranteed unique
 	elements map[ItemType]map[int64]Element
+	index    *SpatialIndex
 
 	AddElementChannel     chan Element
+	UpdateElementChannel  chan Element
+	RemoveElementChannel  chan ElementKey
 	KNearestSearchChannel chan KNearestNodesQuery
+	RadiusSearchChannel   chan RadiusQuery
+	RectSearchChannel     chan RectQuery
+	IntersectionChannel   chan IntersectionQuery
+	EdgeSearchChannel     chan NearestEdgesQuery
 }
 
-func NewElementFinder() *ElementFinder {
+func NewElementFinder(supervisor *lifecycle.Supervisor) *ElementFinder {
 	finder := ElementFinder{
+		supervisor:            supervisor,
 		elements:              make(map[ItemType]map[int64]Element),
+		index:                 NewSpatialIndex(float32(config.Config.Terrain.RegionSize)),
 		AddElementChannel:     make(chan Element),
-		KNearestSearchChannel: make(chan KNearestNodesQuery)}
+		UpdateElementChannel:  make(chan Element),
+		RemoveElementChannel:  make(chan ElementKey),
+		KNearestSearchChannel: make(chan KNearestNodesQuery),
+		RadiusSearchChannel:   make(chan RadiusQuery),
+		RectSearchChannel:     make(chan RectQuery),
+		IntersectionChannel:   make(chan IntersectionQuery),
+		EdgeSearchChannel:     make(chan NearestEdgesQuery)}
 
-	go finder.run()
+	supervisor.Go("finder.ElementFinder", finder.run)
 
 	return &finder
 }
 
-func (e *ElementFinder) run() {
+func (e *ElementFinder) run(ctx context.Context) {
 	for {
 		select {
 		case newElement := <-e.AddElementChannel:
-			if _, ok := e.elements[newElement.Type]; !ok {
-				e.elements[newElement.Type] = make(map[int64]Element)
-			}
-
-			e.elements[newElement.Type][newElement.Id] = newElement
+			e.addElement(newElement)
+		case updatedElement := <-e.UpdateElementChannel:
+			e.updateElement(updatedElement)
+		case key := <-e.RemoveElementChannel:
+			e.removeElement(key)
 		case search := <-e.KNearestSearchChannel:
 			search.Results <- e.KNearest(search)
 			close(search.Results)
+		case search := <-e.RadiusSearchChannel:
+			search.Results <- e.index.WithinRadius(search.Pos, search.Types, search.Radius)
+			close(search.Results)
+		case search := <-e.RectSearchChannel:
+			search.Results <- e.index.WithinRect(search.Min, search.Max, search.Types)
+			close(search.Results)
+		case search := <-e.IntersectionChannel:
+			search.Results <- e.index.Intersecting(search.Footprint, search.Types)
+			close(search.Results)
+		case search := <-e.EdgeSearchChannel:
+			search.Results <- e.index.NearestEdges(search.Pos, search.Types, search.MaxDistance)
+			close(search.Results)
+		case _ = <-ctx.Done():
+			return
 		}
 	}
 }
 
-// Returns the K-nearest elements, searching via nodes
-func (e *ElementFinder) KNearest(search KNearestNodesQuery) []*NodeWithDistance {
-	nodes := core.NewSortableArray(search.Count)
-
-	for _, itemType := range search.Types {
-		if _, ok := e.elements[itemType]; ok {
-			for _, element := range e.elements[itemType] {
-				for idx, node := range element.Nodes {
-					distance := node.Sub(search.Pos).Len()
-					nodes.Add(NewNodeWithDistance(element.Id, itemType, node, idx, distance))
+// Keeps elements in sync with a graph, removing them when nodes or connections are deleted and moving them when nodes are edited.
+// Node elements should be added with one of the node types and their node ID, and node data should be a SnapNodeProvider.
+// Connection elements should be added with the connection type and their connection ID.
+func (e *ElementFinder) WatchGraph(grid *graph.Graph, nodeTypes []ItemType, connectionType ItemType) {
+	nodeEdits := make(chan graph.NodeEdit, 10)
+	connectionEdits := make(chan graph.ConnectionEdit, 10)
+	lifecycle.Send(e.supervisor.Context(), grid.NodeEditRegChannel, nodeEdits)
+	lifecycle.Send(e.supervisor.Context(), grid.ConnectionEditRegChannel, connectionEdits)
+
+	e.supervisor.Go("finder.GraphWatcher", func(ctx context.Context) {
+		for {
+			select {
+			case edit := <-nodeEdits:
+				// Removing or updating an element that doesn't exist is a no-op, so try each type the node could be.
+				for _, itemType := range nodeTypes {
+					if edit.EditType == graph.Delete {
+						lifecycle.Send(ctx, e.RemoveElementChannel, ElementKey{Type: itemType, Id: edit.NodeIdx})
+					} else if edit.EditType == graph.Edit {
+						if element, ok := nodeDataElement(edit.NodeIdx, itemType, edit.Data); ok {
+							lifecycle.Send(ctx, e.UpdateElementChannel, element)
+						}
+					}
 				}
+			case edit := <-connectionEdits:
+				if edit.EditType == graph.Delete {
+					lifecycle.Send(ctx, e.RemoveElementChannel, ElementKey{Type: connectionType, Id: edit.ConnectionIdx})
+				}
+			case _ = <-ctx.Done():
+				return
 			}
 		}
+	})
+}
+
+// Converts graph node data into an element, if the data has a position
+func nodeDataElement(id int64, itemType ItemType, data interface{}) (Element, bool) {
+	provider, ok := data.(SnapNodeProvider)
+	if !ok {
+		return Element{}, false
+	}
+
+	element := NewElement(id, itemType, provider.GetSnapNodes())
+	if footprintProvider, ok := data.(FootprintProvider); ok {
+		element = NewElementWithFootprint(id, itemType, provider.GetSnapNodes(), footprintProvider.GetFootprint())
+
+		// Edges are only indexed with footprints
+		if edgeProvider, ok := data.(SnapEdgeProvider); ok {
+			element.Edges = edgeProvider.GetSnapEdges()
+		}
 	}
 
-	resultSet := make([]*NodeWithDistance, len(nodes.Items))
-	for idx, item := range nodes.Items {
-		resultSet[idx] = item.(*NodeWithDistance)
+	return element, true
+}
+
+func (e *ElementFinder) addElement(element Element) {
+	if _, ok := e.elements[element.Type]; !ok {
+		e.elements[element.Type] = make(map[int64]Element)
 	}
 
-	return resultSet
+	// Re-adding an element replaces it
+	e.removeElement(element.GetKey())
+
+	e.elements[element.Type][element.Id] = element
+	e.index.Add(element)
+}
+
+// Replaces the nodes of an existing element, ignoring elements that were never added or have been removed
+func (e *ElementFinder) updateElement(element Element) {
+	if _, ok := e.elements[element.Type][element.Id]; ok {
+		e.addElement(element)
+	}
+}
+
+func (e *ElementFinder) removeElement(key ElementKey) {
+	if existing, ok := e.elements[key.Type][key.Id]; ok {
+		e.index.Remove(existing)
+		delete(e.elements[key.Type], key.Id)
+	}
+}
+
+// Returns the K-nearest elements, searching via nodes
+func (e *ElementFinder) KNearest(search KNearestNodesQuery) []*NodeWithDistance {
+	return e.index.KNearest(search.Pos, search.Types, search.Count)
 }
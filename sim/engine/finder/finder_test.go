@@ -0,0 +1,219 @@
+package finder
+
+import (
+	"common/commonmath"
+	"sim/core/graph"
+	"sim/core/lifecycle/lifecycletest"
+	"testing"
+	"time"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+type testTerminus struct {
+	location mgl32.Vec2
+}
+
+func (t *testTerminus) GetSnapNodes() []mgl32.Vec2 {
+	return []mgl32.Vec2{t.location}
+}
+
+func findNearest(finder *ElementFinder, pos mgl32.Vec2, count int) []*NodeWithDistance {
+	results := make(chan []*NodeWithDistance)
+	finder.KNearestSearchChannel <- NewKNNQuery(pos, RoadTerminus, count, results)
+	return <-results
+}
+
+// Polls the finder until the nearest elements have the expected IDs, as graph edits are published asynchronously
+func waitForNearest(t *testing.T, finder *ElementFinder, pos mgl32.Vec2, count int, expectedIds []int64) {
+	deadline := time.Now().Add(time.Second)
+	for {
+		nodes := findNearest(finder, pos, count)
+		if nearestIdsEqual(nodes, expectedIds) {
+			return
+		}
+
+		if time.Now().After(deadline) {
+			t.Errorf("Expected nearest IDs %v, found %v nodes", expectedIds, len(nodes))
+			for _, node := range nodes {
+				t.Errorf("  Found %v at %v", node.Id, node.Pos)
+			}
+			return
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func nearestIdsEqual(nodes []*NodeWithDistance, ids []int64) bool {
+	if len(nodes) != len(ids) {
+		return false
+	}
+
+	for idx, node := range nodes {
+		if node.Id != ids[idx] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestRemoveElement(t *testing.T) {
+	finder := NewElementFinder(lifecycletest.NewSupervisor(t))
+	finder.AddElementChannel <- NewElement(0, RoadTerminus, []mgl32.Vec2{{0, 0}})
+	finder.AddElementChannel <- NewElement(1, RoadTerminus, []mgl32.Vec2{{10, 0}})
+
+	finder.RemoveElementChannel <- ElementKey{Type: RoadTerminus, Id: 0}
+	if nodes := findNearest(finder, mgl32.Vec2{0, 0}, 2); !nearestIdsEqual(nodes, []int64{1}) {
+		t.Errorf("Only element 1 should remain, found %v nodes", len(nodes))
+	}
+
+	// Removing an element twice, or one of another type, is a no-op
+	finder.RemoveElementChannel <- ElementKey{Type: RoadTerminus, Id: 0}
+	finder.RemoveElementChannel <- ElementKey{Type: PowerTerminus, Id: 1}
+	if nodes := findNearest(finder, mgl32.Vec2{0, 0}, 2); !nearestIdsEqual(nodes, []int64{1}) {
+		t.Errorf("Element 1 should still exist, found %v nodes", len(nodes))
+	}
+}
+
+func TestUpdateElement(t *testing.T) {
+	finder := NewElementFinder(lifecycletest.NewSupervisor(t))
+	finder.AddElementChannel <- NewElement(0, RoadTerminus, []mgl32.Vec2{{0, 0}})
+	finder.AddElementChannel <- NewElement(1, RoadTerminus, []mgl32.Vec2{{10, 0}})
+
+	// Move element 0 far away, across several index cells
+	finder.UpdateElementChannel <- NewElement(0, RoadTerminus, []mgl32.Vec2{{1000, 1000}})
+	nodes := findNearest(finder, mgl32.Vec2{0, 0}, 2)
+	if !nearestIdsEqual(nodes, []int64{1, 0}) || nodes[1].Pos != (mgl32.Vec2{1000, 1000}) {
+		t.Errorf("Element 0 should have moved behind element 1")
+	}
+
+	// Updating an element that was never added does not add it
+	finder.UpdateElementChannel <- NewElement(2, RoadTerminus, []mgl32.Vec2{{1, 1}})
+	if nodes := findNearest(finder, mgl32.Vec2{0, 0}, 3); len(nodes) != 2 {
+		t.Errorf("Updates should not add elements, found %v nodes", len(nodes))
+	}
+}
+
+func TestReAddReplacesElement(t *testing.T) {
+	finder := NewElementFinder(lifecycletest.NewSupervisor(t))
+	finder.AddElementChannel <- NewElement(0, RoadTerminus, []mgl32.Vec2{{0, 0}})
+	finder.AddElementChannel <- NewElement(0, RoadTerminus, []mgl32.Vec2{{5, 5}})
+
+	nodes := findNearest(finder, mgl32.Vec2{0, 0}, 3)
+	if len(nodes) != 1 || nodes[0].Pos != (mgl32.Vec2{5, 5}) {
+		t.Errorf("Re-adding an element should replace it, found %v nodes", len(nodes))
+	}
+}
+
+func TestWatchGraph(t *testing.T) {
+	supervisor := lifecycletest.NewSupervisor(t)
+	grid := graph.NewGraph(supervisor)
+	finder := NewElementFinder(supervisor)
+	finder.WatchGraph(grid, []ItemType{RoadTerminus}, RoadLine)
+
+	first := grid.AddNode(&testTerminus{location: mgl32.Vec2{0, 0}})
+	finder.AddElementChannel <- NewElement(first, RoadTerminus, []mgl32.Vec2{{0, 0}})
+	second := grid.AddNode(&testTerminus{location: mgl32.Vec2{10, 0}})
+	finder.AddElementChannel <- NewElement(second, RoadTerminus, []mgl32.Vec2{{10, 0}})
+	grid.AddConnection(first, second, nil)
+
+	grid.UpdateNode(second, &testTerminus{location: mgl32.Vec2{-500, 0}})
+	waitForNearest(t, finder, mgl32.Vec2{-500, 0}, 1, []int64{second})
+
+	grid.DeleteNode(second)
+	waitForNearest(t, finder, mgl32.Vec2{-500, 0}, 2, []int64{first})
+}
+
+func findIntersecting(finder *ElementFinder, footprint Footprint, types []ItemType) []ElementKey {
+	results := make(chan []ElementKey)
+	finder.IntersectionChannel <- NewIntersectionQuery(footprint, types, results)
+	return <-results
+}
+
+func TestWatchGraphRemovesConnections(t *testing.T) {
+	supervisor := lifecycletest.NewSupervisor(t)
+	grid := graph.NewGraph(supervisor)
+	finder := NewElementFinder(supervisor)
+	finder.WatchGraph(grid, []ItemType{RoadTerminus}, RoadLine)
+
+	first := grid.AddNode(&testTerminus{location: mgl32.Vec2{0, 0}})
+	second := grid.AddNode(&testTerminus{location: mgl32.Vec2{100, 0}})
+	line := grid.AddConnection(first, second, nil).Id
+	finder.AddElementChannel <- NewElementWithFootprint(line, RoadLine, []mgl32.Vec2{{0, 0}, {100, 0}}, NewCorridorFootprint(mgl32.Vec2{0, 0}, mgl32.Vec2{100, 0}, 4))
+
+	crossing := NewCorridorFootprint(mgl32.Vec2{50, -50}, mgl32.Vec2{50, 50}, 4)
+	if keys := findIntersecting(finder, crossing, []ItemType{RoadLine}); len(keys) != 1 || keys[0].Id != line {
+		t.Errorf("Expected the crossing to intersect line %v, found %v", line, keys)
+	}
+
+	grid.DeleteConnection(first, second)
+
+	deadline := time.Now().Add(time.Second)
+	for len(findIntersecting(finder, crossing, []ItemType{RoadLine})) != 0 {
+		if time.Now().After(deadline) {
+			t.Error("The deleted line should have been removed from the finder")
+			return
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestIntersectionQuery(t *testing.T) {
+	finder := NewElementFinder(lifecycletest.NewSupervisor(t))
+
+	// Plants span index cells, and lines cross several of them
+	plant := NewRegionFootprint(commonMath.Region{RegionType: commonMath.SquareRegion, Position: mgl32.Vec2{200, 200}, Scale: 50})
+	finder.AddElementChannel <- NewElementWithFootprint(0, PowerPlant, []mgl32.Vec2{{200, 200}}, plant)
+	finder.AddElementChannel <- NewElementWithFootprint(1, PowerLine, []mgl32.Vec2{{-500, 0}, {500, 0}}, NewCorridorFootprint(mgl32.Vec2{-500, 0}, mgl32.Vec2{500, 0}, 4))
+	finder.AddElementChannel <- NewElement(2, PowerTerminus, []mgl32.Vec2{{200, 200}})
+
+	allTypes := []ItemType{PowerPlant, PowerLine, PowerTerminus}
+	overlapping := NewRegionFootprint(commonMath.Region{RegionType: commonMath.SquareRegion, Position: mgl32.Vec2{180, 180}, Scale: 50})
+	if keys := findIntersecting(finder, overlapping, allTypes); len(keys) != 1 || keys[0] != (ElementKey{PowerPlant, 0}) {
+		t.Errorf("Expected only the plant to overlap, found %v", keys)
+	}
+
+	onLine := NewRegionFootprint(commonMath.Region{RegionType: commonMath.CircleRegion, Position: mgl32.Vec2{-390, 10}, Scale: 30})
+	if keys := findIntersecting(finder, onLine, allTypes); len(keys) != 1 || keys[0] != (ElementKey{PowerLine, 1}) {
+		t.Errorf("Expected only the line to overlap, found %v", keys)
+	}
+
+	if keys := findIntersecting(finder, overlapping, []ItemType{PowerLine}); len(keys) != 0 {
+		t.Errorf("Types should be filtered, found %v", keys)
+	}
+
+	finder.RemoveElementChannel <- ElementKey{PowerPlant, 0}
+	if keys := findIntersecting(finder, overlapping, allTypes); len(keys) != 0 {
+		t.Errorf("The removed plant should not overlap, found %v", keys)
+	}
+}
+
+func findNearestEdges(finder *ElementFinder, pos mgl32.Vec2, types []ItemType, maxDistance float32) []*EdgeWithDistance {
+	results := make(chan []*EdgeWithDistance)
+	finder.EdgeSearchChannel <- NewNearestEdgesQuery(pos, types, maxDistance, results)
+	return <-results
+}
+
+func TestNearestEdges(t *testing.T) {
+	finder := NewElementFinder(lifecycletest.NewSupervisor(t))
+	finder.AddElementChannel <- NewLineElement(0, RoadLine, [2]mgl32.Vec2{{-500, 0}, {500, 0}}, NewCorridorFootprint(mgl32.Vec2{-500, 0}, mgl32.Vec2{500, 0}, 4))
+	finder.AddElementChannel <- NewLineElement(1, RoadLine, [2]mgl32.Vec2{{0, 20}, {0, 500}}, NewCorridorFootprint(mgl32.Vec2{0, 20}, mgl32.Vec2{0, 500}, 4))
+
+	edges := findNearestEdges(finder, mgl32.Vec2{300, 5}, []ItemType{RoadLine}, 50)
+	if len(edges) != 1 || edges[0].Id != 0 || edges[0].Pos != (mgl32.Vec2{300, 0}) {
+		t.Errorf("Expected the point on line 0 nearest to the position, found %v edges", len(edges))
+	}
+
+	// Positions past the end of a line snap to the line end
+	edges = findNearestEdges(finder, mgl32.Vec2{3, 12}, []ItemType{RoadLine}, 50)
+	if len(edges) != 2 || edges[0].Id != 1 || edges[0].Pos != (mgl32.Vec2{0, 20}) || edges[1].Id != 0 {
+		t.Errorf("Expected both lines sorted by distance, found %v edges", len(edges))
+	}
+
+	if edges := findNearestEdges(finder, mgl32.Vec2{300, 5}, []ItemType{PowerLine}, 50); len(edges) != 0 {
+		t.Errorf("Types should be filtered, found %v edges", len(edges))
+	}
+}
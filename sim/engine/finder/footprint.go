@@ -0,0 +1,120 @@
+package finder
+
+import (
+	"common/commonmath"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Defines the area an element covers on the map, as either an oriented box or a circle.
+type Footprint struct {
+	Center mgl32.Vec2
+
+	isCircle bool
+	radius   float32
+
+	// Box half-widths along the (rotated) X and Y axes of the box
+	halfExtents mgl32.Vec2
+	orientation float32
+}
+
+// Creates a footprint covering a region. Triangle regions are treated as their bounding circle.
+func NewRegionFootprint(region commonMath.Region) Footprint {
+	if region.RegionType == commonMath.SquareRegion {
+		return Footprint{
+			Center:      region.Position,
+			isCircle:    false,
+			halfExtents: mgl32.Vec2{region.Scale / 2, region.Scale / 2},
+			orientation: region.Orientation}
+	}
+
+	return Footprint{
+		Center:   region.Position,
+		isCircle: true,
+		radius:   region.Scale / 2}
+}
+
+// Creates a footprint covering a line of the given width, such as the corridor taken by a road or powerline
+func NewCorridorFootprint(start, end mgl32.Vec2, width float32) Footprint {
+	direction := end.Sub(start)
+	return Footprint{
+		Center:      start.Add(end).Mul(0.5),
+		isCircle:    false,
+		halfExtents: mgl32.Vec2{direction.Len() / 2, width / 2},
+		orientation: float32(math.Atan2(float64(direction.Y()), float64(direction.X())))}
+}
+
+// Returns the unit X and Y axes of the footprint
+func (f Footprint) axes() (mgl32.Vec2, mgl32.Vec2) {
+	sin, cos := math.Sincos(float64(f.orientation))
+	return mgl32.Vec2{float32(cos), float32(sin)}, mgl32.Vec2{float32(-sin), float32(cos)}
+}
+
+// Returns the axis-aligned bounds of the footprint
+func (f Footprint) Bounds() (mgl32.Vec2, mgl32.Vec2) {
+	if f.isCircle {
+		offset := mgl32.Vec2{f.radius, f.radius}
+		return f.Center.Sub(offset), f.Center.Add(offset)
+	}
+
+	xAxis, yAxis := f.axes()
+	offset := mgl32.Vec2{
+		abs(xAxis.X())*f.halfExtents.X() + abs(yAxis.X())*f.halfExtents.Y(),
+		abs(xAxis.Y())*f.halfExtents.X() + abs(yAxis.Y())*f.halfExtents.Y()}
+	return f.Center.Sub(offset), f.Center.Add(offset)
+}
+
+// Returns true if the footprints overlap. Footprints that only touch do not overlap.
+func (f Footprint) Intersects(other Footprint) bool {
+	if f.isCircle && other.isCircle {
+		return f.Center.Sub(other.Center).Len() < f.radius+other.radius
+	} else if f.isCircle {
+		return other.intersectsCircle(f)
+	} else if other.isCircle {
+		return f.intersectsCircle(other)
+	}
+
+	return f.intersectsBox(other)
+}
+
+// Finds the closest point of the box to the circle center, in the box's frame
+func (f Footprint) intersectsCircle(circle Footprint) bool {
+	xAxis, yAxis := f.axes()
+	offset := circle.Center.Sub(f.Center)
+
+	localX := clamp(offset.Dot(xAxis), -f.halfExtents.X(), f.halfExtents.X())
+	localY := clamp(offset.Dot(yAxis), -f.halfExtents.Y(), f.halfExtents.Y())
+	closest := f.Center.Add(xAxis.Mul(localX)).Add(yAxis.Mul(localY))
+
+	return closest.Sub(circle.Center).Len() < circle.radius
+}
+
+// Uses the separating axis theorem, which only needs the two axes of each box
+func (f Footprint) intersectsBox(other Footprint) bool {
+	firstX, firstY := f.axes()
+	secondX, secondY := other.axes()
+	offset := other.Center.Sub(f.Center)
+
+	for _, axis := range []mgl32.Vec2{firstX, firstY, secondX, secondY} {
+		firstProjection := f.halfExtents.X()*abs(firstX.Dot(axis)) + f.halfExtents.Y()*abs(firstY.Dot(axis))
+		secondProjection := other.halfExtents.X()*abs(secondX.Dot(axis)) + other.halfExtents.Y()*abs(secondY.Dot(axis))
+		if abs(offset.Dot(axis)) >= firstProjection+secondProjection {
+			return false
+		}
+	}
+
+	return true
+}
+
+func abs(value float32) float32 {
+	if value < 0 {
+		return -value
+	}
+
+	return value
+}
+
+func clamp(value, minValue, maxValue float32) float32 {
+	return max(minValue, min(value, maxValue))
+}
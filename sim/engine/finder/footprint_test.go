@@ -0,0 +1,56 @@
+package finder
+
+import (
+	"common/commonmath"
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func square(pos mgl32.Vec2, scale, orientation float32) Footprint {
+	return NewRegionFootprint(commonMath.Region{RegionType: commonMath.SquareRegion, Position: pos, Scale: scale, Orientation: orientation})
+}
+
+func circle(pos mgl32.Vec2, scale float32) Footprint {
+	return NewRegionFootprint(commonMath.Region{RegionType: commonMath.CircleRegion, Position: pos, Scale: scale})
+}
+
+func checkIntersects(t *testing.T, name string, first, second Footprint, expected bool) {
+	if first.Intersects(second) != expected || second.Intersects(first) != expected {
+		t.Errorf("%v: expected intersection to be %v", name, expected)
+	}
+}
+
+func TestSquareIntersections(t *testing.T) {
+	checkIntersects(t, "Overlapping squares", square(mgl32.Vec2{0, 0}, 10, 0), square(mgl32.Vec2{9, 9}, 10, 0), true)
+	checkIntersects(t, "Touching squares", square(mgl32.Vec2{0, 0}, 10, 0), square(mgl32.Vec2{10, 0}, 10, 0), false)
+	checkIntersects(t, "Separate squares", square(mgl32.Vec2{0, 0}, 10, 0), square(mgl32.Vec2{20, 0}, 10, 0), false)
+
+	// A square rotated 45 degrees reaches ~7.07 along each axis, so only overlaps on the axis.
+	checkIntersects(t, "Rotated square along an axis", square(mgl32.Vec2{0, 0}, 10, math.Pi/4), square(mgl32.Vec2{11, 0}, 10, 0), true)
+	checkIntersects(t, "Rotated square near a corner", square(mgl32.Vec2{0, 0}, 10, math.Pi/4), square(mgl32.Vec2{10.5, 10.5}, 10, 0), false)
+}
+
+func TestCircleIntersections(t *testing.T) {
+	checkIntersects(t, "Overlapping circles", circle(mgl32.Vec2{0, 0}, 10), circle(mgl32.Vec2{9, 0}, 10), true)
+	checkIntersects(t, "Separate circles", circle(mgl32.Vec2{0, 0}, 10), circle(mgl32.Vec2{11, 0}, 10), false)
+
+	checkIntersects(t, "Circle on a square edge", circle(mgl32.Vec2{9, 0}, 10), square(mgl32.Vec2{0, 0}, 10, 0), true)
+	checkIntersects(t, "Circle near a square corner", circle(mgl32.Vec2{9, 9}, 10), square(mgl32.Vec2{0, 0}, 10, 0), false)
+	checkIntersects(t, "Circle inside a square", circle(mgl32.Vec2{1, 1}, 2), square(mgl32.Vec2{0, 0}, 10, 0), true)
+}
+
+func TestCorridorIntersections(t *testing.T) {
+	diagonal := NewCorridorFootprint(mgl32.Vec2{0, 0}, mgl32.Vec2{100, 100}, 2)
+
+	checkIntersects(t, "Square on a diagonal corridor", diagonal, square(mgl32.Vec2{50, 50}, 4, 0), true)
+	checkIntersects(t, "Square beside a diagonal corridor", diagonal, square(mgl32.Vec2{60, 40}, 4, 0), false)
+	checkIntersects(t, "Square past the corridor end", diagonal, square(mgl32.Vec2{110, 110}, 4, 0), false)
+	checkIntersects(t, "Crossing corridors", diagonal, NewCorridorFootprint(mgl32.Vec2{0, 100}, mgl32.Vec2{100, 0}, 2), true)
+
+	minPos, maxPos := diagonal.Bounds()
+	if minPos.X() > 0 || minPos.Y() > 0 || maxPos.X() < 100 || maxPos.Y() < 100 || maxPos.X() > 101 {
+		t.Errorf("Corridor bounds are incorrect: %v to %v", minPos, maxPos)
+	}
+}
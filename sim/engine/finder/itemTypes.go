@@ -7,4 +7,27 @@ type ItemType int
 const (
 	PowerTerminus ItemType = iota
 	RoadTerminus
+	PowerPlant
+	Building
+	RoadLine
+	PowerLine
 )
+
+func (i ItemType) String() string {
+	switch i {
+	case PowerTerminus:
+		return "power terminus"
+	case RoadTerminus:
+		return "road terminus"
+	case PowerPlant:
+		return "power plant"
+	case Building:
+		return "building"
+	case RoadLine:
+		return "road"
+	case PowerLine:
+		return "powerline"
+	default:
+		return "unknown item"
+	}
+}
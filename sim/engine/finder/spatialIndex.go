@@ -0,0 +1,378 @@
+package finder
+
+import (
+	"common/commonmath"
+	"math"
+	"sim/core"
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Used when the index is created without a valid cell size, such as before config has loaded
+const defaultCellSize float32 = 200
+
+type cellIndex struct {
+	x, y int
+}
+
+type cellBounds struct {
+	min, max cellIndex
+}
+
+func (b *cellBounds) expand(cell cellIndex) {
+	b.min.x = min(b.min.x, cell.x)
+	b.min.y = min(b.min.y, cell.y)
+	b.max.x = max(b.max.x, cell.x)
+	b.max.y = max(b.max.y, cell.y)
+}
+
+type indexedFootprint struct {
+	id        int64
+	footprint Footprint
+	edges     [][2]mgl32.Vec2
+}
+
+type indexedNode struct {
+	id        int64
+	pos       mgl32.Vec2
+	nodeIndex int
+}
+
+// Defines a uniform grid of square cells, bucketing element nodes by item type and cell for fast spatial queries.
+// Cells are aligned to the origin, so a cell size equal to the terrain region size aligns cells with regions.
+type SpatialIndex struct {
+	cellSize float32
+	cells    map[ItemType]map[cellIndex][]indexedNode
+
+	// Footprints are stored in every cell their bounds overlap
+	footprintCells map[ItemType]map[cellIndex][]indexedFootprint
+
+	// Bounds only ever grow, which keeps searches correct (if conservative) after removals.
+	bounds map[ItemType]*cellBounds
+}
+
+func NewSpatialIndex(cellSize float32) *SpatialIndex {
+	if cellSize <= 0 {
+		cellSize = defaultCellSize
+	}
+
+	return &SpatialIndex{
+		cellSize:       cellSize,
+		cells:          make(map[ItemType]map[cellIndex][]indexedNode),
+		footprintCells: make(map[ItemType]map[cellIndex][]indexedFootprint),
+		bounds:         make(map[ItemType]*cellBounds)}
+}
+
+func (s *SpatialIndex) getCell(pos mgl32.Vec2) cellIndex {
+	return cellIndex{
+		x: int(math.Floor(float64(pos.X() / s.cellSize))),
+		y: int(math.Floor(float64(pos.Y() / s.cellSize)))}
+}
+
+// Adds all nodes of an element to the index
+func (s *SpatialIndex) Add(element Element) {
+	if _, ok := s.cells[element.Type]; !ok {
+		s.cells[element.Type] = make(map[cellIndex][]indexedNode)
+	}
+
+	for idx, node := range element.Nodes {
+		cell := s.getCell(node)
+		s.cells[element.Type][cell] = append(s.cells[element.Type][cell], indexedNode{id: element.Id, pos: node, nodeIndex: idx})
+
+		if bounds, ok := s.bounds[element.Type]; ok {
+			bounds.expand(cell)
+		} else {
+			s.bounds[element.Type] = &cellBounds{min: cell, max: cell}
+		}
+	}
+
+	if element.Footprint != nil {
+		if _, ok := s.footprintCells[element.Type]; !ok {
+			s.footprintCells[element.Type] = make(map[cellIndex][]indexedFootprint)
+		}
+
+		s.visitFootprintCells(*element.Footprint, func(cell cellIndex) {
+			s.footprintCells[element.Type][cell] = append(s.footprintCells[element.Type][cell], indexedFootprint{id: element.Id, footprint: *element.Footprint, edges: element.Edges})
+		})
+	}
+}
+
+// Removes all nodes of an element from the index. The element nodes must match those it was added with.
+func (s *SpatialIndex) Remove(element Element) {
+	typeCells, ok := s.cells[element.Type]
+	if !ok {
+		return
+	}
+
+	for _, node := range element.Nodes {
+		cell := s.getCell(node)
+
+		remaining := make([]indexedNode, 0, len(typeCells[cell]))
+		for _, existing := range typeCells[cell] {
+			if existing.id != element.Id {
+				remaining = append(remaining, existing)
+			}
+		}
+
+		if len(remaining) == 0 {
+			delete(typeCells, cell)
+		} else {
+			typeCells[cell] = remaining
+		}
+	}
+
+	if element.Footprint != nil {
+		s.removeFootprint(element)
+	}
+}
+
+func (s *SpatialIndex) removeFootprint(element Element) {
+	typeCells, ok := s.footprintCells[element.Type]
+	if !ok {
+		return
+	}
+
+	s.visitFootprintCells(*element.Footprint, func(cell cellIndex) {
+		remaining := make([]indexedFootprint, 0, len(typeCells[cell]))
+		for _, existing := range typeCells[cell] {
+			if existing.id != element.Id {
+				remaining = append(remaining, existing)
+			}
+		}
+
+		if len(remaining) == 0 {
+			delete(typeCells, cell)
+		} else {
+			typeCells[cell] = remaining
+		}
+	})
+}
+
+// Visits every cell overlapped by the bounds of the footprint
+func (s *SpatialIndex) visitFootprintCells(footprint Footprint, visit func(cell cellIndex)) {
+	minPos, maxPos := footprint.Bounds()
+	minCell := s.getCell(minPos)
+	maxCell := s.getCell(maxPos)
+	for x := minCell.x; x <= maxCell.x; x++ {
+		for y := minCell.y; y <= maxCell.y; y++ {
+			visit(cellIndex{x, y})
+		}
+	}
+}
+
+// Visits every node in the cell for the given types
+func (s *SpatialIndex) visitCell(cell cellIndex, types []ItemType, visit func(itemType ItemType, node indexedNode)) {
+	for _, itemType := range types {
+		for _, node := range s.cells[itemType][cell] {
+			visit(itemType, node)
+		}
+	}
+}
+
+// Visits every cell exactly ring cells away (in Chebyshev distance) from the center cell
+func (s *SpatialIndex) visitRing(center cellIndex, ring int, types []ItemType, visit func(itemType ItemType, node indexedNode)) {
+	if ring == 0 {
+		s.visitCell(center, types, visit)
+		return
+	}
+
+	for dx := -ring; dx <= ring; dx++ {
+		s.visitCell(cellIndex{center.x + dx, center.y - ring}, types, visit)
+		s.visitCell(cellIndex{center.x + dx, center.y + ring}, types, visit)
+	}
+
+	for dy := -ring + 1; dy <= ring-1; dy++ {
+		s.visitCell(cellIndex{center.x - ring, center.y + dy}, types, visit)
+		s.visitCell(cellIndex{center.x + ring, center.y + dy}, types, visit)
+	}
+}
+
+// Returns the combined bounds of the given types, or false if none have been indexed
+func (s *SpatialIndex) getBounds(types []ItemType) (cellBounds, bool) {
+	var combined cellBounds
+	found := false
+	for _, itemType := range types {
+		if bounds, ok := s.bounds[itemType]; ok {
+			if !found {
+				combined = *bounds
+				found = true
+			} else {
+				combined.expand(bounds.min)
+				combined.expand(bounds.max)
+			}
+		}
+	}
+
+	return combined, found
+}
+
+func toResults(nodes []core.Sortable) []*NodeWithDistance {
+	resultSet := make([]*NodeWithDistance, len(nodes))
+	for idx, item := range nodes {
+		resultSet[idx] = item.(*NodeWithDistance)
+	}
+
+	return resultSet
+}
+
+// Returns the K-nearest nodes of the given types, sorted by distance
+func (s *SpatialIndex) KNearest(pos mgl32.Vec2, types []ItemType, count int) []*NodeWithDistance {
+	nodes := core.NewSortableArray(count)
+
+	bounds, ok := s.getBounds(types)
+	if !ok || count <= 0 {
+		return toResults(nodes.Items)
+	}
+
+	center := s.getCell(pos)
+	maxRing := max(
+		center.x-bounds.min.x, bounds.max.x-center.x,
+		center.y-bounds.min.y, bounds.max.y-center.y)
+
+	for ring := 0; ring <= maxRing; ring++ {
+		s.visitRing(center, ring, types, func(itemType ItemType, node indexedNode) {
+			nodes.Add(NewNodeWithDistance(node.id, itemType, node.pos, node.nodeIndex, node.pos.Sub(pos).Len()))
+		})
+
+		// Every node in further rings is at least this far away, so there's nothing closer to find.
+		if len(nodes.Items) == count && nodes.Items[count-1].GetDistance() <= float32(ring)*s.cellSize {
+			break
+		}
+	}
+
+	return toResults(nodes.Items)
+}
+
+// Visits all nodes of the given types in the cells overlapping the rectangle
+func (s *SpatialIndex) visitRect(minPos, maxPos mgl32.Vec2, types []ItemType, visit func(itemType ItemType, node indexedNode)) {
+	bounds, ok := s.getBounds(types)
+	if !ok {
+		return
+	}
+
+	minCell := s.getCell(minPos)
+	maxCell := s.getCell(maxPos)
+	for x := max(minCell.x, bounds.min.x); x <= min(maxCell.x, bounds.max.x); x++ {
+		for y := max(minCell.y, bounds.min.y); y <= min(maxCell.y, bounds.max.y); y++ {
+			s.visitCell(cellIndex{x, y}, types, visit)
+		}
+	}
+}
+
+func sortByDistance(nodes []*NodeWithDistance) {
+	sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].Distance < nodes[j].Distance })
+}
+
+// Returns all nodes of the given types within the radius, sorted by distance
+func (s *SpatialIndex) WithinRadius(pos mgl32.Vec2, types []ItemType, radius float32) []*NodeWithDistance {
+	results := make([]*NodeWithDistance, 0)
+
+	offset := mgl32.Vec2{radius, radius}
+	s.visitRect(pos.Sub(offset), pos.Add(offset), types, func(itemType ItemType, node indexedNode) {
+		if distance := node.pos.Sub(pos).Len(); distance <= radius {
+			results = append(results, NewNodeWithDistance(node.id, itemType, node.pos, node.nodeIndex, distance))
+		}
+	})
+
+	sortByDistance(results)
+	return results
+}
+
+// Returns all nodes of the given types within the rectangle, sorted by distance from the rectangle center
+func (s *SpatialIndex) WithinRect(minPos, maxPos mgl32.Vec2, types []ItemType) []*NodeWithDistance {
+	results := make([]*NodeWithDistance, 0)
+
+	center := minPos.Add(maxPos).Mul(0.5)
+	s.visitRect(minPos, maxPos, types, func(itemType ItemType, node indexedNode) {
+		if node.pos.X() >= minPos.X() && node.pos.X() <= maxPos.X() &&
+			node.pos.Y() >= minPos.Y() && node.pos.Y() <= maxPos.Y() {
+			results = append(results, NewNodeWithDistance(node.id, itemType, node.pos, node.nodeIndex, node.pos.Sub(center).Len()))
+		}
+	})
+
+	sortByDistance(results)
+	return results
+}
+
+// Returns the keys of all elements of the given types whose footprints overlap the footprint, sorted by type and then ID
+func (s *SpatialIndex) Intersecting(footprint Footprint, types []ItemType) []ElementKey {
+	found := make(map[ElementKey]bool)
+	s.visitFootprintCells(footprint, func(cell cellIndex) {
+		for _, itemType := range types {
+			for _, existing := range s.footprintCells[itemType][cell] {
+				key := ElementKey{Type: itemType, Id: existing.id}
+				if !found[key] && existing.footprint.Intersects(footprint) {
+					found[key] = true
+				}
+			}
+		}
+	})
+
+	results := make([]ElementKey, 0, len(found))
+	for key := range found {
+		results = append(results, key)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Type != results[j].Type {
+			return results[i].Type < results[j].Type
+		}
+
+		return results[i].Id < results[j].Id
+	})
+	return results
+}
+
+// Returns the closest point on the line segment to the position
+func closestPointOnEdge(edge [2]mgl32.Vec2, pos mgl32.Vec2) mgl32.Vec2 {
+	direction := edge[1].Sub(edge[0])
+	lengthSquared := direction.Dot(direction)
+	if lengthSquared == 0 {
+		return edge[0]
+	}
+
+	fraction := clamp(pos.Sub(edge[0]).Dot(direction)/lengthSquared, 0, 1)
+	return edge[0].Add(direction.Mul(fraction))
+}
+
+// Returns the closest point of each edge of the given types within the distance, sorted by distance
+func (s *SpatialIndex) NearestEdges(pos mgl32.Vec2, types []ItemType, maxDistance float32) []*EdgeWithDistance {
+	type edgeKey struct {
+		element   ElementKey
+		edgeIndex int
+	}
+
+	found := make(map[edgeKey]bool)
+	results := make([]*EdgeWithDistance, 0)
+
+	// Edges lie within footprints, so only footprints overlapping the search area can have edges in range.
+	area := NewRegionFootprint(commonMath.Region{RegionType: commonMath.CircleRegion, Position: pos, Scale: maxDistance * 2})
+	s.visitFootprintCells(area, func(cell cellIndex) {
+		for _, itemType := range types {
+			for _, existing := range s.footprintCells[itemType][cell] {
+				for edgeIndex, edge := range existing.edges {
+					key := edgeKey{element: ElementKey{Type: itemType, Id: existing.id}, edgeIndex: edgeIndex}
+					if found[key] {
+						continue
+					}
+
+					found[key] = true
+					closest := closestPointOnEdge(edge, pos)
+					if distance := closest.Sub(pos).Len(); distance <= maxDistance {
+						results = append(results, &EdgeWithDistance{
+							Id:        existing.id,
+							Type:      itemType,
+							Edge:      edge,
+							EdgeIndex: edgeIndex,
+							Pos:       closest,
+							Distance:  distance})
+					}
+				}
+			}
+		}
+	})
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Distance < results[j].Distance })
+	return results
+}
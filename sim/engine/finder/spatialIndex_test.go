@@ -0,0 +1,170 @@
+package finder
+
+import (
+	"math"
+	"math/rand"
+	"sim/core"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func floatCompare(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 0.001
+}
+
+// Finds the K-nearest nodes by checking every node, as the finder originally did
+func linearKNearest(elements []Element, pos mgl32.Vec2, count int) []core.Sortable {
+	nodes := core.NewSortableArray(count)
+	for _, element := range elements {
+		for idx, node := range element.Nodes {
+			nodes.Add(NewNodeWithDistance(element.Id, element.Type, node, idx, node.Sub(pos).Len()))
+		}
+	}
+
+	return nodes.Items
+}
+
+func randomElements(count int, extent float32) []Element {
+	random := rand.New(rand.NewSource(42))
+
+	elements := make([]Element, count)
+	for i := range elements {
+		pos := mgl32.Vec2{(random.Float32()*2 - 1) * extent, (random.Float32()*2 - 1) * extent}
+		elements[i] = NewElement(int64(i), RoadTerminus, []mgl32.Vec2{pos})
+	}
+
+	return elements
+}
+
+func newTestIndex(elements []Element) *SpatialIndex {
+	index := NewSpatialIndex(200)
+	for _, element := range elements {
+		index.Add(element)
+	}
+
+	return index
+}
+
+func TestEmptyIndex(t *testing.T) {
+	index := NewSpatialIndex(200)
+	if len(index.KNearest(mgl32.Vec2{0, 0}, []ItemType{RoadTerminus}, 3)) != 0 {
+		t.Error("No items should exist")
+	}
+}
+
+func TestKNearestMatchesLinearSearch(t *testing.T) {
+	elements := randomElements(500, 2000)
+	index := newTestIndex(elements)
+
+	for _, pos := range []mgl32.Vec2{{0, 0}, {-1999, 1999}, {5000, -5000}, {123.4, -567.8}} {
+		expected := linearKNearest(elements, pos, 5)
+		actual := index.KNearest(pos, []ItemType{RoadTerminus}, 5)
+
+		if len(actual) != len(expected) {
+			t.Fatalf("Expected %v results, found %v", len(expected), len(actual))
+		}
+
+		for i := range expected {
+			if !floatCompare(expected[i].GetDistance(), actual[i].Distance) {
+				t.Errorf("Result %v at %v should be %v away, found %v", i, pos, expected[i].GetDistance(), actual[i].Distance)
+			}
+		}
+	}
+}
+
+func TestKNearestFiltersTypes(t *testing.T) {
+	index := NewSpatialIndex(200)
+	index.Add(NewElement(1, PowerTerminus, []mgl32.Vec2{{1, 1}}))
+	index.Add(NewElement(2, RoadTerminus, []mgl32.Vec2{{500, 500}}))
+
+	results := index.KNearest(mgl32.Vec2{0, 0}, []ItemType{RoadTerminus}, 1)
+	if len(results) != 1 || results[0].Id != 2 || results[0].Type != RoadTerminus {
+		t.Error("Only nodes of the requested type should be found")
+	}
+}
+
+func TestRemove(t *testing.T) {
+	index := NewSpatialIndex(200)
+	first := NewElement(1, RoadTerminus, []mgl32.Vec2{{1, 1}, {2, 2}})
+	index.Add(first)
+	index.Add(NewElement(2, RoadTerminus, []mgl32.Vec2{{3, 3}}))
+
+	index.Remove(first)
+	results := index.KNearest(mgl32.Vec2{0, 0}, []ItemType{RoadTerminus}, 3)
+	if len(results) != 1 || results[0].Id != 2 {
+		t.Error("Removed elements should not be found")
+	}
+}
+
+func TestWithinRadius(t *testing.T) {
+	elements := randomElements(500, 2000)
+	index := newTestIndex(elements)
+
+	pos := mgl32.Vec2{100, -100}
+	expected := 0
+	for _, element := range elements {
+		if element.Nodes[0].Sub(pos).Len() <= 300 {
+			expected++
+		}
+	}
+
+	results := index.WithinRadius(pos, []ItemType{RoadTerminus}, 300)
+	if len(results) != expected {
+		t.Errorf("Expected %v nodes within the radius, found %v", expected, len(results))
+	}
+
+	for i := 1; i < len(results); i++ {
+		if results[i-1].Distance > results[i].Distance {
+			t.Error("Radius results should be sorted by distance")
+		}
+	}
+}
+
+func TestWithinRect(t *testing.T) {
+	elements := randomElements(500, 2000)
+	index := newTestIndex(elements)
+
+	minPos := mgl32.Vec2{-450, 10}
+	maxPos := mgl32.Vec2{250, 900}
+	expected := 0
+	for _, element := range elements {
+		node := element.Nodes[0]
+		if node.X() >= minPos.X() && node.X() <= maxPos.X() && node.Y() >= minPos.Y() && node.Y() <= maxPos.Y() {
+			expected++
+		}
+	}
+
+	if results := index.WithinRect(minPos, maxPos, []ItemType{RoadTerminus}); len(results) != expected {
+		t.Errorf("Expected %v nodes within the rectangle, found %v", expected, len(results))
+	}
+}
+
+func BenchmarkLinearKNearest(b *testing.B) {
+	elements := randomElements(10000, 10000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		linearKNearest(elements, mgl32.Vec2{float32(i % 1000), 0}, 5)
+	}
+}
+
+func BenchmarkIndexedKNearest(b *testing.B) {
+	index := newTestIndex(randomElements(10000, 10000))
+	types := []ItemType{RoadTerminus}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		index.KNearest(mgl32.Vec2{float32(i % 1000), 0}, types, 5)
+	}
+}
+
+func BenchmarkIndexedWithinRadius(b *testing.B) {
+	index := newTestIndex(randomElements(10000, 10000))
+	types := []ItemType{RoadTerminus}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		index.WithinRadius(mgl32.Vec2{float32(i % 1000), 0}, types, 500)
+	}
+}
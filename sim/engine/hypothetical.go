@@ -2,8 +2,10 @@ package engine
 
 import (
 	"common/commonmath"
+	"sim/config"
 	"sim/core/dto/editorengdto"
 	"sim/engine/power"
+	"sim/engine/road"
 	"sim/input/editorEngine"
 
 	"github.com/go-gl/mathgl/mgl32"
@@ -17,6 +19,7 @@ type HypotheticalRegion struct {
 type HypotheticalLine struct {
 	Color mgl32.Vec3
 	Line  [2]mgl32.Vec2
+	Cost  float32
 }
 
 // Defines hypothetical regions for drawing and actions
@@ -42,17 +45,9 @@ func (h *HypotheticalActions) setSingleRegion(region HypotheticalRegion) {
 }
 
 func (e *HypotheticalActions) computePowerPlantHypotheticalRegion(n *Engine) {
-	plantType := power.GetPlantType(editorengdto.Item1) // editorEngine.EngineState.ItemSubSelection)
-	plantSize := power.Small                            // TODO: Configurable
+	_, _, region := n.getPlantPlacement()
 
 	// Ensure we only put power plants on valid ground.
-	_, size := power.GetPowerOutputAndSize(plantType, plantSize)
-	region := commonMath.Region{
-		RegionType:  commonMath.SquareRegion,
-		Scale:       float32(size),
-		Orientation: 0,
-		Position:    n.lastBoardPos} // effective snapped pos
-
 	anyNearbyObjects := false // n.elementFinder.IntersectsWithElement(n.lastBoardPos, region.Scale)
 	var color mgl32.Vec3
 	if !anyNearbyObjects && n.terrainMap.ValidateGroundLocation(region) {
@@ -77,12 +72,31 @@ func (e *HypotheticalActions) computePowerLineHypotheticalRegion(n *Engine) {
 					Position:    n.lastBoardPos}}) // effective snapped pos
 	} else {
 		e.Reset()
+
+		// Priced the same as the powerline would be when placed
+		start := n.powerLineState.firstNode
+		end := n.lastBoardPos // effective snapped pos
+		cost := float32(0)
+		if tier, ok := power.GetLineTier(n.itemSelection); ok {
+			cost = power.GetLineCost(n.terrainMap.GetTerrainAlongLine(start, end), tier)
+		}
+
 		e.Lines = []HypotheticalLine{
 			HypotheticalLine{
 				Color: mgl32.Vec3{1.0, 0.0, 1.0},
-				Line: [2]mgl32.Vec2{
-					n.lastBoardPos, // effective snapped pos
-					n.powerLineState.firstNode}}}
+				Line:  [2]mgl32.Vec2{end, start},
+				Cost:  cost}}
+
+		// Show where pylons would be added
+		spans := power.GetPylonCount(end.Sub(start).Len()) + 1
+		for i := 1; i < spans; i++ {
+			e.Regions = append(e.Regions, HypotheticalRegion{
+				Color: mgl32.Vec3{1.0, 0.0, 1.0},
+				Region: commonMath.Region{
+					RegionType: commonMath.CircleRegion,
+					Scale:      config.Config.Power.PowerLineWidth,
+					Position:   start.Add(end.Sub(start).Mul(float32(i) / float32(spans)))}})
+		}
 	}
 }
 
@@ -99,12 +113,20 @@ func (e *HypotheticalActions) computeRoadLineHypotheticalRegion(n *Engine) {
 					Position:    n.lastBoardPos}}) // effective snapped pos
 	} else {
 		e.Reset()
+
+		// Priced the same as the road would be when placed
+		start := n.roadLineState.firstNode
+		end := n.lastBoardPos // effective snapped pos
+		cost := float32(0)
+		if class, ok := road.GetRoadClass(n.itemSelection); ok {
+			cost = road.GetRoadCost(start, end, class)
+		}
+
 		e.Lines = []HypotheticalLine{
 			HypotheticalLine{
 				Color: mgl32.Vec3{1.0, 1.0, 0.0},
-				Line: [2]mgl32.Vec2{
-					n.lastBoardPos, // effective snapped pos
-					n.roadLineState.firstNode}}}
+				Line:  [2]mgl32.Vec2{end, start},
+				Cost:  cost}}
 	}
 }
 
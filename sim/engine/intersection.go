@@ -0,0 +1,52 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sim/config"
+	"sim/core/lifecycle"
+	"sim/engine/finder"
+	"sim/engine/road"
+)
+
+// Selects the road terminus nearest the cursor, so its intersection control can be picked with the item sub-selection
+func (e *Engine) selectIntersection(ctx context.Context) {
+	results := make(chan []*finder.NodeWithDistance)
+	if !lifecycle.Send(ctx, e.elementFinder.KNearestSearchChannel, finder.NewKNNQuery(e.lastBoardPos, finder.RoadTerminus, 1, results)) {
+		return
+	}
+
+	nodes, ok := lifecycle.Receive(ctx, results)
+	if !ok {
+		return
+	}
+
+	if len(nodes) == 0 || nodes[0].Distance > config.Config.Draw.MinSnapNodeDistance {
+		e.selectedTerminus = -1
+		return
+	}
+
+	e.selectedTerminus = nodes[0].Id
+	fmt.Printf("Selected intersection %v. Pick %v (1), %v (2) or %v (3).\n", e.selectedTerminus, road.Uncontrolled, road.AllWayStop, road.Signals)
+}
+
+// Sets the intersection control of the selected road terminus to the item sub-selection
+func (e *Engine) setIntersectionControl() {
+	if e.selectedTerminus == -1 {
+		return
+	}
+
+	control, ok := road.GetIntersectionControl(e.itemSelection)
+	if !ok {
+		fmt.Printf("There is no intersection control %v.\n", int(e.itemSelection)+1)
+		return
+	}
+
+	if !e.roadGrid.SetIntersectionControl(e.selectedTerminus, control) {
+		fmt.Printf("Intersection %v no longer exists.\n", e.selectedTerminus)
+		e.selectedTerminus = -1
+		return
+	}
+
+	fmt.Printf("Intersection %v now has %v.\n", e.selectedTerminus, control)
+}
@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"common/commonmath"
+	"context"
+	"errors"
+	"fmt"
+	"sim/config"
+	"sim/core/lifecycle"
+	"sim/engine/finder"
+	"sim/engine/terrain"
+)
+
+var errStopping = errors.New("the simulation is stopping")
+
+// Item types that cannot overlap with any new element
+var solidItemTypes = []finder.ItemType{finder.PowerPlant, finder.Building}
+
+// Item types that cannot overlap with new plants and buildings
+var placeableBlockingItemTypes = []finder.ItemType{finder.PowerPlant, finder.Building, finder.RoadLine, finder.PowerLine}
+
+// Returns an error with the reason if the footprint overlaps any element of the blocking types, other than the ignored elements.
+func (e *Engine) validateNoOverlap(ctx context.Context, footprint finder.Footprint, blockingTypes []finder.ItemType, ignored ...finder.ElementKey) error {
+	results := make(chan []finder.ElementKey)
+	if !lifecycle.Send(ctx, e.elementFinder.IntersectionChannel, finder.NewIntersectionQuery(footprint, blockingTypes, results)) {
+		return errStopping
+	}
+
+	overlapping, ok := lifecycle.Receive(ctx, results)
+	if !ok {
+		return errStopping
+	}
+
+	for _, key := range overlapping {
+		isIgnored := false
+		for _, ignoredKey := range ignored {
+			isIgnored = isIgnored || key == ignoredKey
+		}
+
+		if !isIgnored {
+			return fmt.Errorf("it overlaps %v %v", key.Type, key.Id)
+		}
+	}
+
+	return nil
+}
+
+// Returns an error with the reason if any part of the region is not on buildable ground
+func (e *Engine) validateGround(ctx context.Context, region commonMath.Region) error {
+	query := terrain.GroundValidationQuery{
+		Region: region,
+		Result: make(chan bool)}
+
+	if !lifecycle.Send(ctx, e.terrainMap.GroundValidationChannel, query) {
+		return errStopping
+	}
+
+	isGroundValid, ok := lifecycle.Receive(ctx, query.Result)
+	if !ok {
+		return errStopping
+	} else if !isGroundValid {
+		return fmt.Errorf("it is not entirely on dry land")
+	}
+
+	return nil
+}
+
+// Returns an error with the reason if a plant or building cannot be placed in the region
+func (e *Engine) validateRegionPlacement(ctx context.Context, region commonMath.Region) error {
+	if err := e.validateNoOverlap(ctx, finder.NewRegionFootprint(region), placeableBlockingItemTypes); err != nil {
+		return err
+	}
+
+	return e.validateGround(ctx, region)
+}
+
+// Returns an error with the reason if the plant type's placement prerequisites are not met in the region
+func (e *Engine) validatePlantPrerequisites(ctx context.Context, plantType config.PowerPlant, region commonMath.Region) error {
+	if plantType.RequiredWaterDistance > 0 {
+		query := terrain.WaterProximityQuery{
+			Position: region.Position,
+			Distance: region.Scale/2 + plantType.RequiredWaterDistance,
+			Result:   make(chan bool)}
+
+		if !lifecycle.Send(ctx, e.terrainMap.WaterProximityChannel, query) {
+			return errStopping
+		}
+
+		hasWater, ok := lifecycle.Receive(ctx, query.Result)
+		if !ok {
+			return errStopping
+		} else if !hasWater {
+			return fmt.Errorf("it is not within %v of water for cooling", plantType.RequiredWaterDistance)
+		}
+	}
+
+	if plantType.RequiresGeothermal && !terrain.IsGeothermalDeposit(region.Position) {
+		return fmt.Errorf("it is not on a geothermal deposit")
+	}
+
+	return nil
+}
+
+// Returns an error with the reason if a power plant of the type cannot be placed in the region
+func (e *Engine) validatePlantPlacement(ctx context.Context, plantType string, region commonMath.Region) error {
+	if err := e.validateRegionPlacement(ctx, region); err != nil {
+		return err
+	}
+
+	return e.validatePlantPrerequisites(ctx, config.Config.Power.PowerPlantTypes[plantType], region)
+}
+
+// Returns an error with the reason if a line cannot be placed along the corridor.
+// Lines may cross each other, and may pass over the elements they connect to, which should be ignored.
+func (e *Engine) validateLinePlacement(ctx context.Context, corridor finder.Footprint, ignored ...finder.ElementKey) error {
+	return e.validateNoOverlap(ctx, corridor, solidItemTypes, ignored...)
+}
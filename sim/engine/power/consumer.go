@@ -1,5 +1,13 @@
 package power
 
+import "github.com/go-gl/mathgl/mgl32"
+
 type PowerConsumer struct {
-	power int // kW
+	location mgl32.Vec2
+	power    int // kW
+}
+
+// Gets positions on the map that can be used to snap to the consumer
+func (p *PowerConsumer) GetSnapNodes() []mgl32.Vec2 {
+	return []mgl32.Vec2{p.location}
 }
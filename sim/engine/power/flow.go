@@ -0,0 +1,165 @@
+package power
+
+import (
+	"sim/core/dto/powerdto"
+	"sim/core/graph"
+	"sim/engine/core/dto"
+)
+
+// Defines a directed arc of the flow network. Each arc is paired with its reverse arc, so flow can be undone.
+type flowArc struct {
+	to       int
+	reverse  int
+	residual int64
+}
+
+// Defines a flow network for a single connected component of the power grid, with a source feeding every plant
+// and a sink drained by every consumer
+type flowNetwork struct {
+	arcs    [][]flowArc
+	indices map[int64]int
+
+	source int
+	sink   int
+}
+
+func newFlowNetwork(nodeIds []int64) *flowNetwork {
+	network := flowNetwork{
+		arcs:    make([][]flowArc, len(nodeIds)+2),
+		indices: make(map[int64]int),
+		source:  len(nodeIds),
+		sink:    len(nodeIds) + 1}
+
+	for idx, nodeId := range nodeIds {
+		network.indices[nodeId] = idx
+	}
+
+	return &network
+}
+
+// Adds an arc pair, returning the index of the forward arc within the arcs leaving from
+func (n *flowNetwork) addArc(from, to int, capacity, reverseCapacity int64) int {
+	n.arcs[from] = append(n.arcs[from], flowArc{to: to, reverse: len(n.arcs[to]), residual: capacity})
+	n.arcs[to] = append(n.arcs[to], flowArc{to: from, reverse: len(n.arcs[from]) - 1, residual: reverseCapacity})
+	return len(n.arcs[from]) - 1
+}
+
+// Finds the shortest path from the source to the sink with residual capacity, returning the arc taken into each node
+func (n *flowNetwork) findAugmentingPath() ([][2]int, bool) {
+	parents := make([][2]int, len(n.arcs))
+	for idx := range parents {
+		parents[idx] = [2]int{-1, -1}
+	}
+
+	pending := []int{n.source}
+	parents[n.source] = [2]int{n.source, -1}
+	for len(pending) > 0 && parents[n.sink][0] == -1 {
+		node := pending[0]
+		pending = pending[1:]
+
+		for arcIdx, arc := range n.arcs[node] {
+			if arc.residual > 0 && parents[arc.to][0] == -1 {
+				parents[arc.to] = [2]int{node, arcIdx}
+				pending = append(pending, arc.to)
+			}
+		}
+	}
+
+	return parents, parents[n.sink][0] != -1
+}
+
+// Pushes as much flow from the source to the sink as possible, using Edmonds-Karp
+func (n *flowNetwork) maximizeFlow() {
+	for {
+		parents, found := n.findAugmentingPath()
+		if !found {
+			return
+		}
+
+		bottleneck := int64(-1)
+		for node := n.sink; node != n.source; node = parents[node][0] {
+			residual := n.arcs[parents[node][0]][parents[node][1]].residual
+			if bottleneck == -1 || residual < bottleneck {
+				bottleneck = residual
+			}
+		}
+
+		for node := n.sink; node != n.source; node = parents[node][0] {
+			arc := &n.arcs[parents[node][0]][parents[node][1]]
+			arc.residual -= bottleneck
+			n.arcs[arc.to][arc.reverse].residual += bottleneck
+		}
+	}
+}
+
+// Solves the power flow of a connected component of the power grid, adding the results to the flow.
+// Plants supply up to their available output and consumers draw up to their demand, limited by the capacity of the lines between them.
+func solveComponentFlow(grid *graph.Graph, nodeIds []int64, time dto.Time, flow *powerdto.PowerFlow) {
+	network := newFlowNetwork(nodeIds)
+
+	plantArcs := make(map[int64]int)
+	plantTypes := make(map[int64]string)
+	consumerArcs := make(map[int64]int)
+	lineArcs := make(map[int64][2]int)
+	lineCapacities := make(map[int64]int64)
+	for _, nodeId := range nodeIds {
+		node := network.indices[nodeId]
+		switch data := grid.GetNode(nodeId).(type) {
+		case *PowerPlant:
+			available := int64(data.getAvailableOutput(time))
+			plantArcs[nodeId] = network.addArc(network.source, node, available, 0)
+			plantTypes[nodeId] = data.plantType
+			flow.Supply += available
+		case *PowerConsumer:
+			consumerArcs[nodeId] = network.addArc(node, network.sink, int64(data.power), 0)
+			flow.Demand += int64(data.power)
+		}
+
+		// Lines carry power in either direction, so each is added once, from its lower node
+		for _, neighbor := range grid.GetNeighbors(nodeId) {
+			line, ok := neighbor.ConnectionData.(*PowerLine)
+			neighborNode, inComponent := network.indices[neighbor.NodeId]
+			if ok && inComponent && nodeId < neighbor.NodeId {
+				lineArcs[neighbor.ConnectionId] = [2]int{node, network.addArc(node, neighborNode, line.capacity, line.capacity)}
+				lineCapacities[neighbor.ConnectionId] = line.capacity
+			}
+		}
+	}
+
+	network.maximizeFlow()
+
+	for nodeId, arcIdx := range plantArcs {
+		arc := network.arcs[network.source][arcIdx]
+		output := network.arcs[arc.to][arc.reverse].residual
+
+		flow.Plants[nodeId] = powerdto.PlantFlow{Id: nodeId, Type: plantTypes[nodeId], Available: output + arc.residual, Output: output}
+	}
+
+	for nodeId, arcIdx := range consumerArcs {
+		arc := network.arcs[network.indices[nodeId]][arcIdx]
+		demand := network.arcs[network.sink][arc.reverse].residual + arc.residual
+		served := network.arcs[network.sink][arc.reverse].residual
+
+		flow.Consumers[nodeId] = powerdto.ConsumerFlow{Id: nodeId, Demand: demand, Served: served}
+		flow.Served += served
+	}
+
+	for lineId, arcLocation := range lineArcs {
+		load := lineCapacities[lineId] - network.arcs[arcLocation[0]][arcLocation[1]].residual
+		if load < 0 {
+			load = -load
+		}
+
+		flow.Lines[lineId] = powerdto.LineFlow{Id: lineId, Load: load, Capacity: lineCapacities[lineId]}
+	}
+}
+
+// Solves the power flow across every connected component of the power grid at the given time
+func solvePowerFlow(grid *graph.Graph, time dto.Time) powerdto.PowerFlow {
+	flow := powerdto.NewPowerFlow()
+	for _, component := range grid.GetConnectedComponents() {
+		solveComponentFlow(grid, component, time, &flow)
+	}
+
+	return flow
+}
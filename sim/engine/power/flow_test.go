@@ -0,0 +1,70 @@
+package power
+
+import (
+	"sim/core/graph"
+	"sim/core/lifecycle/lifecycletest"
+	"sim/engine/core/dto"
+	"testing"
+)
+
+func connect(grid *graph.Graph, first, second int64, capacity int64) int64 {
+	return grid.AddConnection(first, second, &PowerLine{capacity: capacity}).Id
+}
+
+func TestSolvePowerFlowLimitedBySupply(t *testing.T) {
+	grid := graph.NewGraph(lifecycletest.NewSupervisor(t))
+	plant := grid.AddNode(&PowerPlant{output: 100})
+	terminus := grid.AddNode(&PowerTerminus{})
+	first := grid.AddNode(&PowerConsumer{power: 60})
+	second := grid.AddNode(&PowerConsumer{power: 60})
+
+	connect(grid, plant, terminus, 1000)
+	connect(grid, terminus, first, 1000)
+	connect(grid, terminus, second, 1000)
+
+	flow := solvePowerFlow(grid, dto.NewTime())
+	if flow.Supply != 100 || flow.Demand != 120 || flow.Served != 100 {
+		t.Errorf("Expected 100 of 120 kW served from 100 kW, found %v of %v kW served from %v kW", flow.Served, flow.Demand, flow.Supply)
+	}
+
+	if unserved := flow.Consumers[first].Unserved() + flow.Consumers[second].Unserved(); unserved != 20 {
+		t.Errorf("Expected 20 kW unserved, found %v kW", unserved)
+	}
+}
+
+func TestSolvePowerFlowLimitedByLines(t *testing.T) {
+	grid := graph.NewGraph(lifecycletest.NewSupervisor(t))
+	plant := grid.AddNode(&PowerPlant{output: 1000})
+	near := grid.AddNode(&PowerConsumer{power: 50})
+	far := grid.AddNode(&PowerConsumer{power: 50})
+
+	// Power reaches the far consumer through the near one, over a smaller line
+	nearLine := connect(grid, plant, near, 100)
+	farLine := connect(grid, far, near, 30)
+
+	flow := solvePowerFlow(grid, dto.NewTime())
+	if flow.Consumers[near].Served != 50 || flow.Consumers[far].Served != 30 {
+		t.Errorf("Expected 50 and 30 kW served, found %v and %v kW", flow.Consumers[near].Served, flow.Consumers[far].Served)
+	}
+
+	if flow.Lines[nearLine].Load != 80 || flow.Lines[farLine].Load != 30 || flow.Lines[farLine].Capacity != 30 {
+		t.Errorf("Expected line loads of 80 and 30 kW, found %v and %v kW", flow.Lines[nearLine].Load, flow.Lines[farLine].Load)
+	}
+}
+
+func TestSolvePowerFlowComponents(t *testing.T) {
+	grid := graph.NewGraph(lifecycletest.NewSupervisor(t))
+	plant := grid.AddNode(&PowerPlant{output: 100})
+	connected := grid.AddNode(&PowerConsumer{power: 40})
+	isolated := grid.AddNode(&PowerConsumer{power: 40})
+	connect(grid, plant, connected, 1000)
+
+	flow := solvePowerFlow(grid, dto.NewTime())
+	if flow.Consumers[connected].Served != 40 {
+		t.Errorf("The connected consumer should be served, found %v kW", flow.Consumers[connected].Served)
+	}
+
+	if flow.Consumers[isolated].Served != 0 || flow.Consumers[isolated].Demand != 40 {
+		t.Errorf("The isolated consumer should not be served, found %v of %v kW", flow.Consumers[isolated].Served, flow.Consumers[isolated].Demand)
+	}
+}
@@ -1,28 +1,91 @@
 package power
 
 import (
+	"context"
 	"fmt"
+	"sim/config"
+	"sim/core/broadcast"
 	"sim/core/dto/geometry"
+	"sim/core/dto/powerdto"
 	"sim/core/graph"
+	"sim/core/lifecycle"
 	"sim/core/mailroom"
+	"sim/engine/core"
+	"sim/engine/core/dto"
 	"sim/engine/finder"
 
 	"github.com/go-gl/mathgl/mgl32"
 )
 
+// Powerlines are not split this close to their ends, as the split would be at the existing node
+const minSplitDistance float32 = 0.01
+
 type PowerGrid struct {
-	finder *finder.ElementFinder
-	grid   *graph.Graph
+	supervisor *lifecycle.Supervisor
+	finder     *finder.ElementFinder
+	grid       *graph.Graph
+
+	flows    *broadcast.Broadcaster[powerdto.PowerFlow]
+	lastFlow powerdto.PowerFlow
+
+	// Fuel burned by plants since it was last bought
+	fuelBurned  map[string]float32
+	lastSimTime float32
+	lastDay     int
+
+	TimerUpdateChannel  chan dto.Time
+	PowerFlowRegChannel chan chan powerdto.PowerFlow
+	ReportQueryChannel  chan powerdto.PowerReportQuery
 }
 
-func NewPowerGrid(finder *finder.ElementFinder) *PowerGrid {
+func NewPowerGrid(supervisor *lifecycle.Supervisor, elementFinder *finder.ElementFinder) *PowerGrid {
 	grid := PowerGrid{
-		finder: finder,
-		grid:   graph.NewGraph()}
+		supervisor:          supervisor,
+		finder:              elementFinder,
+		grid:                graph.NewGraph(supervisor),
+		flows:               broadcast.NewBroadcaster[powerdto.PowerFlow]("Power flows", broadcast.CoalesceLatest, broadcast.DefaultTimeout),
+		lastFlow:            powerdto.NewPowerFlow(),
+		fuelBurned:          make(map[string]float32),
+		lastSimTime:         0,
+		lastDay:             0,
+		TimerUpdateChannel:  make(chan dto.Time, 3),
+		PowerFlowRegChannel: make(chan chan powerdto.PowerFlow),
+		ReportQueryChannel:  make(chan powerdto.PowerReportQuery, 3)}
+
+	elementFinder.WatchGraph(grid.grid, []finder.ItemType{finder.PowerTerminus, finder.PowerPlant}, finder.PowerLine)
+
+	mailroom.CoreTimerRegChannel.Use("power.PowerGrid")
+	mailroom.NewPowerLineChannel.Use("power.PowerGrid")
+	mailroom.NewPowerPlantChannel.Use("power.PowerGrid")
+	mailroom.DeletePowerLineChannel.Use("power.PowerGrid")
+	mailroom.DeletePowerPlantChannel.Use("power.PowerGrid")
+
+	supervisor.Go("power.PowerGrid", grid.run)
+	mailroom.CoreTimerRegChannel.SendContext(supervisor.Context(), grid.TimerUpdateChannel)
 	return &grid
 }
 
-func (p *PowerGrid) Add(pos mgl32.Vec2, plantType string, plantSize PowerPlantSize) *PowerPlant {
+// Solves the power flow every tick, publishing the served power of each consumer and the load on each line
+func (p *PowerGrid) run(ctx context.Context) {
+	for {
+		select {
+		case reg := <-p.PowerFlowRegChannel:
+			p.flows.Register(reg)
+		case time := <-p.TimerUpdateChannel:
+			flow := solvePowerFlow(p.grid, time)
+			p.burnFuel(ctx, flow, time)
+			p.flows.Send(flow)
+			p.lastFlow = flow
+		case query := <-p.ReportQueryChannel:
+			query.Result <- buildPowerReport(p.grid, p.lastFlow)
+			close(query.Result)
+		case _ = <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (p *PowerGrid) Add(pos mgl32.Vec2, plantType string, plantSize PowerPlantSize, orientation float32) *PowerPlant {
 	output, size := GetPowerOutputAndSize(plantType, plantSize)
 
 	plant := PowerPlant{
@@ -30,50 +93,304 @@ func (p *PowerGrid) Add(pos mgl32.Vec2, plantType string, plantSize PowerPlantSi
 		plantType:   plantType,
 		namedSize:   plantSize,
 		size:        float32(size),
-		orientation: 0, // TODO: Rotation
+		orientation: orientation,
 		output:      output}
 
 	gridId := p.grid.AddNode(&plant)
 	fmt.Printf("Added power plant '%v'.\n", plant)
 
-	p.finder.AddElementChannel <- finder.NewElement(gridId, finder.PowerTerminus, []mgl32.Vec2{pos})
-	mailroom.NewPowerPlantChannel <- geometry.NewIdRegion(gridId, *plant.GetRegion())
+	element := finder.NewElementWithFootprint(gridId, finder.PowerPlant, plant.GetSnapNodes(), plant.GetFootprint())
+	element.Edges = plant.GetSnapEdges()
+	lifecycle.Send(p.supervisor.Context(), p.finder.AddElementChannel, element)
+	mailroom.NewPowerPlantChannel.SendContext(p.supervisor.Context(), geometry.NewIdRegion(gridId, *plant.GetRegion()))
 
 	return &plant
 }
 
+// Gets a report on each network of the power grid as of the last tick, blocking until the grid responds.
+// Returns false if the grid stops first.
+func (p *PowerGrid) GetReport(ctx context.Context) (powerdto.PowerReport, bool) {
+	query := powerdto.NewPowerReportQuery()
+	if !lifecycle.Send(ctx, p.ReportQueryChannel, query) {
+		return powerdto.PowerReport{}, false
+	}
+
+	return lifecycle.Receive(ctx, query.Result)
+}
+
+// Tracks the fuel plants burn for their output, buying it at the end of each day
+func (p *PowerGrid) burnFuel(ctx context.Context, flow powerdto.PowerFlow, time dto.Time) {
+	elapsedDays := (time.SimTime - p.lastSimTime) / config.Config.Sim.SecondsPerDay
+	p.lastSimTime = time.SimTime
+
+	for _, plantFlow := range flow.Plants {
+		plantType := config.Config.Power.PowerPlantTypes[plantFlow.Type]
+		if plantType.Fuel != "" {
+			p.fuelBurned[plantType.Fuel] += float32(plantFlow.Output) * plantType.FuelPerDay * elapsedDays
+		}
+	}
+
+	if time.Days <= p.lastDay {
+		return
+	}
+
+	p.lastDay = time.Days
+	for _, resource := range config.Config.Resources {
+		if burned := p.fuelBurned[resource.Name]; burned > 0 {
+			lifecycle.Send(ctx, core.CoreFinances.TransactionChannel, dto.NewTransaction(fmt.Sprintf("day of %v fuel", resource.Name), burned*resource.Price))
+		}
+	}
+
+	p.fuelBurned = make(map[string]float32)
+}
+
+// Adds a consumer drawing the given power, which powerlines can connect to. Returns the consumer node ID.
+func (p *PowerGrid) AddConsumer(pos mgl32.Vec2, power int) int64 {
+	nodeId := p.grid.AddNode(&PowerConsumer{location: pos, power: power})
+	lifecycle.Send(p.supervisor.Context(), p.finder.AddElementChannel, finder.NewElement(nodeId, finder.PowerTerminus, []mgl32.Vec2{pos}))
+	return nodeId
+}
+
+// Connects a consumer to the nearest terminus or powerline within range, splitting the line if needed.
+// Returns false if nothing is in range, leaving the consumer unconnected until a powerline is drawn to it.
+func (p *PowerGrid) ConnectConsumer(consumerId int64) bool {
+	consumer, ok := p.grid.GetNode(consumerId).(*PowerConsumer)
+	if !ok {
+		return false
+	}
+
+	ctx := p.supervisor.Context()
+	maxDistance := config.Config.Power.ConsumerConnectionDistance
+
+	nodeResults := make(chan []*finder.NodeWithDistance)
+	if !lifecycle.Send(ctx, p.finder.RadiusSearchChannel, finder.NewRadiusQuery(consumer.location, finder.PowerTerminus, maxDistance, nodeResults)) {
+		return false
+	}
+
+	nodes, ok := lifecycle.Receive(ctx, nodeResults)
+	if !ok {
+		return false
+	}
+
+	edgeResults := make(chan []*finder.EdgeWithDistance)
+	if !lifecycle.Send(ctx, p.finder.EdgeSearchChannel, finder.NewNearestEdgesQuery(consumer.location, []finder.ItemType{finder.PowerLine}, maxDistance, edgeResults)) {
+		return false
+	}
+
+	edges, ok := lifecycle.Receive(ctx, edgeResults)
+	if !ok {
+		return false
+	}
+
+	// Consumers are also termini, so the consumer itself is skipped.
+	var nearestNode *finder.NodeWithDistance
+	for _, node := range nodes {
+		if node.Id != consumerId {
+			nearestNode = node
+			break
+		}
+	}
+
+	// Service drops carry the consumer's demand
+	capacity := int64(consumer.power)
+	if nearestNode != nil && (len(edges) == 0 || nearestNode.Distance <= edges[0].Distance) {
+		_, lineId, _ := p.addLine(&PowerLine{capacity: capacity, isServiceDrop: true, ends: [2]mgl32.Vec2{consumer.location, nearestNode.Pos}}, consumerId, nearestNode.Id)
+		return lineId != -1
+	} else if len(edges) != 0 {
+		splitNode := p.SplitLine(edges[0].Id, edges[0].Pos)
+		if splitNode == -1 {
+			return false
+		}
+
+		_, lineId, _ := p.addLine(&PowerLine{capacity: capacity, isServiceDrop: true, ends: [2]mgl32.Vec2{consumer.location, edges[0].Pos}}, consumerId, splitNode)
+		return lineId != -1
+	}
+
+	return false
+}
+
+func (p *PowerGrid) addTerminus(pos mgl32.Vec2) int64 {
+	nodeId := p.grid.AddNode(&PowerTerminus{location: pos})
+	lifecycle.Send(p.supervisor.Context(), p.finder.AddElementChannel, finder.NewElement(nodeId, finder.PowerTerminus, []mgl32.Vec2{pos}))
+	return nodeId
+}
+
+func (p *PowerGrid) addLineElement(lineId int64, start, end mgl32.Vec2) {
+	lifecycle.Send(p.supervisor.Context(), p.finder.AddElementChannel, finder.NewLineElement(lineId, finder.PowerLine, [2]mgl32.Vec2{start, end}, GetLineFootprint(start, end)))
+	mailroom.NewPowerLineChannel.SendContext(p.supervisor.Context(), geometry.NewIdLine(lineId, [2]mgl32.Vec2{start, end}))
+}
+
 // Adds a powerline. For both startNode and endNode, if -1 generates a new grid node, else uses an existing node.
 // Returns the start ID, line ID, and end ID, in that order.
 func (p *PowerGrid) AddLine(start, end mgl32.Vec2, capacity int64, startNode, endNode int64) (int64, int64, int64) {
-	line := PowerLine{capacity: capacity}
+	return p.addLine(&PowerLine{capacity: capacity, ends: [2]mgl32.Vec2{start, end}}, startNode, endNode)
+}
+
+// Adds the powerline between its ends, with the same node handling as AddLine
+func (p *PowerGrid) addLine(line *PowerLine, startNode, endNode int64) (int64, int64, int64) {
+	start := line.ends[0]
+	end := line.ends[1]
 
 	if startNode == endNode && startNode != -1 {
 		fmt.Printf("Powerlines must be between nodes and cannot (for a single line) loop\n")
 		return -1, -1, -1
 	} else if startNode != -1 && endNode != -1 {
 		// This might be a duplicate line.
-		connectionStatus := p.grid.AddConnection(startNode, endNode, &line)
+		connectionStatus := p.grid.AddConnection(startNode, endNode, line)
 		if connectionStatus.Status == graph.Exists {
 			fmt.Printf("There already is a line from %v to %v.\n", startNode, endNode)
 			return -1, -1, -1
+		} else if connectionStatus.Status == graph.NodesMissing {
+			fmt.Printf("Cannot add a line from %v to %v, as one of them no longer exists.\n", startNode, endNode)
+			return -1, -1, -1
 		} else {
-			mailroom.NewPowerLineChannel <- geometry.NewIdLine(connectionStatus.Id, [2]mgl32.Vec2{start, end})
+			p.addLineElement(connectionStatus.Id, start, end)
 			return startNode, connectionStatus.Id, endNode
 		}
 	}
 
 	if startNode == -1 {
-		startNode = p.grid.AddNode(&PowerTerminus{location: start})
-		p.finder.AddElementChannel <- finder.NewElement(startNode, finder.PowerTerminus, []mgl32.Vec2{start})
+		startNode = p.addTerminus(start)
 	}
 
 	if endNode == -1 {
-		endNode = p.grid.AddNode(&PowerTerminus{location: end})
-		p.finder.AddElementChannel <- finder.NewElement(endNode, finder.PowerTerminus, []mgl32.Vec2{end})
+		endNode = p.addTerminus(end)
 	}
 
-	connectionStatus := p.grid.AddConnection(startNode, endNode, &line)
-	mailroom.NewPowerLineChannel <- geometry.NewIdLine(connectionStatus.Id, [2]mgl32.Vec2{start, end})
+	connectionStatus := p.grid.AddConnection(startNode, endNode, line)
+	p.addLineElement(connectionStatus.Id, start, end)
 
 	return startNode, connectionStatus.Id, endNode
 }
+
+// Adds a powerline with pylons evenly spaced along it, so no span is longer than the maximum.
+// Returns the start node ID, the IDs of the lines between pylons in order, and the end node ID, in that order.
+func (p *PowerGrid) AddLineWithPylons(start, end mgl32.Vec2, capacity int64, startNode, endNode int64) (int64, []int64, int64) {
+	lineIds := make([]int64, 0)
+	spans := GetPylonCount(end.Sub(start).Len()) + 1
+
+	lineStartNode := startNode
+	segmentStart := start
+	for i := 1; i < spans; i++ {
+		pylon := start.Add(end.Sub(start).Mul(float32(i) / float32(spans)))
+		segmentStartNode, lineId, pylonNode := p.AddLine(segmentStart, pylon, capacity, startNode, -1)
+		if lineId == -1 {
+			return lineStartNode, lineIds, -1
+		} else if i == 1 {
+			lineStartNode = segmentStartNode
+		}
+
+		lineIds = append(lineIds, lineId)
+		startNode = pylonNode
+		segmentStart = pylon
+	}
+
+	segmentStartNode, lineId, endNode := p.AddLine(segmentStart, end, capacity, startNode, endNode)
+	if lineId == -1 {
+		return lineStartNode, lineIds, -1
+	} else if spans == 1 {
+		lineStartNode = segmentStartNode
+	}
+
+	lineIds = append(lineIds, lineId)
+	return lineStartNode, lineIds, endNode
+}
+
+// Splits a powerline in two at a new terminus, returning the terminus ID, or -1 if the line no longer exists.
+// Splitting at the ends of the line returns the node already there.
+func (p *PowerGrid) SplitLine(lineId int64, pos mgl32.Vec2) int64 {
+	firstNode, secondNode, ok := p.grid.GetConnectionNodes(lineId)
+	if !ok {
+		fmt.Printf("Cannot split powerline %v, as it no longer exists.\n", lineId)
+		return -1
+	}
+
+	line := p.grid.GetConnection(lineId).(*PowerLine)
+	direction := line.ends[1].Sub(line.ends[0])
+	length := direction.Len()
+	splitFraction := pos.Sub(line.ends[0]).Dot(direction) / direction.Dot(direction)
+	if splitFraction*length < minSplitDistance {
+		return firstNode
+	} else if (1-splitFraction)*length < minSplitDistance {
+		return secondNode
+	}
+	p.grid.DeleteConnection(firstNode, secondNode)
+	mailroom.DeletePowerLineChannel.SendContext(p.supervisor.Context(), lineId)
+
+	splitNode := p.addTerminus(pos)
+	p.addLine(&PowerLine{capacity: line.capacity, isServiceDrop: line.isServiceDrop, ends: [2]mgl32.Vec2{line.ends[0], pos}}, firstNode, splitNode)
+	p.addLine(&PowerLine{capacity: line.capacity, isServiceDrop: line.isServiceDrop, ends: [2]mgl32.Vec2{pos, line.ends[1]}}, splitNode, secondNode)
+	return splitNode
+}
+
+// Removes a powerline, along with any termini it leaves unconnected.
+// Returns the removed line, or false if the line no longer exists.
+func (p *PowerGrid) DeleteLine(lineId int64) (*PowerLine, bool) {
+	firstNode, secondNode, ok := p.grid.GetConnectionNodes(lineId)
+	if !ok {
+		return nil, false
+	}
+
+	line := p.grid.GetConnection(lineId).(*PowerLine)
+	p.grid.DeleteConnection(firstNode, secondNode)
+	mailroom.DeletePowerLineChannel.SendContext(p.supervisor.Context(), lineId)
+
+	p.deleteIfUnconnected(firstNode)
+	p.deleteIfUnconnected(secondNode)
+	return line, true
+}
+
+// Removes a terminus that no longer connects anything. Plants and consumers stand on their own, so they are kept.
+func (p *PowerGrid) deleteIfUnconnected(nodeId int64) {
+	if _, ok := p.grid.GetNode(nodeId).(*PowerTerminus); ok && len(p.grid.GetNeighbors(nodeId)) == 0 {
+		p.grid.DeleteNode(nodeId)
+	}
+}
+
+// Removes a node and the powerlines connected to it, returning the removed powerlines
+func (p *PowerGrid) deleteNode(nodeId int64) []*PowerLine {
+	removedLines := make([]*PowerLine, 0)
+	for _, neighbor := range p.grid.GetNeighbors(nodeId) {
+		if line, ok := p.DeleteLine(neighbor.ConnectionId); ok {
+			removedLines = append(removedLines, line)
+		}
+	}
+
+	// Termini are removed with their last line
+	p.grid.DeleteNode(nodeId)
+	return removedLines
+}
+
+// Removes a power plant and the powerlines connected to it.
+// Returns the plant type and the removed powerlines, or false if the plant no longer exists.
+func (p *PowerGrid) DeletePlant(plantId int64) (string, []*PowerLine, bool) {
+	plant, ok := p.grid.GetNode(plantId).(*PowerPlant)
+	if !ok {
+		return "", nil, false
+	}
+
+	removedLines := p.deleteNode(plantId)
+	mailroom.DeletePowerPlantChannel.SendContext(p.supervisor.Context(), plantId)
+	fmt.Printf("Removed power plant '%v'.\n", *plant)
+	return plant.plantType, removedLines, true
+}
+
+// Removes a terminus and the powerlines connected to it.
+// Returns the removed powerlines, or false if the terminus no longer exists.
+func (p *PowerGrid) DeleteTerminus(terminusId int64) ([]*PowerLine, bool) {
+	if _, ok := p.grid.GetNode(terminusId).(*PowerTerminus); !ok {
+		return nil, false
+	}
+
+	return p.deleteNode(terminusId), true
+}
+
+// Removes a consumer and the powerlines connected to it, such as its service drop.
+// Returns the removed powerlines, or false if the consumer no longer exists.
+func (p *PowerGrid) DeleteConsumer(consumerId int64) ([]*PowerLine, bool) {
+	if _, ok := p.grid.GetNode(consumerId).(*PowerConsumer); !ok {
+		return nil, false
+	}
+
+	return p.deleteNode(consumerId), true
+}
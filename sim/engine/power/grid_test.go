@@ -0,0 +1,26 @@
+package power
+
+import (
+	"sim/core/graph"
+	"sim/core/lifecycle/lifecycletest"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestSplitLineAtEnd(t *testing.T) {
+	supervisor := lifecycletest.NewSupervisor(t)
+	grid := PowerGrid{supervisor: supervisor, grid: graph.NewGraph(supervisor)}
+
+	firstNode := grid.grid.AddNode(&PowerTerminus{location: mgl32.Vec2{0, 0}})
+	secondNode := grid.grid.AddNode(&PowerTerminus{location: mgl32.Vec2{100, 0}})
+	lineId := grid.grid.AddConnection(firstNode, secondNode, &PowerLine{capacity: 100, ends: [2]mgl32.Vec2{{0, 0}, {100, 0}}}).Id
+
+	if grid.SplitLine(lineId, mgl32.Vec2{0, 0}) != firstNode || grid.SplitLine(lineId, mgl32.Vec2{100, 0.005}) != secondNode {
+		t.Error("Splitting at the ends of a powerline should return the existing nodes")
+	}
+
+	if grid.grid.GetConnection(lineId) == nil || len(grid.grid.GetNodeIds()) != 2 {
+		t.Error("Splitting at the ends of a powerline should leave it intact, without adding termini")
+	}
+}
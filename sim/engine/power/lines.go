@@ -1,11 +1,96 @@
 package power
 
-import "github.com/go-gl/mathgl/mgl32"
+import (
+	"math"
+	"sim/config"
+	"sim/core/dto/editorengdto"
+	"sim/core/dto/terraindto"
+	"sim/engine/finder"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
 
 type PowerTerminus struct {
 	location mgl32.Vec2
 }
 
+// Gets positions on the map that can be used to snap to the terminus
+func (p *PowerTerminus) GetSnapNodes() []mgl32.Vec2 {
+	return []mgl32.Vec2{p.location}
+}
+
 type PowerLine struct {
 	capacity int64
+
+	// Service drops are added automatically to connect consumers, rather than bought
+	isServiceDrop bool
+
+	// The line ends, in the order of the nodes of the graph connection
+	ends [2]mgl32.Vec2
+}
+
+func (p *PowerLine) GetCapacity() int64 {
+	return p.capacity
+}
+
+func (p *PowerLine) GetEnds() [2]mgl32.Vec2 {
+	return p.ends
+}
+
+func (p *PowerLine) IsServiceDrop() bool {
+	return p.isServiceDrop
+}
+
+// Gets the corridor of the map a powerline would cover
+func GetLineFootprint(start, end mgl32.Vec2) finder.Footprint {
+	return finder.NewCorridorFootprint(start, end, config.Config.Power.PowerLineWidth)
+}
+
+// Gets the powerline tier for the item sub-selection, returning false if there is none
+func GetLineTier(itemSelection editorengdto.ItemSubSelection) (config.PowerLineTier, bool) {
+	if int(itemSelection) >= len(config.Config.Power.PowerLineTiers) {
+		return config.PowerLineTier{}, false
+	}
+
+	return config.Config.Power.PowerLineTiers[itemSelection], true
+}
+
+// Gets the smallest powerline tier that carries the capacity, or the largest tier if none do
+func GetLineTierForCapacity(capacity int64) config.PowerLineTier {
+	tiers := config.Config.Power.PowerLineTiers
+	for _, tier := range tiers {
+		if tier.Capacity >= capacity {
+			return tier
+		}
+	}
+
+	return tiers[len(tiers)-1]
+}
+
+// Gets the number of pylons needed along a line so no span is longer than the maximum
+func GetPylonCount(length float32) int {
+	maxSpan := config.Config.Power.MaxPowerLineSpan
+	if maxSpan <= 0 || length <= maxSpan {
+		return 0
+	}
+
+	return int(math.Ceil(float64(length/maxSpan))) - 1
+}
+
+// Gets the cost of a powerline of the tier, given the length of it crossing each terrain type, including the pylons it needs
+func GetLineCost(terrainLengths map[terraindto.TerrainType]float32, tier config.PowerLineTier) float32 {
+	length := float32(0)
+	weightedLength := float32(0)
+	for terrainType, terrainLength := range terrainLengths {
+		factor, ok := config.Config.Power.PowerLineTerrainCosts[terrainType.String()]
+		if !ok {
+			factor = 1
+		}
+
+		length += terrainLength
+		weightedLength += terrainLength * factor
+	}
+
+	lineCost := weightedLength * config.Config.Power.PowerLineCost * tier.CostFactor
+	return lineCost + float32(GetPylonCount(length))*config.Config.Power.PylonCost
 }
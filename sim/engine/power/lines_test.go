@@ -0,0 +1,66 @@
+package power
+
+import (
+	"sim/config"
+	"sim/config/configtest"
+	"sim/core/dto/terraindto"
+	"testing"
+)
+
+func withLineCosts(t *testing.T) {
+	power := config.Config.Power
+	power.PowerLineCost = 10
+	power.PowerLineTerrainCosts = map[string]float32{"water": 5, "rocks": 2}
+	power.MaxPowerLineSpan = 100
+	power.PylonCost = 50
+	power.PowerLineTiers = []config.PowerLineTier{
+		{Name: "low", Capacity: 100, CostFactor: 1},
+		{Name: "high", Capacity: 1000, CostFactor: 3}}
+	configtest.Override(t, &config.Config.Power, power)
+}
+
+func TestGetPylonCount(t *testing.T) {
+	withLineCosts(t)
+
+	for length, expected := range map[float32]int{50: 0, 100: 0, 101: 1, 200: 1, 250: 2} {
+		if pylons := GetPylonCount(length); pylons != expected {
+			t.Errorf("A line of length %v should need %v pylons, found %v", length, expected, pylons)
+		}
+	}
+}
+
+func TestGetLineCost(t *testing.T) {
+	withLineCosts(t)
+
+	// Unlisted terrain types cost the base amount
+	lowTier := config.Config.Power.PowerLineTiers[0]
+	if cost := GetLineCost(map[terraindto.TerrainType]float32{terraindto.Grass: 50}, lowTier); cost != 500 {
+		t.Errorf("Expected a cost of 500 over grass, found %v", cost)
+	}
+
+	if cost := GetLineCost(map[terraindto.TerrainType]float32{terraindto.Grass: 40, terraindto.Water: 10, terraindto.Rocks: 10}, lowTier); cost != 1100 {
+		t.Errorf("Expected water and rocks to cost more, for a cost of 1100, found %v", cost)
+	}
+
+	// 150 units needs a pylon
+	highTier := config.Config.Power.PowerLineTiers[1]
+	if cost := GetLineCost(map[terraindto.TerrainType]float32{terraindto.Grass: 150}, highTier); cost != 4550 {
+		t.Errorf("Expected the higher tier and a pylon to cost 4550, found %v", cost)
+	}
+}
+
+func TestGetLineTierForCapacity(t *testing.T) {
+	withLineCosts(t)
+
+	if tier := GetLineTierForCapacity(100); tier.Name != "low" {
+		t.Errorf("Expected the low tier to carry 100 kW, found %v", tier.Name)
+	}
+
+	if tier := GetLineTierForCapacity(500); tier.Name != "high" {
+		t.Errorf("Expected the high tier to carry 500 kW, found %v", tier.Name)
+	}
+
+	if tier := GetLineTierForCapacity(5000); tier.Name != "high" {
+		t.Errorf("Expected the largest tier for capacities beyond all tiers, found %v", tier.Name)
+	}
+}
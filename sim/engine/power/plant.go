@@ -2,6 +2,7 @@ package power
 
 import (
 	"common/commonmath"
+	"sim/engine/finder"
 
 	"github.com/go-gl/mathgl/mgl32"
 )
@@ -15,8 +16,7 @@ type PowerPlant struct {
 	size        float32 // All plants are assumed square, for now. (TODO)
 	orientation float32
 
-	output int // kW
-	// TODO: Add capacity factor, things impacted by, required resources, etc.
+	output int // kW, at full capacity
 }
 
 // Implement Element
@@ -29,6 +29,11 @@ func (p *PowerPlant) GetRegion() *commonMath.Region {
 		Orientation: p.orientation}
 }
 
+// Gets the area of the map covered by the plant
+func (p *PowerPlant) GetFootprint() finder.Footprint {
+	return finder.NewRegionFootprint(*p.GetRegion())
+}
+
 // Gets positions on the map that can be used to snap to points of the element.
 func (p *PowerPlant) GetSnapNodes() []mgl32.Vec2 {
 	// TODO: This should be plant-type specific so it matches with the 2D model or image.
@@ -39,7 +44,18 @@ func (p *PowerPlant) GetSnapNodes() []mgl32.Vec2 {
 
 // Gets lines on the map that can be used to snap to *edges* of the element
 func (p *PowerPlant) GetSnapEdges() [][2]mgl32.Vec2 {
-	return make([][2]mgl32.Vec2, 0)
+	rotation := mgl32.Rotate2D(p.orientation)
+	corners := make([]mgl32.Vec2, 4)
+	for idx, corner := range []mgl32.Vec2{{-1, -1}, {1, -1}, {1, 1}, {-1, 1}} {
+		corners[idx] = p.location.Add(rotation.Mul2x1(corner.Mul(p.size / 2)))
+	}
+
+	edges := make([][2]mgl32.Vec2, len(corners))
+	for idx := range corners {
+		edges[idx] = [2]mgl32.Vec2{corners[idx], corners[(idx+1)%len(corners)]}
+	}
+
+	return edges
 }
 
 //
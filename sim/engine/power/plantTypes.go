@@ -1,8 +1,11 @@
 package power
 
 import (
+	"common/commonmath"
 	"sim/config"
 	"sim/core/dto/editorengdto"
+
+	"github.com/go-gl/mathgl/mgl32"
 )
 
 type PowerPlantSize int
@@ -27,6 +30,16 @@ func GetPowerOutputAndSize(plantType string, plantSize PowerPlantSize) (output i
 	return output, size
 }
 
+// Gets the region a plant would cover if placed at the position
+func GetPlantRegion(pos mgl32.Vec2, plantType string, plantSize PowerPlantSize, orientation float32) commonMath.Region {
+	_, size := GetPowerOutputAndSize(plantType, plantSize)
+	return commonMath.Region{
+		RegionType:  commonMath.SquareRegion,
+		Scale:       float32(size),
+		Orientation: orientation,
+		Position:    pos}
+}
+
 func GetPlantType(itemSelection editorengdto.ItemSubSelection) string {
 	return config.Config.Power.IdToNameMap[int(itemSelection)]
 }
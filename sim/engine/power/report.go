@@ -0,0 +1,100 @@
+package power
+
+import (
+	"sim/core/dto/powerdto"
+	"sim/core/graph"
+	"sort"
+)
+
+// The number of most loaded lines listed for each network
+const reportedLineCount = 5
+
+// Gets the fraction of a line's capacity its load uses
+func getLoadFraction(line powerdto.LineFlow) float32 {
+	if line.Capacity <= 0 {
+		return 0
+	}
+
+	return float32(line.Load) / float32(line.Capacity)
+}
+
+// Summarizes a connected component of the power grid from the flow solved across it
+func buildNetworkReport(grid *graph.Graph, nodeIds []int64, flow powerdto.PowerFlow) (powerdto.NetworkReport, []powerdto.PlantFlow) {
+	network := powerdto.NetworkReport{Id: nodeIds[0], MostLoadedLines: make([]powerdto.LineFlow, 0)}
+	plants := make([]powerdto.PlantFlow, 0)
+
+	inNetwork := make(map[int64]bool)
+	for _, nodeId := range nodeIds {
+		inNetwork[nodeId] = true
+	}
+
+	for _, nodeId := range nodeIds {
+		switch data := grid.GetNode(nodeId).(type) {
+		case *PowerPlant:
+			plantFlow, ok := flow.Plants[nodeId]
+			if !ok {
+				plantFlow = powerdto.PlantFlow{Id: nodeId, Type: data.plantType}
+			}
+
+			network.Plants++
+			network.Capacity += int64(data.output)
+			network.Available += plantFlow.Available
+			plants = append(plants, plantFlow)
+		case *PowerConsumer:
+			network.Consumers++
+			network.Demand += int64(data.power)
+			network.Served += flow.Consumers[nodeId].Served
+		}
+
+		// Each line is listed once, from its lower node
+		for _, neighbor := range grid.GetNeighbors(nodeId) {
+			lineFlow, ok := flow.Lines[neighbor.ConnectionId]
+			if ok && inNetwork[neighbor.NodeId] && nodeId < neighbor.NodeId {
+				network.MostLoadedLines = append(network.MostLoadedLines, lineFlow)
+			}
+		}
+	}
+
+	if network.Demand > 0 {
+		network.ReserveMargin = float32(network.Available-network.Demand) / float32(network.Demand)
+	}
+
+	sort.SliceStable(network.MostLoadedLines, func(i, j int) bool {
+		first, second := network.MostLoadedLines[i], network.MostLoadedLines[j]
+		if getLoadFraction(first) != getLoadFraction(second) {
+			return getLoadFraction(first) > getLoadFraction(second)
+		}
+
+		return first.Id < second.Id
+	})
+
+	if len(network.MostLoadedLines) > reportedLineCount {
+		network.MostLoadedLines = network.MostLoadedLines[:reportedLineCount]
+	}
+
+	if network.Consumers > 0 {
+		plants = plants[:0]
+	}
+
+	return network, plants
+}
+
+// Summarizes every network of the power grid from the last flow solved across it.
+// Networks without plants or consumers, such as unused termini, are omitted.
+func buildPowerReport(grid *graph.Graph, flow powerdto.PowerFlow) powerdto.PowerReport {
+	report := powerdto.PowerReport{
+		Networks:       make([]powerdto.NetworkReport, 0),
+		IsolatedPlants: make([]powerdto.PlantFlow, 0)}
+
+	for _, component := range grid.GetConnectedComponents() {
+		network, isolatedPlants := buildNetworkReport(grid, component, flow)
+		if network.Plants == 0 && network.Consumers == 0 {
+			continue
+		}
+
+		report.Networks = append(report.Networks, network)
+		report.IsolatedPlants = append(report.IsolatedPlants, isolatedPlants...)
+	}
+
+	return report
+}
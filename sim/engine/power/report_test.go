@@ -0,0 +1,62 @@
+package power
+
+import (
+	"sim/core/dto/powerdto"
+	"sim/core/graph"
+	"sim/core/lifecycle/lifecycletest"
+	"sim/engine/core/dto"
+	"testing"
+)
+
+func TestBuildPowerReport(t *testing.T) {
+	grid := graph.NewGraph(lifecycletest.NewSupervisor(t))
+	plant := grid.AddNode(&PowerPlant{output: 100})
+	terminus := grid.AddNode(&PowerTerminus{})
+	consumer := grid.AddNode(&PowerConsumer{power: 80})
+	trunkLine := connect(grid, plant, terminus, 1000)
+	dropLine := connect(grid, terminus, consumer, 100)
+
+	// Plants without consumers are isolated, whether or not they have lines
+	isolated := grid.AddNode(&PowerPlant{output: 50})
+	stranded := grid.AddNode(&PowerPlant{output: 20})
+	connect(grid, stranded, grid.AddNode(&PowerTerminus{}), 1000)
+
+	// Termini alone are not networks
+	grid.AddNode(&PowerTerminus{})
+
+	report := buildPowerReport(grid, solvePowerFlow(grid, dto.NewTime()))
+	if len(report.Networks) != 3 {
+		t.Fatalf("Expected 3 networks, found %v", len(report.Networks))
+	}
+
+	network := report.Networks[0]
+	if network.Id != plant || network.Plants != 1 || network.Consumers != 1 {
+		t.Errorf("Expected the first network to have a plant and a consumer, found %v", network)
+	}
+
+	if network.Capacity != 100 || network.Demand != 80 || network.Served != 80 || network.ReserveMargin != 0.25 {
+		t.Errorf("Expected 80 of 80 kW served from 100 kW for a 25%% reserve margin, found %v", network)
+	}
+
+	if len(network.MostLoadedLines) != 2 || network.MostLoadedLines[0].Id != dropLine || network.MostLoadedLines[1].Id != trunkLine {
+		t.Errorf("Expected the smaller line to be the most loaded, found %v", network.MostLoadedLines)
+	}
+
+	if len(report.IsolatedPlants) != 2 || report.IsolatedPlants[0].Id != isolated || report.IsolatedPlants[1].Id != stranded {
+		t.Errorf("Expected plants %v and %v to be isolated, found %v", isolated, stranded, report.IsolatedPlants)
+	}
+}
+
+func TestGetReport(t *testing.T) {
+	supervisor := lifecycletest.NewSupervisor(t)
+	grid := PowerGrid{
+		grid:               graph.NewGraph(supervisor),
+		ReportQueryChannel: make(chan powerdto.PowerReportQuery)}
+	supervisor.Go("power.PowerGrid", grid.run)
+	grid.grid.AddNode(&PowerPlant{output: 100})
+
+	report, ok := grid.GetReport(supervisor.Context())
+	if !ok || len(report.Networks) != 1 || len(report.IsolatedPlants) != 1 {
+		t.Errorf("Expected a report with an isolated plant, found %v", report)
+	}
+}
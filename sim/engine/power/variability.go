@@ -0,0 +1,58 @@
+package power
+
+import (
+	"math"
+	"sim/config"
+	"sim/engine/core/dto"
+	"sync"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/ojrac/opensimplex-go"
+)
+
+const (
+	solarVariability = "solar"
+	windVariability  = "wind"
+)
+
+// The wind field varies smoothly across the map and over time.
+// TODO: Configurable
+const windScale float32 = 500
+const windTimeScale float32 = 20
+
+var windNoise opensimplex.Noise
+var windNoiseOnce sync.Once
+
+// Returns the fraction of peak sunlight at the time of day. The sun is up for the middle half of each day.
+func getDaylight(time dto.Time) float32 {
+	dayFraction := float64(time.DayTime / config.Config.Sim.SecondsPerDay)
+	return float32(math.Max(0, math.Sin(math.Pi*(dayFraction-0.25)/0.5)))
+}
+
+// Returns the fraction of peak wind at the position and time
+func getWind(pos mgl32.Vec2, time dto.Time) float32 {
+	windNoiseOnce.Do(func() {
+		windNoise = opensimplex.New(int64(config.Config.Terrain.Generation.Seed))
+	})
+
+	noise := windNoise.Eval3(float64(pos.X()/windScale), float64(pos.Y()/windScale), float64(time.SimTime/windTimeScale))
+	return float32(math.Min(1, math.Max(0, (noise+1)/2)))
+}
+
+// Gets the output the plant can supply at the given time, in kW
+func (p *PowerPlant) getAvailableOutput(time dto.Time) int {
+	plantType, ok := config.Config.Power.PowerPlantTypes[p.plantType]
+	if !ok {
+		return p.output
+	}
+
+	factor := plantType.CapacityFactor
+	switch plantType.Variability {
+	case solarVariability:
+		factor *= getDaylight(time)
+	case windVariability:
+		factor *= getWind(p.location, time)
+	}
+
+	return int(float32(p.output) * factor)
+}
@@ -0,0 +1,63 @@
+package power
+
+import (
+	"math"
+	"sim/config"
+	"sim/config/configtest"
+	"sim/engine/core/dto"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func withPlantTypes(t *testing.T, plantTypes map[string]config.PowerPlant) {
+	configtest.Override(t, &config.Config.Power.PowerPlantTypes, plantTypes)
+	configtest.Override(t, &config.Config.Sim.SecondsPerDay, 10)
+}
+
+func atDayTime(dayTime float32) dto.Time {
+	return dto.Time{SimTime: dayTime, DayTime: dayTime, Days: 0}
+}
+
+func TestDaylight(t *testing.T) {
+	withPlantTypes(t, nil)
+
+	if daylight := getDaylight(atDayTime(1)); daylight != 0 {
+		t.Errorf("There should be no sun at night, found %v", daylight)
+	}
+
+	if daylight := getDaylight(atDayTime(5)); math.Abs(float64(daylight-1)) > 0.001 {
+		t.Errorf("The sun should peak at midday, found %v", daylight)
+	}
+
+	if morning, evening := getDaylight(atDayTime(4)), getDaylight(atDayTime(6)); math.Abs(float64(morning-evening)) > 0.001 || morning <= 0 || morning >= 1 {
+		t.Errorf("The sun should rise and set symmetrically, found %v and %v", morning, evening)
+	}
+}
+
+func TestAvailableOutput(t *testing.T) {
+	withPlantTypes(t, map[string]config.PowerPlant{
+		"coal":  {CapacityFactor: 0.8},
+		"solar": {CapacityFactor: 0.5, Variability: solarVariability},
+		"wind":  {CapacityFactor: 1, Variability: windVariability}})
+
+	coal := PowerPlant{plantType: "coal", output: 1000}
+	if output := coal.getAvailableOutput(atDayTime(1)); output != 800 {
+		t.Errorf("Coal plants should supply their capacity factor at any time, found %v kW", output)
+	}
+
+	solar := PowerPlant{plantType: "solar", output: 1000}
+	if night, noon := solar.getAvailableOutput(atDayTime(1)), solar.getAvailableOutput(atDayTime(5)); night != 0 || noon != 500 {
+		t.Errorf("Solar plants should only supply power in the day, found %v and %v kW", night, noon)
+	}
+
+	wind := PowerPlant{plantType: "wind", location: mgl32.Vec2{100, 200}, output: 1000}
+	if first, second := wind.getAvailableOutput(atDayTime(1)), wind.getAvailableOutput(atDayTime(1)); first != second || first < 0 || first > 1000 {
+		t.Errorf("Wind plants should supply a consistent fraction of their output, found %v and %v kW", first, second)
+	}
+
+	unknown := PowerPlant{plantType: "unknown", output: 1000}
+	if output := unknown.getAvailableOutput(atDayTime(1)); output != 1000 {
+		t.Errorf("Plants without a configured type should supply their full output, found %v kW", output)
+	}
+}
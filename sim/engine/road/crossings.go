@@ -0,0 +1,139 @@
+package road
+
+import (
+	"sim/config"
+	"sim/core/lifecycle"
+	"sim/engine/finder"
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Defines where a new road crosses an existing one
+type crossing struct {
+	lineId   int64
+	position mgl32.Vec2
+
+	// Fraction of the way along the new road
+	fraction float32
+}
+
+// Returns the fractions along the first and second segments where they cross, if they cross.
+// Parallel segments are never considered to cross.
+func segmentIntersection(firstStart, firstEnd, secondStart, secondEnd mgl32.Vec2) (float32, float32, bool) {
+	first := firstEnd.Sub(firstStart)
+	second := secondEnd.Sub(secondStart)
+
+	denominator := first.X()*second.Y() - first.Y()*second.X()
+	if denominator == 0 {
+		return 0, 0, false
+	}
+
+	offset := secondStart.Sub(firstStart)
+	firstFraction := (offset.X()*second.Y() - offset.Y()*second.X()) / denominator
+	secondFraction := (offset.X()*first.Y() - offset.Y()*first.X()) / denominator
+	return firstFraction, secondFraction, firstFraction >= 0 && firstFraction <= 1 && secondFraction >= 0 && secondFraction <= 1
+}
+
+// Finds the existing roads a new road of the class from start to end crosses, ordered from the start of the new road.
+// Roads connected to the start or end nodes and crossings at the ends of the new road are excluded, as they already meet.
+func (p *RoadGrid) findCrossings(start, end mgl32.Vec2, class config.RoadClass, startNode, endNode int64) []crossing {
+	results := make(chan []finder.ElementKey)
+	if !lifecycle.Send(p.supervisor.Context(), p.finder.IntersectionChannel, finder.NewIntersectionQuery(GetLineFootprint(start, end, class), []finder.ItemType{finder.RoadLine}, results)) {
+		return nil
+	}
+
+	candidates, ok := lifecycle.Receive(p.supervisor.Context(), results)
+	if !ok {
+		return nil
+	}
+
+	length := end.Sub(start).Len()
+	crossings := make([]crossing, 0)
+	for _, key := range candidates {
+		// The finder may not have caught up with lines that were just removed
+		firstNode, secondNode, ok := p.grid.GetConnectionNodes(key.Id)
+		if !ok || firstNode == startNode || firstNode == endNode || secondNode == startNode || secondNode == endNode {
+			continue
+		}
+
+		lineStart := p.grid.GetNode(firstNode).(*RoadTerminus).location
+		lineEnd := p.grid.GetNode(secondNode).(*RoadTerminus).location
+		fraction, _, crosses := segmentIntersection(start, end, lineStart, lineEnd)
+		if !crosses || fraction*length < minSplitDistance || (1-fraction)*length < minSplitDistance {
+			continue
+		}
+
+		crossings = append(crossings, crossing{
+			lineId:   key.Id,
+			position: start.Add(end.Sub(start).Mul(fraction)),
+			fraction: fraction})
+	}
+
+	sort.Slice(crossings, func(i, j int) bool {
+		return crossings[i].fraction < crossings[j].fraction
+	})
+
+	return crossings
+}
+
+// Adds a road of the class, splitting it and the roads it crosses at shared junctions unless it is an overpass.
+// Returns the start node ID, the IDs of the lines making up the road in order, the end node ID, and the number of junctions created.
+func (p *RoadGrid) AddRoad(start, end mgl32.Vec2, class config.RoadClass, startNode, endNode int64, isOverpass bool) (int64, []int64, int64, int) {
+	crossings := make([]crossing, 0)
+	if !isOverpass {
+		crossings = p.findCrossings(start, end, class, startNode, endNode)
+	}
+
+	lineIds := make([]int64, 0)
+	junctions := 0
+	roadStartNode := int64(-1)
+
+	segmentStart := start
+	for _, crossing := range crossings {
+		firstNode, secondNode, _ := p.grid.GetConnectionNodes(crossing.lineId)
+		junctionNode := p.SplitLine(crossing.lineId, crossing.position)
+		if junctionNode == -1 || junctionNode == startNode {
+			continue
+		} else if junctionNode != firstNode && junctionNode != secondNode {
+			junctions++
+		}
+
+		// Junctions at existing termini are positioned there, rather than at the crossing
+		junctionPos := p.grid.GetNode(junctionNode).(*RoadTerminus).location
+
+		segmentStartNode, lineId, _ := p.AddLine(segmentStart, junctionPos, class, startNode, junctionNode)
+		if lineId == -1 {
+			return roadStartNode, lineIds, -1, junctions
+		} else if roadStartNode == -1 {
+			roadStartNode = segmentStartNode
+		}
+
+		lineIds = append(lineIds, lineId)
+		startNode = junctionNode
+		segmentStart = junctionPos
+	}
+
+	segmentStartNode, lineId, endNode := p.AddLine(segmentStart, end, class, startNode, endNode)
+	if lineId == -1 {
+		return roadStartNode, lineIds, -1, junctions
+	} else if roadStartNode == -1 {
+		roadStartNode = segmentStartNode
+	}
+
+	lineIds = append(lineIds, lineId)
+	return roadStartNode, lineIds, endNode, junctions
+}
+
+// Gets the cost of building the lines, so a road that was only partly added is only paid for in part
+func (p *RoadGrid) GetLinesCost(lineIds []int64) float32 {
+	cost := float32(0)
+	for _, lineId := range lineIds {
+		if line, ok := p.grid.GetConnection(lineId).(*RoadLine); ok {
+			ends := line.GetEnds()
+			cost += GetRoadCost(ends[0], ends[1], line.GetClass())
+		}
+	}
+
+	return cost
+}
@@ -2,8 +2,10 @@ package road
 
 import (
 	commonMath "common/commonmath"
+	"context"
 	"fmt"
 	"sim/config"
+	"sim/core/lifecycle"
 	"sim/core/mailroom"
 	"sim/engine/core/dto"
 	"sim/engine/vehicle"
@@ -41,7 +43,7 @@ type InfiniRoadGenerator struct {
 	NewCarTimer int
 }
 
-func NewInfiniRoadGenerator(grid *RoadGrid, vehicleManager *vehicle.VehicleManager) *InfiniRoadGenerator {
+func NewInfiniRoadGenerator(supervisor *lifecycle.Supervisor, grid *RoadGrid, vehicleManager *vehicle.VehicleManager) *InfiniRoadGenerator {
 	infiniRoadGenerator := InfiniRoadGenerator{
 		grid:               grid,
 		vehicleManager:     vehicleManager,
@@ -58,14 +60,14 @@ func NewInfiniRoadGenerator(grid *RoadGrid, vehicleManager *vehicle.VehicleManag
 		EastTerminusId:     -1,
 		NewCarTimer:        0}
 
-	mailroom.NewRegionRegChannel <- infiniRoadGenerator.newRegionChannel
-	mailroom.CoreTimerRegChannel <- infiniRoadGenerator.timerUpdateChannel
+	mailroom.NewRegionRegChannel.Use("road.InfiniRoadGenerator").Send(infiniRoadGenerator.newRegionChannel)
+	mailroom.CoreTimerRegChannel.Use("road.InfiniRoadGenerator").Send(infiniRoadGenerator.timerUpdateChannel)
 
-	go infiniRoadGenerator.run()
+	supervisor.Go("road.InfiniRoadGenerator", infiniRoadGenerator.run)
 	return &infiniRoadGenerator
 }
 
-func (i *InfiniRoadGenerator) run() {
+func (i *InfiniRoadGenerator) run(ctx context.Context) {
 	for {
 		select {
 		case newRegion := <-i.newRegionChannel:
@@ -78,17 +80,24 @@ func (i *InfiniRoadGenerator) run() {
 				if i.NewCarTimer == 10 {
 					// i.NewCarTimer = 11
 
-					// TODO create cars based on demand and if roads have space
+					// TODO create cars based on demand
+					// The west road may have been removed, or be too congested for another car
+					westRoadLine, ok := i.grid.grid.GetConnection(i.WestLineId).(*RoadLine)
+					if !ok || !westRoadLine.HasEntrySpace(i.WestTerminusId) {
+						break
+					}
+
+					// Vehicles cross the map, leaving it at the far edge
 					westVehicle, westVehicleId := i.vehicleManager.NewVehicle()
+					westVehicle.Destination = i.EastTerminusId
 					fmt.Printf("Adding vehicle %v to %v, line %v\n", westVehicleId, i.WestTerminusId, i.WestLineId)
 
 					// Create a new west-bound car
-					westRoadLine := i.grid.grid.GetConnection(i.WestLineId).(*RoadLine)
-					westRoadLine.AddVehicleChannel <- VehicleAddition{
+					lifecycle.Send(ctx, westRoadLine.AddVehicleChannel, VehicleAddition{
 						VehicleId:        westVehicleId,
 						Vehicle:          westVehicle,
 						SourceTerminusId: i.WestTerminusId,
-						Speed:            0.0}
+						Speed:            0.0})
 
 					// eastVehicle, eastVehicleId := i.vehicleManager.NewVehicle()
 					// fmt.Printf("Adding vehicle %v to %v, line %v\n", eastVehicleId, i.EastTerminusId, i.EastLineId)
@@ -101,10 +110,22 @@ func (i *InfiniRoadGenerator) run() {
 					// 	Speed:            0.0}
 				}
 			}
+		case _ = <-ctx.Done():
+			return
 		}
 	}
 }
 
+// Moves an end of the infinite road, which is at the edge of the map, to a new terminus
+func (i *InfiniRoadGenerator) moveMapEdge(edgeTerminusId *int64, terminusId int64) {
+	if *edgeTerminusId != -1 {
+		i.grid.SetMapEdge(*edgeTerminusId, false)
+	}
+
+	*edgeTerminusId = terminusId
+	i.grid.SetMapEdge(terminusId, true)
+}
+
 func (i *InfiniRoadGenerator) markRoadAsGenerated(regionX int) {
 	i.RoadGenerated[regionX] = true
 }
@@ -160,21 +181,20 @@ func (i *InfiniRoadGenerator) GenerateRoad(region commonMath.IntVec2) {
 		}
 	}
 
-	// TODO: Default to highway capacity for the infinte road.
 	// TODO: This should be a lot smarter and follow contours
 	roadId := int64(-1)
-	westNodeId, roadId, eastNodeId = i.grid.AddLine(start, end, 1000, westNodeId, eastNodeId)
+	westNodeId, roadId, eastNodeId = i.grid.AddLine(start, end, getHighwayClass(), westNodeId, eastNodeId)
 
 	if region.X()-1 < i.WestEdge {
 		i.WestEdge = region.X() - 1
 		i.WestLineId = roadId
-		i.WestTerminusId = westNodeId
+		i.moveMapEdge(&i.WestTerminusId, westNodeId)
 	}
 
 	if region.X()+1 > i.EastEdge {
 		i.EastEdge = region.X() + 1
 		i.EastLineId = roadId
-		i.EastTerminusId = eastNodeId
+		i.moveMapEdge(&i.EastTerminusId, eastNodeId)
 	}
 
 	// Update our caches so we don't infinitely generate infinite roads.
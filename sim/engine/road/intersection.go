@@ -0,0 +1,172 @@
+package road
+
+import (
+	"math"
+	"sim/config"
+	"sim/core/dto/editorengdto"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Defines how vehicles take turns crossing a terminus
+type IntersectionControl int
+
+const (
+	Uncontrolled IntersectionControl = iota
+	AllWayStop
+	Signals
+)
+
+func (c IntersectionControl) String() string {
+	switch c {
+	case Uncontrolled:
+		return "uncontrolled"
+	case AllWayStop:
+		return "all-way stop"
+	case Signals:
+		return "traffic signals"
+	default:
+		return "unknown"
+	}
+}
+
+// Gets the intersection control for the item sub-selection, returning false if there is none
+func GetIntersectionControl(itemSelection editorengdto.ItemSubSelection) (IntersectionControl, bool) {
+	control := IntersectionControl(itemSelection)
+	if control > Signals {
+		return Uncontrolled, false
+	}
+
+	return control, true
+}
+
+// Defines a vehicle waiting at a terminus to cross it
+type waitingVehicle struct {
+	addition VehicleAddition
+
+	// The direction the vehicle approached the terminus from, or zero if unknown
+	approach mgl32.Vec2
+
+	// The next terminus on the vehicle's route, or -1 if it has none
+	nextTerminusId int64
+
+	// True while the vehicle's route is being looked up, with the channel the route arrives on once it was asked for
+	isRouting   bool
+	routeResult chan Route
+
+	// How long the vehicle has waited, in seconds
+	waitTime float32
+}
+
+// Decides when vehicles waiting at a terminus can cross it
+type intersectionController interface {
+	// Advances the controller by the elapsed time, in seconds
+	update(elapsed float32)
+
+	// Returns true if the waiting vehicle can cross now
+	canCross(vehicle *waitingVehicle) bool
+
+	// Records that a vehicle crossed
+	crossed()
+}
+
+func newIntersectionController(control IntersectionControl) intersectionController {
+	switch control {
+	case AllWayStop:
+		return &allWayStop{sinceLastCrossing: 0}
+	case Signals:
+		return &fixedCycleSignals{cycleTime: 0}
+	default:
+		return &uncontrolledIntersection{}
+	}
+}
+
+// Lets vehicles cross as soon as they arrive
+type uncontrolledIntersection struct{}
+
+func (u *uncontrolledIntersection) update(elapsed float32) {}
+
+func (u *uncontrolledIntersection) canCross(vehicle *waitingVehicle) bool {
+	return true
+}
+
+func (u *uncontrolledIntersection) crossed() {}
+
+// Stops every vehicle, then lets them cross one at a time
+type allWayStop struct {
+	sinceLastCrossing float32
+}
+
+func (a *allWayStop) update(elapsed float32) {
+	a.sinceLastCrossing += elapsed
+}
+
+func (a *allWayStop) canCross(vehicle *waitingVehicle) bool {
+	stopTime := config.Config.Intersection.StopTime
+	return vehicle.waitTime >= stopTime && a.sinceLastCrossing >= stopTime
+}
+
+func (a *allWayStop) crossed() {
+	a.sinceLastCrossing = 0
+}
+
+// Cycles through the configured signal phases, with all signals red between each phase
+type fixedCycleSignals struct {
+	cycleTime float32
+}
+
+func getCycleLength() float32 {
+	length := float32(0)
+	for _, phase := range config.Config.Intersection.SignalPhases {
+		length += phase.GreenTime + config.Config.Intersection.ClearanceTime
+	}
+
+	return length
+}
+
+// Gets the phase of the signal cycle, returning false if all signals are red
+func (f *fixedCycleSignals) getGreenPhase() (int, bool) {
+	phaseStart := float32(0)
+	for idx, phase := range config.Config.Intersection.SignalPhases {
+		if f.cycleTime < phaseStart+phase.GreenTime {
+			return idx, f.cycleTime >= phaseStart
+		}
+
+		phaseStart += phase.GreenTime + config.Config.Intersection.ClearanceTime
+	}
+
+	return -1, false
+}
+
+// Gets the phase with the axis closest to the approach direction
+func getApproachPhase(approach mgl32.Vec2) int {
+	bestPhase := 0
+	bestAlignment := float32(-1)
+	for idx, phase := range config.Config.Intersection.SignalPhases {
+		axis := mgl32.DegToRad(phase.Axis)
+		alignment := float32(math.Abs(float64(approach.Dot(mgl32.Vec2{float32(math.Cos(float64(axis))), float32(math.Sin(float64(axis)))}))))
+		if alignment > bestAlignment {
+			bestPhase = idx
+			bestAlignment = alignment
+		}
+	}
+
+	return bestPhase
+}
+
+func (f *fixedCycleSignals) update(elapsed float32) {
+	if cycleLength := getCycleLength(); cycleLength > 0 {
+		f.cycleTime = float32(math.Mod(float64(f.cycleTime+elapsed), float64(cycleLength)))
+	}
+}
+
+func (f *fixedCycleSignals) canCross(vehicle *waitingVehicle) bool {
+	if len(config.Config.Intersection.SignalPhases) == 0 {
+		return true
+	}
+
+	phase, isGreen := f.getGreenPhase()
+	return isGreen && phase == getApproachPhase(vehicle.approach)
+}
+
+func (f *fixedCycleSignals) crossed() {}
@@ -0,0 +1,100 @@
+package road
+
+import (
+	"context"
+	"sim/config"
+	"sim/config/configtest"
+	"sim/engine/vehicle"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func withIntersectionConfig(t *testing.T) {
+	configtest.Override(t, &config.Config.Intersection, config.IntersectionConfig{
+		StopTime:      1,
+		ClearanceTime: 2,
+		SignalPhases:  []config.SignalPhase{{Axis: 0, GreenTime: 10}, {Axis: 90, GreenTime: 10}}})
+}
+
+func TestAllWayStop(t *testing.T) {
+	withIntersectionConfig(t)
+
+	controller := newIntersectionController(AllWayStop)
+	first := waitingVehicle{waitTime: 0}
+	second := waitingVehicle{waitTime: 0}
+	if controller.canCross(&first) {
+		t.Error("Vehicles should stop before crossing")
+	}
+
+	controller.update(1)
+	first.waitTime, second.waitTime = 1, 1
+	if !controller.canCross(&first) {
+		t.Fatal("Vehicles should cross after stopping")
+	}
+
+	controller.crossed()
+	if controller.canCross(&second) {
+		t.Error("Vehicles should take turns crossing")
+	}
+
+	controller.update(1)
+	if !controller.canCross(&second) {
+		t.Error("The next vehicle should cross once the last has")
+	}
+}
+
+func TestFixedCycleSignals(t *testing.T) {
+	withIntersectionConfig(t)
+
+	controller := newIntersectionController(Signals)
+	eastbound := waitingVehicle{approach: mgl32.Vec2{1, 0}}
+	southbound := waitingVehicle{approach: mgl32.Vec2{0, -1}}
+
+	// Green for 10 seconds along the X axis, red for 2, green for 10 along the Y axis, then red for 2
+	for _, step := range []struct {
+		elapsed         float32
+		eastboundGreen  bool
+		southboundGreen bool
+	}{{0, true, false}, {9, true, false}, {2, false, false}, {2, false, true}, {8, false, true}, {2, false, false}, {1, true, false}} {
+		controller.update(step.elapsed)
+		if controller.canCross(&eastbound) != step.eastboundGreen || controller.canCross(&southbound) != step.southboundGreen {
+			t.Errorf("Expected green lights of %v and %v, found %v and %v", step.eastboundGreen, step.southboundGreen,
+				controller.canCross(&eastbound), controller.canCross(&southbound))
+		}
+	}
+}
+
+func TestSignalsOnlyHoldRedApproaches(t *testing.T) {
+	withIntersectionConfig(t)
+
+	terminus := NewRoadTerminus(mgl32.Vec2{0, 0})
+	terminus.Id = 0
+	terminus.controller = newIntersectionController(Signals)
+
+	// Vehicles from the north have a red light, and those from the west a green light
+	lines := make(map[int64]*RoadLine)
+	for destinationId, location := range map[int64]mgl32.Vec2{1: {0, 100}, 2: {-100, 0}, 3: {100, 0}} {
+		lines[destinationId] = NewRoadLine(newTestRoadClass(10), 100)
+		lines[destinationId].lowTerminus = terminus.Id
+		terminus.lines[destinationId] = lineConnection{destinationId: destinationId, line: lines[destinationId], approach: terminus.location.Sub(location).Normalize()}
+	}
+
+	terminus.addWaiting(context.Background(), VehicleAddition{VehicleId: 0, Vehicle: vehicle.NewVehicle(), SourceTerminusId: 1})
+	terminus.addWaiting(context.Background(), VehicleAddition{VehicleId: 1, Vehicle: vehicle.NewVehicle(), SourceTerminusId: 2})
+
+	terminus.crossWaiting(context.Background(), 0.1)
+	if len(terminus.waiting) != 1 || terminus.waiting[0].addition.VehicleId != 0 {
+		t.Errorf("Only the vehicle with a red light should wait, found %v waiting", len(terminus.waiting))
+	}
+}
+
+func TestGetIntersectionControl(t *testing.T) {
+	if control, ok := GetIntersectionControl(2); !ok || control != Signals {
+		t.Errorf("The third sub-selection should be traffic signals, found %v", control)
+	}
+
+	if _, ok := GetIntersectionControl(3); ok {
+		t.Error("There are only three intersection controls")
+	}
+}
@@ -0,0 +1,45 @@
+package road
+
+import (
+	"math"
+	"sim/engine/vehicle"
+)
+
+// Parameters of the Intelligent Driver Model (IDM) vehicles follow each other with
+const desiredTimeHeadway float32 = 1.5 // seconds
+const minimumGap float32 = 2.0         // units between stopped vehicles
+const accelerationExponent = 4
+
+// Vehicles brake harder than is comfortable when they must, up to this multiple of their deceleration
+const emergencyBrakingFactor float32 = 3.0
+
+// Gets the acceleration of a vehicle with the Intelligent Driver Model, which approaches the speed limit on a free road
+// and keeps a safe distance behind the vehicle (or stopping point) ahead. Free roads have an infinite gap.
+func getIdmAcceleration(vehicle *vehicle.Vehicle, speed, speedLimit, gap, leaderSpeed float32) float32 {
+	freeRoad := 1 - float32(math.Pow(float64(speed/speedLimit), accelerationExponent))
+	if math.IsInf(float64(gap), 1) {
+		return vehicle.Acceleration * freeRoad
+	}
+
+	brakingTerm := speed * (speed - leaderSpeed) / (2 * float32(math.Sqrt(float64(vehicle.Acceleration*vehicle.Deceleration))))
+	desiredGap := minimumGap + max(0, speed*desiredTimeHeadway+brakingTerm)
+	interaction := desiredGap / max(gap, 0.01)
+
+	return vehicle.Acceleration * (freeRoad - interaction*interaction)
+}
+
+// Moves a vehicle for the elapsed time, given the gap to the vehicle (or stopping point) ahead and how fast that is going.
+// Vehicles never close within the minimum gap of what is ahead. Returns the new speed and the distance traveled.
+func advanceVehicle(vehicle *vehicle.Vehicle, speed, speedLimit, gap, leaderSpeed, elapsed float32) (float32, float32) {
+	acceleration := getIdmAcceleration(vehicle, speed, speedLimit, gap, leaderSpeed)
+	acceleration = max(acceleration, -vehicle.Deceleration*emergencyBrakingFactor)
+
+	newSpeed := max(0, speed+acceleration*elapsed)
+	distance := (speed + newSpeed) / 2 * elapsed
+	if maxDistance := max(0, gap-minimumGap); distance > maxDistance {
+		distance = maxDistance
+		newSpeed = min(newSpeed, leaderSpeed)
+	}
+
+	return newSpeed, distance
+}
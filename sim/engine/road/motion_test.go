@@ -0,0 +1,59 @@
+package road
+
+import (
+	"math"
+	"sim/engine/vehicle"
+	"testing"
+)
+
+var freeRoad = float32(math.Inf(1))
+
+func TestAdvanceVehicleAccelerates(t *testing.T) {
+	car := vehicle.NewVehicle()
+	car.Acceleration = 2
+
+	speed, distance := advanceVehicle(car, 0, 10, freeRoad, 0, 1)
+	if speed != 2 || distance != 1 {
+		t.Errorf("Expected to reach 2 units/s after 1 unit, found %v units/s after %v units", speed, distance)
+	}
+
+	speed = 0
+	for i := 0; i < 1000; i++ {
+		speed, _ = advanceVehicle(car, speed, 10, freeRoad, 0, 0.1)
+	}
+
+	if speed > 10 || speed < 9.9 {
+		t.Errorf("Vehicles should approach the speed limit without exceeding it, found %v units/s", speed)
+	}
+}
+
+func TestAdvanceVehicleBrakesForSpeedLimit(t *testing.T) {
+	car := vehicle.NewVehicle()
+	car.Deceleration = 4
+
+	speed, _ := advanceVehicle(car, 20, 10, freeRoad, 0, 0.1)
+	if speed >= 20 || speed < 20-4*emergencyBrakingFactor*0.1 {
+		t.Errorf("Expected to slow down for a lower speed limit, no faster than emergency braking, found %v units/s", speed)
+	}
+}
+
+func TestAdvanceVehicleFollows(t *testing.T) {
+	car := vehicle.NewVehicle()
+
+	// Approaching a stopped vehicle ahead ends with the vehicle stopped behind it
+	speed, position := float32(15), float32(0)
+	for i := 0; i < 1000; i++ {
+		var distance float32
+		speed, distance = advanceVehicle(car, speed, 15, 100-position, 0, 0.1)
+		position += distance
+	}
+
+	if speed > 0.01 || position > 100-minimumGap || position < 100-2*minimumGap {
+		t.Errorf("Expected to stop just behind the vehicle ahead, found %v units/s at %v units", speed, position)
+	}
+
+	// Vehicles never close within the minimum gap
+	if _, distance := advanceVehicle(car, 15, 15, 3, 0, 1); distance != 1 {
+		t.Errorf("Expected to travel only 1 unit to keep the minimum gap, found %v units", distance)
+	}
+}
@@ -1,18 +1,23 @@
 package road
 
 import (
-	"fmt"
+	"context"
+	"math"
+	"sim/config"
 	"sim/core/dto/vehicledto"
+	"sim/core/lifecycle"
 	"sim/core/mailroom"
 	"sim/engine/core/dto"
 	"sim/engine/vehicle"
+	"sort"
+	"sync/atomic"
 
 	"github.com/go-gl/mathgl/mgl32"
 )
 
 type progressingVehicle struct {
 	vehicle *vehicle.Vehicle
-	speed   float32
+	speed   float32 // units / second
 	percent float32
 }
 
@@ -20,171 +25,283 @@ type VehicleAddition struct {
 	VehicleId        int64
 	Vehicle          *vehicle.Vehicle
 	SourceTerminusId int64
-	Speed            float32
-}
-
-type RoadTerminus struct {
-	location mgl32.Vec2
+	Speed            float32 // units / second
 
-	Id                     int64
-	LineAddVehicleChannels map[int64]chan VehicleAddition // TODO: use the graph, not a hardcoded value
-	AddVehicleChannel      chan VehicleAddition
-	// timerUpdateChannel chan dto.Time TODO: perform time-based updates for intersections
-	ControlChannel chan int
+	// How far along the line the vehicle starts, from 0 (at the source terminus) to 1
+	Progress float32
 }
 
-func NewRoadTerminus(location mgl32.Vec2) *RoadTerminus {
-	terminus := RoadTerminus{
-		location:               location,
-		LineAddVehicleChannels: make(map[int64]chan VehicleAddition),
-		AddVehicleChannel:      make(chan VehicleAddition, 3),
-		ControlChannel:         make(chan int)}
-
-	return &terminus
+// Defines the vehicles traveling on a line, in each direction
+type roadTraffic struct {
+	lowToHigh map[int64]*progressingVehicle
+	highToLow map[int64]*progressingVehicle
 }
 
 type RoadLine struct {
-	capacity int64
+	class  config.RoadClass
+	length float32
+	ends   [2]mgl32.Vec2
+
+	// Despawns vehicles leaving the simulation at the end of the line
+	vehicleManager *vehicle.VehicleManager
+
+	// The sim time of the last timer update, or negative before the first one
+	lastSimTime float32
+
+	// Updated by the line's own goroutine, readable from any goroutine.
+	vehicleCount  atomic.Int64
+	lowEntryOpen  atomic.Bool
+	highEntryOpen atomic.Bool
+	delay         atomic.Int64 // ms of vehicle time lost to traveling below the speed limit
 
 	lowToHighTraffic map[int64]*progressingVehicle
 	highToLowTraffic map[int64]*progressingVehicle
 
-	lowTerminus           int64
-	lowTerminusAddChannel chan VehicleAddition
+	lowTerminus     int64
+	lowTerminusNode *RoadTerminus
 
-	highTerminus           int64
-	highTerminusAddChannel chan VehicleAddition
+	highTerminus     int64
+	highTerminusNode *RoadTerminus
 
 	Id                 int64
 	TimerUpdateChannel chan dto.Time
 	AddVehicleChannel  chan VehicleAddition
-	ControlChannel     chan int
+	handOffChannel     chan chan roadTraffic
+
+	stop context.CancelFunc
 }
 
-func NewRoadLine(capacity int64) *RoadLine {
+func NewRoadLine(class config.RoadClass, length float32) *RoadLine {
 	roadLine := RoadLine{
-		capacity:           capacity,
+		class:              class,
+		length:             length,
+		lastSimTime:        -1,
 		lowToHighTraffic:   make(map[int64]*progressingVehicle),
 		highToLowTraffic:   make(map[int64]*progressingVehicle),
 		TimerUpdateChannel: make(chan dto.Time, 3),
 		AddVehicleChannel:  make(chan VehicleAddition, 3),
-		ControlChannel:     make(chan int)}
+		handOffChannel:     make(chan chan roadTraffic)}
 
+	roadLine.lowEntryOpen.Store(true)
+	roadLine.highEntryOpen.Store(true)
 	return &roadLine
 }
 
-func (r *RoadLine) run() {
-	for {
-		select {
-		case addition := <-r.AddVehicleChannel:
-			if addition.SourceTerminusId == r.lowTerminus {
-				r.lowToHighTraffic[addition.VehicleId] = &progressingVehicle{
-					vehicle: addition.Vehicle,
-					speed:   addition.Speed,
-					percent: 0.0}
-
-				mailroom.VehicleUpdateChannel <- vehicledto.VehicleUpdate{
-					Id:            addition.VehicleId,
-					RoadId:        r.Id,
-					TravelLength:  0.001,
-					VehicleLength: addition.Vehicle.Length}
-
-			} else {
-				r.highToLowTraffic[addition.VehicleId] = &progressingVehicle{
-					vehicle: addition.Vehicle,
-					speed:   addition.Speed,
-					percent: 0.0}
-
-				mailroom.VehicleUpdateChannel <- vehicledto.VehicleUpdate{
-					Id:            addition.VehicleId,
-					RoadId:        r.Id,
-					TravelLength:  -0.001,
-					VehicleLength: addition.Vehicle.Length}
-			}
-		case _ = <-r.TimerUpdateChannel:
-			// Move traffic along the road line
-			// TODO: Silly demo
-			for vehicleId, vehicle := range r.highToLowTraffic {
-				fmt.Printf("vehicle %v at L-H %v on line %v from %v to %v\n", vehicleId, vehicle.percent, r.Id, r.highTerminus, r.lowTerminus)
-				vehicle.percent += 0.05
-				if vehicle.percent >= 1.0 {
-					r.lowTerminusAddChannel <- VehicleAddition{
-						VehicleId:        vehicleId,
-						Vehicle:          vehicle.vehicle,
-						SourceTerminusId: r.highTerminus,
-						Speed:            vehicle.speed}
-					delete(r.highToLowTraffic, vehicleId)
-				} else {
-					mailroom.VehicleUpdateChannel <- vehicledto.VehicleUpdate{
-						Id:            vehicleId,
-						RoadId:        r.Id,
-						TravelLength:  -vehicle.percent,
-						VehicleLength: vehicle.vehicle.Length}
-				}
-			}
+func (r *RoadLine) GetLength() float32 {
+	return r.length
+}
 
-			for vehicleId, vehicle := range r.lowToHighTraffic {
-				fmt.Printf("vehicle %v at H-L %v on line %v from %v to %v\n", vehicleId, vehicle.percent, r.Id, r.lowTerminus, r.highTerminus)
-				vehicle.percent += 0.05
-				if vehicle.percent >= 1.0 {
-					r.highTerminusAddChannel <- VehicleAddition{
-						VehicleId:        vehicleId,
-						Vehicle:          vehicle.vehicle,
-						SourceTerminusId: r.lowTerminus,
-						Speed:            vehicle.speed}
-					delete(r.lowToHighTraffic, vehicleId)
-				} else {
-					mailroom.VehicleUpdateChannel <- vehicledto.VehicleUpdate{
-						Id:            vehicleId,
-						RoadId:        r.Id,
-						TravelLength:  vehicle.percent,
-						VehicleLength: vehicle.vehicle.Length}
-				}
-			}
-		case _ = <-r.ControlChannel:
-			return
+func (r *RoadLine) GetEnds() [2]mgl32.Vec2 {
+	return r.ends
+}
+
+func (r *RoadLine) GetClass() config.RoadClass {
+	return r.class
+}
+
+func (r *RoadLine) GetCapacity() int64 {
+	return r.class.Capacity
+}
+
+func (r *RoadLine) GetSpeedLimit() float32 {
+	return r.class.SpeedLimit
+}
+
+// Gets the number of vehicles currently traveling on the line, in either direction.
+func (r *RoadLine) GetVehicleCount() int64 {
+	return r.vehicleCount.Load()
+}
+
+// Gets the total time vehicles on the line have lost to traveling below the speed limit, in seconds.
+// This measures the cost of congestion on the line.
+func (r *RoadLine) GetDelay() float32 {
+	return float32(r.delay.Load()) / 1000
+}
+
+// Returns true if a vehicle from the terminus can enter the line.
+// Lines are full when they reach their capacity or the vehicles last to enter have not moved far enough along to leave a lane free.
+func (r *RoadLine) HasEntrySpace(sourceTerminusId int64) bool {
+	if sourceTerminusId == r.lowTerminus {
+		return r.lowEntryOpen.Load()
+	}
+
+	return r.highEntryOpen.Load()
+}
+
+// Returns true if the vehicles last to enter in one direction have left room behind them in any lane for another
+func (r *RoadLine) hasRoomBehind(traffic map[int64]*progressingVehicle) bool {
+	entering := int64(0)
+	for _, vehicle := range traffic {
+		if vehicle.percent*r.length-vehicle.vehicle.Length < minimumGap {
+			entering++
+		}
+	}
+
+	return entering < getLanesPerDirection(r.class)
+}
+
+func (r *RoadLine) updateTrafficState() {
+	vehicleCount := int64(len(r.lowToHighTraffic) + len(r.highToLowTraffic))
+	r.vehicleCount.Store(vehicleCount)
+	r.lowEntryOpen.Store(vehicleCount < r.class.Capacity && r.hasRoomBehind(r.lowToHighTraffic))
+	r.highEntryOpen.Store(vehicleCount < r.class.Capacity && r.hasRoomBehind(r.highToLowTraffic))
+}
+
+// Stops the road line's goroutine, if it was started
+func (r *RoadLine) Stop() {
+	if r.stop != nil {
+		r.stop()
+	}
+}
+
+// Stops the line's goroutine, returning the vehicles that were traveling on it.
+// Returns false if the line stopped first.
+func (r *RoadLine) handOffTraffic(ctx context.Context) (roadTraffic, bool) {
+	result := make(chan roadTraffic)
+	if !lifecycle.Send(ctx, r.handOffChannel, result) {
+		return roadTraffic{}, false
+	}
+
+	return lifecycle.Receive(ctx, result)
+}
+
+func (r *RoadLine) addVehicle(ctx context.Context, addition VehicleAddition) {
+	if addition.SourceTerminusId == r.lowTerminus {
+		r.lowToHighTraffic[addition.VehicleId] = &progressingVehicle{
+			vehicle: addition.Vehicle,
+			speed:   addition.Speed,
+			percent: addition.Progress}
+
+		mailroom.VehicleUpdateChannel.SendContext(ctx, vehicledto.VehicleUpdate{
+			Id:            addition.VehicleId,
+			RoadId:        r.Id,
+			TravelLength:  max(addition.Progress, 0.001),
+			VehicleLength: addition.Vehicle.Length})
+
+	} else {
+		r.highToLowTraffic[addition.VehicleId] = &progressingVehicle{
+			vehicle: addition.Vehicle,
+			speed:   addition.Speed,
+			percent: addition.Progress}
+
+		mailroom.VehicleUpdateChannel.SendContext(ctx, vehicledto.VehicleUpdate{
+			Id:            addition.VehicleId,
+			RoadId:        r.Id,
+			TravelLength:  -max(addition.Progress, 0.001),
+			VehicleLength: addition.Vehicle.Length})
+	}
+
+	r.updateTrafficState()
+}
+
+// Removes a vehicle that left the road network from the simulation
+func despawnVehicle(ctx context.Context, vehicleManager *vehicle.VehicleManager, vehicleId int64) {
+	if vehicleManager.DeleteVehicle(vehicleId) {
+		mailroom.VehicleDeletionChannel.SendContext(ctx, vehicleId)
+	}
+}
+
+// Gets the IDs of the vehicles traveling in one direction, from the furthest along to the least
+func getVehicleOrder(traffic map[int64]*progressingVehicle) []int64 {
+	vehicleIds := make([]int64, 0, len(traffic))
+	for vehicleId := range traffic {
+		vehicleIds = append(vehicleIds, vehicleId)
+	}
+
+	sort.Slice(vehicleIds, func(i, j int) bool {
+		first, second := traffic[vehicleIds[i]], traffic[vehicleIds[j]]
+		if first.percent != second.percent {
+			return first.percent > second.percent
+		}
+
+		return vehicleIds[i] < vehicleIds[j]
+	})
+
+	return vehicleIds
+}
+
+// Moves the vehicles traveling in one direction along the line for the elapsed time.
+// Vehicles spread across the lanes in their direction, so each follows the vehicle one lane count ahead of it.
+// Vehicles reaching the end of the line are handed off to the terminus there, or queue on the line while it is full.
+// Vehicles leave the simulation instead if the terminus is their destination or at the edge of the map.
+// The direction is 1 from the low terminus to the high terminus, -1 otherwise.
+func (r *RoadLine) moveTraffic(ctx context.Context, traffic map[int64]*progressingVehicle, elapsed float32, sourceTerminusId int64, destination *RoadTerminus, direction float32) {
+	lanes := getLanesPerDirection(r.class)
+	ahead := make([]*progressingVehicle, 0, len(traffic))
+	for _, vehicleId := range getVehicleOrder(traffic) {
+		vehicle := traffic[vehicleId]
+		remaining := (1 - vehicle.percent) * r.length
+		isExiting := destination.isExit(vehicle.vehicle)
+
+		// Vehicles at the front of each lane follow nothing, unless their approach to the terminus ahead is full and they must stop at the end of the line
+		isLeading := int64(len(ahead)) < lanes
+		gap, leaderSpeed := float32(math.Inf(1)), float32(0)
+		if !isLeading {
+			leader := ahead[int64(len(ahead))-lanes]
+			gap = (leader.percent-vehicle.percent)*r.length - leader.vehicle.Length
+			leaderSpeed = leader.speed
+		} else if !isExiting && !destination.hasSpace(sourceTerminusId, lanes) {
+			gap = remaining
 		}
+
+		speed, distance := advanceVehicle(vehicle.vehicle, vehicle.speed, r.class.SpeedLimit, gap, leaderSpeed, elapsed)
+		vehicle.speed = speed
+		r.delay.Add(int64(elapsed * max(0, 1-speed/r.class.SpeedLimit) * 1000))
+
+		if isLeading && distance >= remaining && isExiting {
+			delete(traffic, vehicleId)
+			despawnVehicle(ctx, r.vehicleManager, vehicleId)
+			continue
+		} else if isLeading && distance >= remaining && destination.reserveSpace(sourceTerminusId, lanes) {
+			lifecycle.Send(ctx, destination.AddVehicleChannel, VehicleAddition{
+				VehicleId:        vehicleId,
+				Vehicle:          vehicle.vehicle,
+				SourceTerminusId: sourceTerminusId,
+				Speed:            vehicle.speed})
+			delete(traffic, vehicleId)
+			continue
+		}
+
+		vehicle.percent = min(1, vehicle.percent+distance/r.length)
+		mailroom.VehicleUpdateChannel.SendContext(ctx, vehicledto.VehicleUpdate{
+			Id:            vehicleId,
+			RoadId:        r.Id,
+			TravelLength:  direction * vehicle.percent,
+			VehicleLength: vehicle.vehicle.Length})
+		ahead = append(ahead, vehicle)
 	}
 }
 
-func (r *RoadTerminus) run() {
+func (r *RoadLine) run(ctx context.Context) {
 	for {
 		select {
-		case vehicle := <-r.AddVehicleChannel:
-			for destinationId, _ := range r.LineAddVehicleChannels {
-				fmt.Printf("(%v) %v -- %v\n", r.Id, vehicle.SourceTerminusId, destinationId)
-			}
-
-			// TODO silly demo logic.
-			moved := false
-			for destinationId, channel := range r.LineAddVehicleChannels {
-				if destinationId != vehicle.SourceTerminusId {
-					// We're going somewhere else, so send it!
-					channel <- VehicleAddition{
-						VehicleId:        vehicle.VehicleId,
-						Vehicle:          vehicle.Vehicle,
-						Speed:            vehicle.Speed,
-						SourceTerminusId: r.Id}
-					moved = true
-					break
-				}
+		case addition := <-r.AddVehicleChannel:
+			r.addVehicle(ctx, addition)
+		case time := <-r.TimerUpdateChannel:
+			elapsed := float32(0)
+			if r.lastSimTime >= 0 {
+				elapsed = time.SimTime - r.lastSimTime
 			}
 
-			if !moved {
-				// The vehicle has no where else to go so it bounces to the first result
-				for _, channel := range r.LineAddVehicleChannels {
-					channel <- VehicleAddition{
-						VehicleId:        vehicle.VehicleId,
-						Vehicle:          vehicle.Vehicle,
-						Speed:            vehicle.Speed,
-						SourceTerminusId: r.Id}
-					break
+			r.lastSimTime = time.SimTime
+			r.moveTraffic(ctx, r.lowToHighTraffic, elapsed, r.lowTerminus, r.highTerminusNode, 1)
+			r.moveTraffic(ctx, r.highToLowTraffic, elapsed, r.highTerminus, r.lowTerminusNode, -1)
+			r.updateTrafficState()
+		case result := <-r.handOffChannel:
+			// The line is being replaced, so its vehicles (including any still arriving) move to the replacement lines.
+			for drained := false; !drained; {
+				select {
+				case addition := <-r.AddVehicleChannel:
+					r.addVehicle(ctx, addition)
+				default:
+					drained = true
 				}
 			}
 
-			// Move vehicle through the intersection, or
-			// to the next line for disjointed segments
-		case _ = <-r.ControlChannel:
+			result <- roadTraffic{lowToHigh: r.lowToHighTraffic, highToLow: r.highToLowTraffic}
+			close(result)
+			return
+		case _ = <-ctx.Done():
 			return
 		}
 	}
@@ -0,0 +1,39 @@
+package road
+
+import (
+	"sim/config"
+	"sim/core/dto/editorengdto"
+	"sim/engine/finder"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Gets the road class for the item sub-selection, returning false if there is none
+func GetRoadClass(itemSelection editorengdto.ItemSubSelection) (config.RoadClass, bool) {
+	if int(itemSelection) >= len(config.Config.Road.RoadClasses) {
+		return config.RoadClass{}, false
+	}
+
+	return config.Config.Road.RoadClasses[itemSelection], true
+}
+
+// Gets the largest road class, which the infinite road is built as
+func getHighwayClass() config.RoadClass {
+	classes := config.Config.Road.RoadClasses
+	return classes[len(classes)-1]
+}
+
+// Gets the cost to build a road of the class
+func GetRoadCost(start, end mgl32.Vec2, class config.RoadClass) float32 {
+	return start.Sub(end).Len() * class.CostPerUnit
+}
+
+// Gets the corridor of the map a road of the class would cover
+func GetLineFootprint(start, end mgl32.Vec2, class config.RoadClass) finder.Footprint {
+	return finder.NewCorridorFootprint(start, end, class.RenderWidth)
+}
+
+// Gets the number of lanes the class has in each direction, which is always at least one
+func getLanesPerDirection(class config.RoadClass) int64 {
+	return max(1, int64(class.Lanes/2))
+}
@@ -0,0 +1,87 @@
+package road
+
+import (
+	"sim/config"
+	"sim/config/configtest"
+	"sim/core/lifecycle/lifecycletest"
+	"sim/engine/finder"
+	"sim/engine/vehicle"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func withRoadClasses(t *testing.T) {
+	configtest.Override(t, &config.Config.Road.RoadClasses, []config.RoadClass{
+		{Name: "street", Lanes: 2, SpeedLimit: 10, Capacity: 10, CostPerUnit: 20, Upkeep: 1, RenderWidth: 6},
+		{Name: "highway", Lanes: 4, SpeedLimit: 30, Capacity: 40, CostPerUnit: 80, Upkeep: 5, RenderWidth: 12}})
+}
+
+func TestGetRoadClass(t *testing.T) {
+	withRoadClasses(t)
+
+	if class, ok := GetRoadClass(1); !ok || class.Name != "highway" {
+		t.Errorf("The second sub-selection should be a highway, found %v", class.Name)
+	}
+
+	if _, ok := GetRoadClass(2); ok {
+		t.Error("There are only two road classes")
+	}
+
+	if class := getHighwayClass(); class.Name != "highway" {
+		t.Errorf("The infinite road should be built as the largest road class, found %v", class.Name)
+	}
+
+	if cost := GetRoadCost(mgl32.Vec2{0, 0}, mgl32.Vec2{30, 40}, config.Config.Road.RoadClasses[0]); cost != 1000 {
+		t.Errorf("Expected 50 units of street to cost 1000, found %v", cost)
+	}
+}
+
+func TestDailyUpkeep(t *testing.T) {
+	withRoadClasses(t)
+	provideTestMailboxes()
+
+	supervisor := lifecycletest.NewSupervisor(t)
+	grid := NewRoadGrid(supervisor, finder.NewElementFinder(supervisor), vehicle.NewVehicleManager())
+
+	// Split roads keep their class, so cost the same upkeep
+	street, highway := config.Config.Road.RoadClasses[0], config.Config.Road.RoadClasses[1]
+	grid.AddLine(mgl32.Vec2{0, 0}, mgl32.Vec2{100, 0}, street, -1, -1)
+	_, lineId, _ := grid.AddLine(mgl32.Vec2{0, 100}, mgl32.Vec2{100, 100}, highway, -1, -1)
+	grid.SplitLine(lineId, mgl32.Vec2{40, 100})
+
+	if upkeep := grid.getDailyUpkeep(); upkeep != 600 {
+		t.Errorf("Expected a daily upkeep of 600, found %v", upkeep)
+	}
+}
+
+func TestLanesLetVehiclesSideBySide(t *testing.T) {
+	withRoadClasses(t)
+	provideTestMailboxes()
+	ctx := lifecycletest.NewSupervisor(t).Context()
+
+	// A highway has two lanes in each direction, so two vehicles can enter together and both reach the terminus
+	line := NewRoadLine(config.Config.Road.RoadClasses[1], 100)
+	line.lowTerminus, line.highTerminus = 0, 1
+	line.lowTerminusNode, line.highTerminusNode = NewRoadTerminus(mgl32.Vec2{0, 0}), NewRoadTerminus(mgl32.Vec2{100, 0})
+
+	for vehicleId := int64(0); vehicleId < 2; vehicleId++ {
+		line.lowToHighTraffic[vehicleId] = &progressingVehicle{vehicle: vehicle.NewVehicle(), speed: 30, percent: 0.9}
+		line.updateTrafficState()
+		if !line.HasEntrySpace(line.lowTerminus) {
+			t.Fatalf("There should be a free lane for vehicle %v", vehicleId)
+		}
+	}
+
+	line.lowToHighTraffic[2] = &progressingVehicle{vehicle: vehicle.NewVehicle(), speed: 30, percent: 0}
+	line.lowToHighTraffic[3] = &progressingVehicle{vehicle: vehicle.NewVehicle(), speed: 30, percent: 0}
+	line.updateTrafficState()
+	if line.HasEntrySpace(line.lowTerminus) {
+		t.Error("Vehicles entering both lanes should block the entry")
+	}
+
+	line.moveTraffic(ctx, line.lowToHighTraffic, 1, line.lowTerminus, line.highTerminusNode, 1)
+	if _, ok := line.lowToHighTraffic[0]; ok || len(line.highTerminusNode.AddVehicleChannel) != 2 {
+		t.Errorf("Both vehicles side by side should reach the terminus, found %v waiting", len(line.highTerminusNode.AddVehicleChannel))
+	}
+}
@@ -1,11 +1,17 @@
 package road
 
 import (
+	"context"
 	"fmt"
+	"sim/config"
 	"sim/core/dto/geometry"
 	"sim/core/graph"
+	"sim/core/lifecycle"
 	"sim/core/mailroom"
+	"sim/engine/core"
+	"sim/engine/core/dto"
 	"sim/engine/finder"
+	"sim/engine/vehicle"
 
 	"github.com/go-gl/mathgl/mgl32"
 )
@@ -24,44 +30,130 @@ func max64(a, b int64) int64 {
 	return b
 }
 
+// Roads are not split this close to their ends, as the split would be at the existing terminus
+const minSplitDistance float32 = 0.01
+
 type RoadGrid struct {
-	finder *finder.ElementFinder
-	grid   *graph.Graph
+	supervisor     *lifecycle.Supervisor
+	finder         *finder.ElementFinder
+	vehicleManager *vehicle.VehicleManager
+	grid           *graph.Graph
+	lastDay        int
+
+	Router             *Router
+	TimerUpdateChannel chan dto.Time
 }
 
-func NewRoadGrid(finder *finder.ElementFinder) *RoadGrid {
+func NewRoadGrid(supervisor *lifecycle.Supervisor, elementFinder *finder.ElementFinder, vehicleManager *vehicle.VehicleManager) *RoadGrid {
 	grid := RoadGrid{
-		finder: finder,
-		grid:   graph.NewGraph()}
+		supervisor:         supervisor,
+		finder:             elementFinder,
+		vehicleManager:     vehicleManager,
+		grid:               graph.NewGraph(supervisor),
+		lastDay:            0,
+		TimerUpdateChannel: make(chan dto.Time, 3)}
+
+	elementFinder.WatchGraph(grid.grid, []finder.ItemType{finder.RoadTerminus}, finder.RoadLine)
+
+	grid.Router = NewRouter(supervisor, grid.grid)
+
+	mailroom.CoreTimerRegChannel.Use("road.RoadGrid")
+	mailroom.CoreTimerRegChannel.Use("road.RoadLine")
+	mailroom.CoreTimerRegChannel.Use("road.RoadTerminus")
+	mailroom.CoreTimerUnregChannel.Use("road.RoadGrid")
+	mailroom.DeleteRoadLineChannel.Use("road.RoadGrid")
+	mailroom.VehicleUpdateChannel.Use("road.RoadLine")
+	mailroom.VehicleDeletionChannel.Use("road.RoadLine")
+	mailroom.NewRoadLineChannel.Use("road.RoadGrid")
+	mailroom.NewRoadLineIdChannel.Use("road.RoadGrid")
+	mailroom.NewRoadTerminusChannel.Use("road.RoadGrid")
+
+	supervisor.Go("road.RoadGrid", grid.run)
+	mailroom.CoreTimerRegChannel.SendContext(supervisor.Context(), grid.TimerUpdateChannel)
 	return &grid
 }
 
+// Charges the upkeep of every road once a day
+func (p *RoadGrid) run(ctx context.Context) {
+	for {
+		select {
+		case time := <-p.TimerUpdateChannel:
+			if time.Days > p.lastDay {
+				p.lastDay = time.Days
+				p.chargeUpkeep(ctx)
+			}
+		case _ = <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Gets the cost of a day of upkeep for every road in the grid
+func (p *RoadGrid) getDailyUpkeep() float32 {
+	upkeep := float32(0)
+	for _, connectionId := range p.grid.GetConnectionIds() {
+		if line, ok := p.grid.GetConnection(connectionId).(*RoadLine); ok {
+			upkeep += line.length * line.class.Upkeep
+		}
+	}
+
+	return upkeep
+}
+
+func (p *RoadGrid) chargeUpkeep(ctx context.Context) {
+	if upkeep := p.getDailyUpkeep(); upkeep > 0 {
+		lifecycle.Send(ctx, core.CoreFinances.TransactionChannel, dto.NewTransaction("day of road upkeep", upkeep))
+	}
+}
+
 func (p *RoadGrid) setupLineConnections(startNode, lineId, endNode int64, line *RoadLine) (int64, int64, int64) {
 	startTerminus := p.grid.GetNode(startNode).(*RoadTerminus)
-	startTerminus.LineAddVehicleChannels[endNode] = line.AddVehicleChannel
 	endTerminus := p.grid.GetNode(endNode).(*RoadTerminus)
-	endTerminus.LineAddVehicleChannels[startNode] = line.AddVehicleChannel
+	startTerminus.connectLine(p.supervisor.Context(), endNode, endTerminus.location, line)
+	endTerminus.connectLine(p.supervisor.Context(), startNode, startTerminus.location, line)
 
 	line.Id = lineId
+	line.ends = [2]mgl32.Vec2{startTerminus.location, endTerminus.location}
+	line.vehicleManager = p.vehicleManager
 	line.lowTerminus = min64(startNode, endNode)
 	line.highTerminus = max64(startNode, endNode)
 	if startNode > endNode {
-		line.lowTerminusAddChannel = endTerminus.AddVehicleChannel
-		line.highTerminusAddChannel = startTerminus.AddVehicleChannel
+		line.lowTerminusNode = endTerminus
+		line.highTerminusNode = startTerminus
 	} else {
-		line.lowTerminusAddChannel = startTerminus.AddVehicleChannel
-		line.highTerminusAddChannel = endTerminus.AddVehicleChannel
+		line.lowTerminusNode = startTerminus
+		line.highTerminusNode = endTerminus
 	}
 
-	go line.run()
-	mailroom.CoreTimerRegChannel <- line.TimerUpdateChannel
+	line.stop = p.supervisor.Go("road.RoadLine", line.run)
+	mailroom.CoreTimerRegChannel.SendContext(p.supervisor.Context(), line.TimerUpdateChannel)
 
 	return startNode, lineId, endNode
 }
 
-// Adds a line to the road grid, returning the start node ID, line ID, and end node ID, in that order
-func (p *RoadGrid) AddLine(start, end mgl32.Vec2, capacity int64, startNode, endNode int64) (int64, int64, int64) {
-	line := NewRoadLine(capacity)
+func (p *RoadGrid) addTerminus(pos mgl32.Vec2) int64 {
+	terminus := NewRoadTerminus(pos)
+	nodeId := p.grid.AddNode(terminus)
+	terminus.Id = nodeId
+	terminus.router = p.Router
+
+	mailroom.NewRoadTerminusChannel.SendContext(p.supervisor.Context(), geometry.NewIdPoint(terminus.Id, terminus.location))
+	terminus.stop = p.supervisor.Go("road.RoadTerminus", terminus.run)
+	mailroom.CoreTimerRegChannel.SendContext(p.supervisor.Context(), terminus.TimerUpdateChannel)
+
+	lifecycle.Send(p.supervisor.Context(), p.finder.AddElementChannel, finder.NewElement(nodeId, finder.RoadTerminus, []mgl32.Vec2{pos}))
+	return nodeId
+}
+
+func (p *RoadGrid) addLineElement(lineId int64, start, end mgl32.Vec2, class config.RoadClass, startNode, endNode int64) {
+	lifecycle.Send(p.supervisor.Context(), p.finder.AddElementChannel, finder.NewLineElement(lineId, finder.RoadLine, [2]mgl32.Vec2{start, end}, GetLineFootprint(start, end, class)))
+	mailroom.NewRoadLineChannel.SendContext(p.supervisor.Context(), geometry.NewWideIdLine(lineId, [2]mgl32.Vec2{start, end}, class.RenderWidth))
+	mailroom.NewRoadLineIdChannel.SendContext(p.supervisor.Context(), geometry.NewIdOnlyLine(lineId, startNode, endNode))
+}
+
+// Adds a line of the road class to the road grid, returning the start node ID, line ID, and end node ID, in that order
+func (p *RoadGrid) AddLine(start, end mgl32.Vec2, class config.RoadClass, startNode, endNode int64) (int64, int64, int64) {
+	line := NewRoadLine(class, end.Sub(start).Len())
 
 	if startNode == endNode && startNode != -1 {
 		fmt.Printf("Roads must be between nodes and cannot (for a single line) loop\n")
@@ -72,39 +164,197 @@ func (p *RoadGrid) AddLine(start, end mgl32.Vec2, capacity int64, startNode, end
 		if connectionStatus.Status == graph.Exists {
 			fmt.Printf("There already is a line from %v to %v.\n", startNode, endNode)
 			return -1, -1, -1
+		} else if connectionStatus.Status == graph.NodesMissing {
+			fmt.Printf("Cannot add a line from %v to %v, as one of them no longer exists.\n", startNode, endNode)
+			return -1, -1, -1
 		} else {
-			mailroom.NewRoadLineChannel <- geometry.NewIdLine(connectionStatus.Id, [2]mgl32.Vec2{start, end})
-			mailroom.NewRoadLineIdChannel <- geometry.NewIdOnlyLine(connectionStatus.Id, startNode, endNode)
+			p.addLineElement(connectionStatus.Id, start, end, class, startNode, endNode)
 			return p.setupLineConnections(startNode, connectionStatus.Id, endNode, line)
 		}
 	}
 
 	if startNode == -1 {
-		terminus := NewRoadTerminus(start)
-		startNode = p.grid.AddNode(terminus)
-		terminus.Id = startNode
-
-		mailroom.NewRoadTerminusChannel <- geometry.NewIdPoint(terminus.Id, terminus.location)
-		go terminus.run()
-
-		p.finder.AddElementChannel <- finder.NewElement(startNode, finder.RoadTerminus, []mgl32.Vec2{start})
+		startNode = p.addTerminus(start)
 	}
 
 	if endNode == -1 {
-		terminus := NewRoadTerminus(end)
-		endNode = p.grid.AddNode(terminus)
-		terminus.Id = endNode
-
-		mailroom.NewRoadTerminusChannel <- geometry.NewIdPoint(terminus.Id, terminus.location)
-		go terminus.run()
-
-		p.finder.AddElementChannel <- finder.NewElement(endNode, finder.RoadTerminus, []mgl32.Vec2{end})
+		endNode = p.addTerminus(end)
 	}
 
 	connectionStatus := p.grid.AddConnection(startNode, endNode, line)
-	mailroom.NewRoadLineChannel <- geometry.NewIdLine(connectionStatus.Id, [2]mgl32.Vec2{start, end})
-	mailroom.NewRoadLineIdChannel <- geometry.NewIdOnlyLine(connectionStatus.Id, startNode, endNode)
+	p.addLineElement(connectionStatus.Id, start, end, class, startNode, endNode)
 
 	// Hookup nodes to termii. TODO simplify / use grid more
 	return p.setupLineConnections(startNode, connectionStatus.Id, endNode, line)
 }
+
+// Moves a vehicle from a line that was split onto one of its replacements
+func (p *RoadGrid) migrateVehicle(line *RoadLine, vehicleId int64, vehicle *progressingVehicle, sourceTerminusId int64, progress float32) {
+	lifecycle.Send(p.supervisor.Context(), line.AddVehicleChannel, VehicleAddition{
+		VehicleId:        vehicleId,
+		Vehicle:          vehicle.vehicle,
+		SourceTerminusId: sourceTerminusId,
+		Speed:            vehicle.speed,
+		Progress:         progress})
+}
+
+// Stops a road and removes it from the grid, returning the vehicles that were traveling on it.
+// Returns false if the line stopped first.
+func (p *RoadGrid) removeLine(lineId int64, line *RoadLine) (roadTraffic, bool) {
+	lowTerminus := p.grid.GetNode(line.lowTerminus).(*RoadTerminus)
+	highTerminus := p.grid.GetNode(line.highTerminus).(*RoadTerminus)
+
+	// Disconnect the termini first, so no vehicles are sent to the line after it hands off its traffic.
+	ctx := p.supervisor.Context()
+	lowTerminus.connectLine(ctx, line.highTerminus, highTerminus.location, nil)
+	highTerminus.connectLine(ctx, line.lowTerminus, lowTerminus.location, nil)
+
+	traffic, ok := line.handOffTraffic(ctx)
+	if !ok {
+		return roadTraffic{}, false
+	}
+
+	mailroom.CoreTimerUnregChannel.SendContext(ctx, line.TimerUpdateChannel)
+	p.grid.DeleteConnection(line.lowTerminus, line.highTerminus)
+	mailroom.DeleteRoadLineChannel.SendContext(ctx, lineId)
+	return traffic, true
+}
+
+// Splits a road in two at a new terminus, keeping its class and moving vehicles on the road onto the half they are on.
+// Returns the terminus ID, which is an existing terminus if splitting at the road ends, or -1 if the road no longer exists.
+func (p *RoadGrid) SplitLine(lineId int64, pos mgl32.Vec2) int64 {
+	line, ok := p.grid.GetConnection(lineId).(*RoadLine)
+	if !ok {
+		fmt.Printf("Cannot split road %v, as it no longer exists.\n", lineId)
+		return -1
+	}
+
+	lowTerminus := p.grid.GetNode(line.lowTerminus).(*RoadTerminus)
+	highTerminus := p.grid.GetNode(line.highTerminus).(*RoadTerminus)
+
+	// Vehicles keep their position along the road, which is split this fraction of the way from the low terminus.
+	direction := highTerminus.location.Sub(lowTerminus.location)
+	splitFraction := pos.Sub(lowTerminus.location).Dot(direction) / direction.Dot(direction)
+	if splitFraction*line.length < minSplitDistance {
+		return line.lowTerminus
+	} else if (1-splitFraction)*line.length < minSplitDistance {
+		return line.highTerminus
+	}
+
+	traffic, ok := p.removeLine(lineId, line)
+	if !ok {
+		return -1
+	}
+
+	splitNode := p.addTerminus(pos)
+	_, lowLineId, _ := p.AddLine(lowTerminus.location, pos, line.class, line.lowTerminus, splitNode)
+	_, highLineId, _ := p.AddLine(pos, highTerminus.location, line.class, splitNode, line.highTerminus)
+	lowLine := p.grid.GetConnection(lowLineId).(*RoadLine)
+	highLine := p.grid.GetConnection(highLineId).(*RoadLine)
+
+	for vehicleId, vehicle := range traffic.lowToHigh {
+		if vehicle.percent < splitFraction {
+			p.migrateVehicle(lowLine, vehicleId, vehicle, line.lowTerminus, vehicle.percent/splitFraction)
+		} else {
+			p.migrateVehicle(highLine, vehicleId, vehicle, splitNode, (vehicle.percent-splitFraction)/(1-splitFraction))
+		}
+	}
+
+	// Vehicles traveling from high to low measure their progress from the high terminus
+	highFraction := 1 - splitFraction
+	for vehicleId, vehicle := range traffic.highToLow {
+		if vehicle.percent < highFraction {
+			p.migrateVehicle(highLine, vehicleId, vehicle, line.highTerminus, vehicle.percent/highFraction)
+		} else {
+			p.migrateVehicle(lowLine, vehicleId, vehicle, splitNode, (vehicle.percent-highFraction)/splitFraction)
+		}
+	}
+
+	return splitNode
+}
+
+// Removes a road, stopping its goroutine, along with any termini it leaves unconnected.
+// Returns the removed road and the IDs of the vehicles that were on it or waiting in removed termini, or false if the road no longer exists.
+func (p *RoadGrid) DeleteLine(lineId int64) (*RoadLine, []int64, bool) {
+	line, ok := p.grid.GetConnection(lineId).(*RoadLine)
+	if !ok {
+		return nil, nil, false
+	}
+
+	traffic, ok := p.removeLine(lineId, line)
+	if !ok {
+		return nil, nil, false
+	}
+
+	vehicleIds := make([]int64, 0, len(traffic.lowToHigh)+len(traffic.highToLow))
+	for vehicleId := range traffic.lowToHigh {
+		vehicleIds = append(vehicleIds, vehicleId)
+	}
+
+	for vehicleId := range traffic.highToLow {
+		vehicleIds = append(vehicleIds, vehicleId)
+	}
+
+	vehicleIds = append(vehicleIds, p.deleteIfUnconnected(line.lowTerminus)...)
+	vehicleIds = append(vehicleIds, p.deleteIfUnconnected(line.highTerminus)...)
+	return line, vehicleIds, true
+}
+
+// Removes and stops a terminus that no longer connects any roads, returning the IDs of the vehicles that were waiting in it
+func (p *RoadGrid) deleteIfUnconnected(nodeId int64) []int64 {
+	terminus, ok := p.grid.GetNode(nodeId).(*RoadTerminus)
+	if !ok || len(p.grid.GetNeighbors(nodeId)) != 0 {
+		return nil
+	}
+
+	ctx := p.supervisor.Context()
+	vehicleIds, _ := terminus.handOffWaiting(ctx)
+	terminus.Stop()
+
+	mailroom.CoreTimerUnregChannel.SendContext(ctx, terminus.TimerUpdateChannel)
+	p.grid.DeleteNode(nodeId)
+	return vehicleIds
+}
+
+// Removes a terminus and the roads connected to it.
+// Returns the removed roads and the IDs of the vehicles that were on them, or false if the terminus no longer exists.
+func (p *RoadGrid) DeleteTerminus(terminusId int64) ([]*RoadLine, []int64, bool) {
+	if _, ok := p.grid.GetNode(terminusId).(*RoadTerminus); !ok {
+		return nil, nil, false
+	}
+
+	removedLines := make([]*RoadLine, 0)
+	vehicleIds := make([]int64, 0)
+	for _, neighbor := range p.grid.GetNeighbors(terminusId) {
+		if line, lineVehicleIds, ok := p.DeleteLine(neighbor.ConnectionId); ok {
+			removedLines = append(removedLines, line)
+			vehicleIds = append(vehicleIds, lineVehicleIds...)
+		}
+	}
+
+	// The terminus is removed with its last road, unless it had none
+	vehicleIds = append(vehicleIds, p.deleteIfUnconnected(terminusId)...)
+	return removedLines, vehicleIds, true
+}
+
+// Marks a terminus as being at the edge of the map, or not, returning false if the terminus no longer exists.
+// Vehicles reaching a terminus at the edge of the map leave it.
+func (p *RoadGrid) SetMapEdge(terminusId int64, isMapEdge bool) bool {
+	terminus, ok := p.grid.GetNode(terminusId).(*RoadTerminus)
+	if !ok {
+		return false
+	}
+
+	terminus.isMapEdge.Store(isMapEdge)
+	return true
+}
+
+// Changes how vehicles take turns crossing a terminus, returning false if the terminus no longer exists
+func (p *RoadGrid) SetIntersectionControl(terminusId int64, control IntersectionControl) bool {
+	terminus, ok := p.grid.GetNode(terminusId).(*RoadTerminus)
+	if !ok {
+		return false
+	}
+
+	return terminus.setControl(p.supervisor.Context(), control)
+}
@@ -0,0 +1,307 @@
+package road
+
+import (
+	"context"
+	"math"
+	"sim/core/dto/geometry"
+	"sim/core/dto/vehicledto"
+	"sim/core/lifecycle"
+	"sim/core/lifecycle/lifecycletest"
+	"sim/core/mailroom"
+	"sim/engine/core/dto"
+	"sim/engine/finder"
+	"sim/engine/vehicle"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+var provideMailboxes sync.Once
+
+// Provides the mailboxes the road grid sends to, discarding everything sent
+func provideTestMailboxes() {
+	provideMailboxes.Do(func() {
+		mailroom.CoreTimerRegChannel.Provide("test", discard[chan dto.Time]())
+		mailroom.CoreTimerUnregChannel.Provide("test", discard[chan dto.Time]())
+		mailroom.DeleteRoadLineChannel.Provide("test", discard[int64]())
+		mailroom.VehicleUpdateChannel.Provide("test", discard[vehicledto.VehicleUpdate]())
+		mailroom.VehicleDeletionChannel.Provide("test", discard[int64]())
+		mailroom.NewRoadLineChannel.Provide("test", discard[geometry.IdLine]())
+		mailroom.NewRoadLineIdChannel.Provide("test", discard[geometry.IdOnlyLine]())
+		mailroom.NewRoadTerminusChannel.Provide("test", discard[geometry.IdPoint]())
+	})
+}
+
+func discard[T any]() chan T {
+	channel := make(chan T)
+	go func() {
+		for range channel {
+		}
+	}()
+
+	return channel
+}
+
+func TestRoadGridShutdown(t *testing.T) {
+	provideTestMailboxes()
+
+	supervisor := lifecycle.NewSupervisor(context.Background())
+	grid := NewRoadGrid(supervisor, finder.NewElementFinder(supervisor), vehicle.NewVehicleManager())
+
+	_, _, lastNode := grid.AddLine(mgl32.Vec2{0, 0}, mgl32.Vec2{10, 0}, newTestRoadClass(10), -1, -1)
+	for i := 2; i <= 100; i++ {
+		_, _, lastNode = grid.AddLine(mgl32.Vec2{float32(i-1) * 10, 0}, mgl32.Vec2{float32(i) * 10, 0}, newTestRoadClass(10), lastNode, -1)
+	}
+
+	running := strings.Join(supervisor.Running(), ", ")
+	if !strings.Contains(running, "road.RoadLine (x100)") || !strings.Contains(running, "road.RoadTerminus (x101)") {
+		t.Fatalf("Road lines and termini should be running, found %v", running)
+	}
+
+	if err := supervisor.Shutdown(time.Second); err != nil {
+		t.Fatalf("The road grid should shut down cleanly, found '%v'", err)
+	}
+
+	if running := supervisor.Running(); len(running) != 0 {
+		t.Errorf("Agents leaked after shutdown: %v", running)
+	}
+}
+
+// Returns the line connecting the nodes
+func getLine(t *testing.T, grid *RoadGrid, first, second int64) *RoadLine {
+	for _, neighbor := range grid.grid.GetNeighbors(first) {
+		if neighbor.NodeId == second {
+			return neighbor.ConnectionData.(*RoadLine)
+		}
+	}
+
+	t.Fatalf("There should be a line from %v to %v", first, second)
+	return nil
+}
+
+func checkMigratedVehicle(t *testing.T, traffic map[int64]*progressingVehicle, vehicleId int64, expectedProgress float32) {
+	vehicle, ok := traffic[vehicleId]
+	if !ok {
+		t.Errorf("Vehicle %v should have migrated in this direction", vehicleId)
+	} else if math.Abs(float64(vehicle.percent-expectedProgress)) > 0.001 {
+		t.Errorf("Vehicle %v should have progress %v, found %v", vehicleId, expectedProgress, vehicle.percent)
+	}
+}
+
+func TestSplitLine(t *testing.T) {
+	provideTestMailboxes()
+
+	supervisor := lifecycletest.NewSupervisor(t)
+	ctx := supervisor.Context()
+	grid := NewRoadGrid(supervisor, finder.NewElementFinder(supervisor), vehicle.NewVehicleManager())
+
+	lowNode, lineId, highNode := grid.AddLine(mgl32.Vec2{0, 0}, mgl32.Vec2{100, 0}, newTestRoadClass(10), -1, -1)
+	line := grid.grid.GetConnection(lineId).(*RoadLine)
+
+	// Vehicles 0 and 1 travel low to high, at 20 and 70 units along. Vehicles 2 and 3 travel high to low, at 70 and 10 units along.
+	progresses := []float32{0.2, 0.7, 0.3, 0.9}
+	for vehicleId, progress := range progresses {
+		source := lowNode
+		if vehicleId >= 2 {
+			source = highNode
+		}
+
+		lifecycle.Send(ctx, line.AddVehicleChannel, VehicleAddition{
+			VehicleId:        int64(vehicleId),
+			Vehicle:          vehicle.NewVehicle(),
+			SourceTerminusId: source,
+			Progress:         progress})
+	}
+
+	splitNode := grid.SplitLine(lineId, mgl32.Vec2{40, 0})
+	if splitNode == -1 || grid.grid.GetConnection(lineId) != nil {
+		t.Fatalf("The line should have been split")
+	}
+
+	lowTraffic, _ := getLine(t, grid, lowNode, splitNode).handOffTraffic(ctx)
+	highTraffic, _ := getLine(t, grid, highNode, splitNode).handOffTraffic(ctx)
+	if len(lowTraffic.lowToHigh)+len(lowTraffic.highToLow)+len(highTraffic.lowToHigh)+len(highTraffic.highToLow) != len(progresses) {
+		t.Fatalf("All vehicles should have migrated")
+	}
+
+	// The split terminus has the highest ID, so it is the high terminus of both halves.
+	checkMigratedVehicle(t, lowTraffic.lowToHigh, 0, 0.5)
+	checkMigratedVehicle(t, highTraffic.highToLow, 1, 0.5)
+	checkMigratedVehicle(t, highTraffic.lowToHigh, 2, 0.5)
+	checkMigratedVehicle(t, lowTraffic.highToLow, 3, 0.75)
+
+	if grid.SplitLine(lineId, mgl32.Vec2{50, 0}) != -1 {
+		t.Error("Removed lines cannot be split")
+	}
+}
+
+func TestSplitLineAtEnd(t *testing.T) {
+	provideTestMailboxes()
+
+	supervisor := lifecycletest.NewSupervisor(t)
+	grid := NewRoadGrid(supervisor, finder.NewElementFinder(supervisor), vehicle.NewVehicleManager())
+
+	lowNode, lineId, highNode := grid.AddLine(mgl32.Vec2{0, 0}, mgl32.Vec2{100, 0}, newTestRoadClass(10), -1, -1)
+	if grid.SplitLine(lineId, mgl32.Vec2{0, 0}) != lowNode || grid.SplitLine(lineId, mgl32.Vec2{100, 0}) != highNode {
+		t.Error("Splitting at the ends of a line should return the existing termini")
+	}
+
+	if grid.grid.GetConnection(lineId) == nil {
+		t.Error("Splitting at the ends of a line should leave it intact")
+	}
+}
+
+func TestAddRoadCreatesJunctions(t *testing.T) {
+	provideTestMailboxes()
+
+	supervisor := lifecycletest.NewSupervisor(t)
+	grid := NewRoadGrid(supervisor, finder.NewElementFinder(supervisor), vehicle.NewVehicleManager())
+
+	_, firstLine, _ := grid.AddLine(mgl32.Vec2{0, 0}, mgl32.Vec2{100, 0}, newTestRoadClass(10), -1, -1)
+	_, secondLine, _ := grid.AddLine(mgl32.Vec2{0, 40}, mgl32.Vec2{100, 40}, newTestRoadClass(10), -1, -1)
+
+	startNode, lineIds, endNode, junctions := grid.AddRoad(mgl32.Vec2{50, 80}, mgl32.Vec2{50, -20}, newTestRoadClass(10), -1, -1, false)
+	if len(lineIds) != 3 || junctions != 2 {
+		t.Fatalf("The road should be split at two junctions, found %v lines and %v junctions", len(lineIds), junctions)
+	}
+
+	if grid.grid.GetConnection(firstLine) != nil || grid.grid.GetConnection(secondLine) != nil {
+		t.Error("Crossed roads should have been split")
+	}
+
+	// The junctions are ordered from the start of the road
+	_, firstJunction, _ := grid.grid.GetConnectionNodes(lineIds[0])
+	_, secondJunction, _ := grid.grid.GetConnectionNodes(lineIds[1])
+	if grid.grid.GetNode(firstJunction).(*RoadTerminus).location != (mgl32.Vec2{50, 40}) ||
+		grid.grid.GetNode(secondJunction).(*RoadTerminus).location != (mgl32.Vec2{50, 0}) {
+		t.Error("The junctions should be at the crossings, in order along the road")
+	}
+
+	for _, junction := range []int64{firstJunction, secondJunction} {
+		if neighbors := grid.grid.GetNeighbors(junction); len(neighbors) != 4 {
+			t.Errorf("Junction %v should connect four roads, found %v", junction, len(neighbors))
+		}
+	}
+
+	if _, _, ok := grid.grid.GetConnectionNodes(lineIds[2]); !ok || startNode == -1 || endNode == -1 {
+		t.Error("The road should run between its start and end nodes")
+	}
+
+	// Only the segments given are paid for
+	if cost := grid.GetLinesCost(lineIds[:2]); cost != 800 {
+		t.Errorf("The first two segments should cost 800, found %v", cost)
+	}
+
+	if cost := grid.GetLinesCost(lineIds); cost != GetRoadCost(mgl32.Vec2{50, 80}, mgl32.Vec2{50, -20}, newTestRoadClass(10)) {
+		t.Errorf("All segments should cost as much as the whole road, found %v", cost)
+	}
+}
+
+func TestAddRoadOverpass(t *testing.T) {
+	provideTestMailboxes()
+
+	supervisor := lifecycletest.NewSupervisor(t)
+	grid := NewRoadGrid(supervisor, finder.NewElementFinder(supervisor), vehicle.NewVehicleManager())
+
+	_, lineId, _ := grid.AddLine(mgl32.Vec2{0, 0}, mgl32.Vec2{100, 0}, newTestRoadClass(10), -1, -1)
+	_, lineIds, _, junctions := grid.AddRoad(mgl32.Vec2{50, 50}, mgl32.Vec2{50, -50}, newTestRoadClass(10), -1, -1, true)
+	if len(lineIds) != 1 || junctions != 0 || grid.grid.GetConnection(lineId) == nil {
+		t.Errorf("Overpasses should not create junctions, found %v lines and %v junctions", len(lineIds), junctions)
+	}
+
+	// Roads meeting at their ends already share a terminus
+	lowNode, _, _ := grid.AddLine(mgl32.Vec2{0, 0}, mgl32.Vec2{0, 100}, newTestRoadClass(10), -1, -1)
+	_, lineIds, _, junctions = grid.AddRoad(mgl32.Vec2{0, 0}, mgl32.Vec2{-100, 100}, newTestRoadClass(10), lowNode, -1, false)
+	if len(lineIds) != 1 || junctions != 0 {
+		t.Errorf("Roads sharing a terminus should not create junctions, found %v lines and %v junctions", len(lineIds), junctions)
+	}
+}
+
+func TestSegmentIntersection(t *testing.T) {
+	first, second, crosses := segmentIntersection(mgl32.Vec2{0, 0}, mgl32.Vec2{10, 0}, mgl32.Vec2{2, -5}, mgl32.Vec2{2, 5})
+	if !crosses || first != 0.2 || second != 0.5 {
+		t.Errorf("Expected a crossing at 0.2 and 0.5, found %v at %v and %v", crosses, first, second)
+	}
+
+	if _, _, crosses := segmentIntersection(mgl32.Vec2{0, 0}, mgl32.Vec2{10, 0}, mgl32.Vec2{0, 1}, mgl32.Vec2{10, 1}); crosses {
+		t.Error("Parallel segments should not cross")
+	}
+
+	if _, _, crosses := segmentIntersection(mgl32.Vec2{0, 0}, mgl32.Vec2{10, 0}, mgl32.Vec2{12, -5}, mgl32.Vec2{12, 5}); crosses {
+		t.Error("Segments that would cross if extended should not cross")
+	}
+}
+
+func TestDeleteLine(t *testing.T) {
+	provideTestMailboxes()
+
+	supervisor := lifecycletest.NewSupervisor(t)
+	grid := NewRoadGrid(supervisor, finder.NewElementFinder(supervisor), vehicle.NewVehicleManager())
+
+	lowNode, lineId, middleNode := grid.AddLine(mgl32.Vec2{0, 0}, mgl32.Vec2{100, 0}, newTestRoadClass(10), -1, -1)
+	_, _, highNode := grid.AddLine(mgl32.Vec2{100, 0}, mgl32.Vec2{200, 0}, newTestRoadClass(10), middleNode, -1)
+
+	line := grid.grid.GetConnection(lineId).(*RoadLine)
+	lifecycle.Send(supervisor.Context(), line.AddVehicleChannel, VehicleAddition{
+		VehicleId:        7,
+		Vehicle:          vehicle.NewVehicle(),
+		SourceTerminusId: lowNode,
+		Progress:         0.5})
+
+	removedLine, vehicleIds, ok := grid.DeleteLine(lineId)
+	if !ok {
+		t.Fatal("The line should have been deleted")
+	}
+
+	if ends := removedLine.GetEnds(); ends != [2]mgl32.Vec2{{0, 0}, {100, 0}} {
+		t.Errorf("The removed line should be returned for refunding, found it at %v", ends)
+	}
+
+	if len(vehicleIds) != 1 || vehicleIds[0] != 7 {
+		t.Errorf("The vehicle on the line should be returned for despawning, found %v", vehicleIds)
+	}
+
+	// Only the terminus left without roads is removed
+	if grid.grid.GetNode(lowNode) != nil || grid.grid.GetNode(middleNode) == nil || grid.grid.GetNode(highNode) == nil {
+		t.Error("Only unconnected termini should be removed with the line")
+	}
+
+	if _, _, ok := grid.DeleteLine(lineId); ok {
+		t.Error("Deleted lines cannot be deleted again")
+	}
+}
+
+func TestDeleteTerminus(t *testing.T) {
+	provideTestMailboxes()
+
+	supervisor := lifecycletest.NewSupervisor(t)
+	grid := NewRoadGrid(supervisor, finder.NewElementFinder(supervisor), vehicle.NewVehicleManager())
+
+	westNode, _, centerNode := grid.AddLine(mgl32.Vec2{-100, 0}, mgl32.Vec2{0, 0}, newTestRoadClass(10), -1, -1)
+	grid.AddLine(mgl32.Vec2{0, 0}, mgl32.Vec2{100, 0}, newTestRoadClass(10), centerNode, -1)
+	grid.AddLine(mgl32.Vec2{0, 0}, mgl32.Vec2{0, 100}, newTestRoadClass(10), centerNode, -1)
+
+	removedLines, _, ok := grid.DeleteTerminus(centerNode)
+	if !ok || len(removedLines) != 3 {
+		t.Fatalf("Deleting a terminus should remove its three roads, found %v removed", len(removedLines))
+	}
+
+	if len(grid.grid.GetNodeIds()) != 0 || len(grid.grid.GetConnectionIds()) != 0 || grid.grid.GetNode(westNode) != nil {
+		t.Error("All termini left unconnected should be removed")
+	}
+
+	// Stopped agents finish asynchronously
+	deadline := time.Now().Add(time.Second)
+	running := strings.Join(supervisor.Running(), ", ")
+	for (strings.Contains(running, "road.RoadLine") || strings.Contains(running, "road.RoadTerminus")) && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+		running = strings.Join(supervisor.Running(), ", ")
+	}
+
+	if strings.Contains(running, "road.RoadLine") || strings.Contains(running, "road.RoadTerminus") {
+		t.Errorf("Removed roads and termini should be stopped, found %v", running)
+	}
+}
@@ -0,0 +1,219 @@
+package road
+
+import (
+	"context"
+	"sim/core/graph"
+	"sim/core/lifecycle/lifecycletest"
+	"sim/engine/vehicle"
+	"testing"
+	"time"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Creates a line from terminus 0 to terminus 1, 100 units long
+func newTestTrafficLine(capacity int64) (*RoadLine, *RoadTerminus) {
+	line := NewRoadLine(newTestRoadClass(capacity), 100)
+	line.lowTerminus = 0
+	line.highTerminus = 1
+	line.lowTerminusNode = NewRoadTerminus(mgl32.Vec2{0, 0})
+	line.highTerminusNode = NewRoadTerminus(mgl32.Vec2{100, 0})
+	return line, line.highTerminusNode
+}
+
+func TestTrafficQueuesAtFullTerminus(t *testing.T) {
+	provideTestMailboxes()
+	ctx := lifecycletest.NewSupervisor(t).Context()
+
+	line, destination := newTestTrafficLine(3)
+	destination.occupancy[line.lowTerminus] = terminusCapacity
+	for vehicleId, progress := range []float32{0.9, 0.5, 0.2} {
+		line.lowToHighTraffic[int64(vehicleId)] = &progressingVehicle{vehicle: vehicle.NewVehicle(), speed: line.GetSpeedLimit(), percent: progress}
+	}
+
+	for i := 0; i < 300; i++ {
+		line.moveTraffic(ctx, line.lowToHighTraffic, 0.1, line.lowTerminus, destination, 1)
+	}
+
+	if len(line.lowToHighTraffic) != 3 {
+		t.Fatalf("Vehicles should queue on the line while the terminus is full, found %v", len(line.lowToHighTraffic))
+	}
+
+	if leader := line.lowToHighTraffic[0]; leader.percent > 1-minimumGap/line.length || leader.speed > 0.01 {
+		t.Errorf("The lead vehicle should stop before the end of the line, found %v units/s at %v", leader.speed, leader.percent)
+	}
+
+	for vehicleId := int64(1); vehicleId < 3; vehicleId++ {
+		leader, follower := line.lowToHighTraffic[vehicleId-1], line.lowToHighTraffic[vehicleId]
+		if gap := (leader.percent-follower.percent)*line.length - leader.vehicle.Length; gap < minimumGap-0.001 {
+			t.Errorf("Vehicle %v should stay behind the vehicle ahead, found a gap of %v units", vehicleId, gap)
+		}
+	}
+
+	line.updateTrafficState()
+	if line.HasEntrySpace(line.lowTerminus) || line.HasEntrySpace(line.highTerminus) {
+		t.Error("The line should be full in both directions at capacity")
+	}
+
+	if line.GetDelay() <= 0 {
+		t.Error("Queued vehicles should be delayed")
+	}
+
+	// Once the approach has space, the lead vehicle leaves the line
+	destination.releaseSpace(line.lowTerminus)
+	for i := 0; i < 100 && len(line.lowToHighTraffic) == 3; i++ {
+		line.moveTraffic(ctx, line.lowToHighTraffic, 0.1, line.lowTerminus, destination, 1)
+	}
+
+	if _, ok := line.lowToHighTraffic[0]; ok || destination.GetWaitingCount() != terminusCapacity || len(destination.AddVehicleChannel) != 1 {
+		t.Errorf("The lead vehicle should have been handed off to the terminus")
+	}
+}
+
+func TestTrafficEntrySpace(t *testing.T) {
+	line, _ := newTestTrafficLine(10)
+	line.lowToHighTraffic[0] = &progressingVehicle{vehicle: vehicle.NewVehicle(), percent: 0.1}
+
+	line.updateTrafficState()
+	if line.HasEntrySpace(line.lowTerminus) {
+		t.Error("A vehicle just entering the line should block the entry")
+	}
+
+	line.lowToHighTraffic[0].percent = 0.5
+	line.updateTrafficState()
+	if !line.HasEntrySpace(line.lowTerminus) {
+		t.Error("The entry should open once the vehicle moves along")
+	}
+}
+
+func TestTerminusCrossesWhenLinesHaveSpace(t *testing.T) {
+	line, terminus := newTestTrafficLine(10)
+	terminus.Id = line.highTerminus
+	terminus.lines[line.lowTerminus] = lineConnection{destinationId: line.lowTerminus, line: line}
+	line.highEntryOpen.Store(false)
+
+	for vehicleId := int64(0); vehicleId < 2; vehicleId++ {
+		terminus.reserveSpace(2, 1)
+		terminus.addWaiting(context.Background(), VehicleAddition{VehicleId: vehicleId, Vehicle: vehicle.NewVehicle(), SourceTerminusId: 2, Speed: 10})
+	}
+
+	if terminus.reserveSpace(2, 1) || !terminus.hasSpace(3, 1) {
+		t.Error("Only the approach from terminus 2 should be full")
+	}
+
+	terminus.crossWaiting(context.Background(), 0.1)
+	if len(terminus.waiting) != 2 || terminus.waiting[0].addition.Speed != 0 {
+		t.Fatalf("Vehicles should stop and wait while their next line is full")
+	}
+
+	// Only one vehicle enters a line per update
+	line.highEntryOpen.Store(true)
+	terminus.crossWaiting(context.Background(), 0.1)
+	if len(terminus.waiting) != 1 || terminus.waiting[0].addition.VehicleId != 1 || len(line.AddVehicleChannel) != 1 || !terminus.hasSpace(2, 1) {
+		t.Errorf("The first waiting vehicle should have entered the line, found %v waiting", len(terminus.waiting))
+	}
+
+	if addition := <-line.AddVehicleChannel; addition.VehicleId != 0 || addition.SourceTerminusId != terminus.Id {
+		t.Errorf("Expected vehicle 0 to enter from terminus %v, found %v", terminus.Id, addition)
+	}
+}
+
+func TestVehiclesDespawnOnArrival(t *testing.T) {
+	provideTestMailboxes()
+	ctx := lifecycletest.NewSupervisor(t).Context()
+
+	manager := vehicle.NewVehicleManager()
+	line, destination := newTestTrafficLine(10)
+	line.vehicleManager = manager
+	destination.Id = line.highTerminus
+
+	// Vehicles arriving at their destination leave, even when the terminus is full
+	destination.occupancy[line.lowTerminus] = terminusCapacity
+	arriving, arrivingId := manager.NewVehicle()
+	arriving.Destination = destination.Id
+	line.lowToHighTraffic[arrivingId] = &progressingVehicle{vehicle: arriving, speed: line.GetSpeedLimit(), percent: 0.9}
+
+	for i := 0; i < 100 && len(line.lowToHighTraffic) != 0; i++ {
+		line.moveTraffic(ctx, line.lowToHighTraffic, 0.1, line.lowTerminus, destination, 1)
+	}
+
+	if len(line.lowToHighTraffic) != 0 || manager.Count() != 0 || len(destination.AddVehicleChannel) != 0 {
+		t.Errorf("The vehicle should have despawned at its destination, found %v vehicles", manager.Count())
+	}
+
+	// Vehicles leave at the edge of the map, wherever they are going
+	destination.isMapEdge.Store(true)
+	passing, passingId := manager.NewVehicle()
+	line.lowToHighTraffic[passingId] = &progressingVehicle{vehicle: passing, speed: line.GetSpeedLimit(), percent: 0.9}
+
+	for i := 0; i < 100 && len(line.lowToHighTraffic) != 0; i++ {
+		line.moveTraffic(ctx, line.lowToHighTraffic, 0.1, line.lowTerminus, destination, 1)
+	}
+
+	if len(line.lowToHighTraffic) != 0 || manager.Count() != 0 {
+		t.Errorf("The vehicle should have despawned at the edge of the map, found %v vehicles", manager.Count())
+	}
+}
+
+// Sets up terminus 0 of the test road graph to route vehicles, with a vehicle heading to terminus 1 waiting in it
+func newRoutingTerminus(t *testing.T, ctx context.Context, g *graph.Graph) *RoadTerminus {
+	terminus := g.GetNode(0).(*RoadTerminus)
+	terminus.Id = 0
+	terminus.router = NewRouter(lifecycletest.NewSupervisor(t), g)
+	for _, neighbor := range g.GetNeighbors(0) {
+		terminus.lines[neighbor.NodeId] = lineConnection{destinationId: neighbor.NodeId, line: neighbor.ConnectionData.(*RoadLine)}
+	}
+
+	car := vehicle.NewVehicle()
+	car.Destination = 1
+	terminus.addWaiting(ctx, VehicleAddition{VehicleId: 0, Vehicle: car, SourceTerminusId: 4})
+	return terminus
+}
+
+// Picks the line for the first waiting vehicle, waiting up to a second for the router to find its route
+func waitForLine(ctx context.Context, terminus *RoadTerminus) (*RoadLine, bool) {
+	line, ok := terminus.pickLine(ctx, terminus.waiting[0])
+	for deadline := time.Now().Add(time.Second); !ok && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+		line, ok = terminus.pickLine(ctx, terminus.waiting[0])
+	}
+
+	return line, ok
+}
+
+func TestTerminusRoutesToDestination(t *testing.T) {
+	provideTestMailboxes()
+	ctx := lifecycletest.NewSupervisor(t).Context()
+	g := newTestRoadGraph(t)
+	terminus := newRoutingTerminus(t, ctx, g)
+
+	// Arriving from the detour, the vehicle heads to terminus 1 rather than taking any other line.
+	// The vehicle waits without blocking the terminus until the router finds its route.
+	line, ok := waitForLine(ctx, terminus)
+	if !ok || line != terminus.lines[1].line {
+		t.Error("The vehicle should take the line to its destination")
+	}
+}
+
+func TestTerminusReroutesAroundRemovedLines(t *testing.T) {
+	provideTestMailboxes()
+	ctx := lifecycletest.NewSupervisor(t).Context()
+	g := newTestRoadGraph(t)
+	terminus := newRoutingTerminus(t, ctx, g)
+
+	if line, ok := waitForLine(ctx, terminus); !ok || line != terminus.lines[1].line {
+		t.Fatal("The vehicle should be routed along the line to its destination")
+	}
+
+	// The terminus loses the line before the router sees it removed, so the vehicle waits for a new route
+	delete(terminus.lines, 1)
+	if _, ok := terminus.pickLine(ctx, terminus.waiting[0]); ok {
+		t.Error("The vehicle should wait for a new route once its next line is removed")
+	}
+
+	g.DeleteConnection(0, 1)
+	line, ok := waitForLine(ctx, terminus)
+	if !ok || line != terminus.lines[3].line {
+		t.Error("The vehicle should be rerouted around the removed line, through terminus 3")
+	}
+}
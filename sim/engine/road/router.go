@@ -0,0 +1,308 @@
+package road
+
+import (
+	"container/heap"
+	"context"
+	"math"
+	"sim/config"
+	"sim/core/graph"
+	"sim/core/lifecycle"
+	"sim/core/mailroom"
+	"sim/engine/core/dto"
+)
+
+type RouteMetric int
+
+const (
+	Shortest RouteMetric = iota
+	Fastest
+)
+
+// Defines a route through the road grid.
+// Nodes are the road termini visited in order, Lines are the road lines between each terminus.
+type Route struct {
+	Found bool
+	Nodes []int64
+	Lines []int64
+	Cost  float32
+}
+
+type RouteQuery struct {
+	Start  int64
+	End    int64
+	Metric RouteMetric
+	Result chan Route
+}
+
+type routeKey struct {
+	start, end int64
+	metric     RouteMetric
+}
+
+// Fastest routes depend on congestion, so are only reused for this long, in sim seconds
+const fastestRouteLifetime float32 = 5.0
+
+type cachedRoute struct {
+	route   Route
+	simTime float32 // When the route was found
+}
+
+// Computes routes between road termini.
+// Shortest routes are cached until the road grid connections change, fastest routes for a few seconds, as congestion changes.
+type Router struct {
+	grid  *graph.Graph
+	cache map[routeKey]cachedRoute
+
+	// The current sim time
+	simTime float32
+
+	// The highest speed limit of any road, which bounds the A* heuristic.
+	// This is not lowered as roads are removed, as a higher bound still never overestimates route costs.
+	maxSpeedLimit float32
+
+	connectionEditChannel chan graph.ConnectionEdit
+	TimerUpdateChannel    chan dto.Time
+	RouteQueryChannel     chan RouteQuery
+}
+
+func NewRouter(supervisor *lifecycle.Supervisor, grid *graph.Graph) *Router {
+	router := Router{
+		grid:                  grid,
+		cache:                 make(map[routeKey]cachedRoute),
+		simTime:               0,
+		maxSpeedLimit:         max(getMaxSpeedLimit(grid), getMaxClassSpeedLimit()),
+		connectionEditChannel: make(chan graph.ConnectionEdit, 10),
+		TimerUpdateChannel:    make(chan dto.Time, 3),
+		RouteQueryChannel:     make(chan RouteQuery, 32)}
+
+	lifecycle.Send(supervisor.Context(), grid.ConnectionEditRegChannel, router.connectionEditChannel)
+	mailroom.CoreTimerRegChannel.Use("road.Router").SendContext(supervisor.Context(), router.TimerUpdateChannel)
+
+	supervisor.Go("road.Router", router.run)
+	return &router
+}
+
+func (r *Router) run(ctx context.Context) {
+	for {
+		select {
+		case edit := <-r.connectionEditChannel:
+			if edit.EditType == graph.Add || edit.EditType == graph.Delete {
+				r.cache = make(map[routeKey]cachedRoute)
+			}
+
+			if line, ok := edit.ConnectionData.(*RoadLine); ok && edit.EditType == graph.Add {
+				r.maxSpeedLimit = max(r.maxSpeedLimit, line.GetSpeedLimit())
+			}
+		case time := <-r.TimerUpdateChannel:
+			r.simTime = time.SimTime
+		case query := <-r.RouteQueryChannel:
+			query.Result <- r.getRoute(query.Start, query.End, query.Metric)
+			close(query.Result)
+		case _ = <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Gets a route from the cache, finding it if it is not cached or out of date
+func (r *Router) getRoute(start, end int64, metric RouteMetric) Route {
+	key := routeKey{start: start, end: end, metric: metric}
+	if cached, ok := r.cache[key]; ok && (metric == Shortest || r.simTime-cached.simTime < fastestRouteLifetime) {
+		return cached.route
+	}
+
+	route := FindRoute(r.grid, start, end, metric, r.maxSpeedLimit)
+	r.cache[key] = cachedRoute{route: route, simTime: r.simTime}
+	return route
+}
+
+func NewRouteQuery(start, end int64, metric RouteMetric) RouteQuery {
+	return RouteQuery{
+		Start:  start,
+		End:    end,
+		Metric: metric,
+		Result: make(chan Route, 1)}
+}
+
+// Finds a route, blocking until the router responds. Returns false if the router stopped first.
+func (r *Router) GetRoute(ctx context.Context, start, end int64, metric RouteMetric) (Route, bool) {
+	query := NewRouteQuery(start, end, metric)
+	if !lifecycle.Send(ctx, r.RouteQueryChannel, query) {
+		return Route{}, false
+	}
+
+	return lifecycle.Receive(ctx, query.Result)
+}
+
+// Asks for a route without waiting on the router, returning the channel the route is sent on once found.
+// Returns false if the router is busy or the context ended, in which case the route should be asked for again later.
+func (r *Router) RequestRoute(ctx context.Context, start, end int64, metric RouteMetric) (chan Route, bool) {
+	if ctx.Err() != nil {
+		return nil, false
+	}
+
+	query := NewRouteQuery(start, end, metric)
+	select {
+	case r.RouteQueryChannel <- query:
+		return query.Result, true
+	default:
+		return nil, false
+	}
+}
+
+// Computes the cost of traveling along a road line.
+// Fastest routes use the BPR (Bureau of Public Roads) travel time function,
+// which slows traffic down as the vehicle count approaches the line capacity.
+func getLineCost(line *RoadLine, metric RouteMetric) float32 {
+	if metric == Shortest {
+		return line.GetLength()
+	}
+
+	freeFlowTime := line.GetLength() / line.GetSpeedLimit()
+
+	capacity := float64(line.GetCapacity())
+	if capacity <= 0 {
+		capacity = 1
+	}
+
+	volumeRatio := float64(line.GetVehicleCount()) / capacity
+	return freeFlowTime * float32(1.0+0.15*math.Pow(volumeRatio, 4))
+}
+
+// Gets the highest speed limit of any road class, which is at least 1 unit / second
+func getMaxClassSpeedLimit() float32 {
+	maxSpeedLimit := float32(1)
+	for _, class := range config.Config.Road.RoadClasses {
+		maxSpeedLimit = max(maxSpeedLimit, class.SpeedLimit)
+	}
+
+	return maxSpeedLimit
+}
+
+// Gets the highest speed limit of any road in the grid, which is at least 1 unit / second
+func getMaxSpeedLimit(grid *graph.Graph) float32 {
+	maxSpeedLimit := float32(1)
+	for _, connectionId := range grid.GetConnectionIds() {
+		if line, ok := grid.GetConnection(connectionId).(*RoadLine); ok {
+			maxSpeedLimit = max(maxSpeedLimit, line.GetSpeedLimit())
+		}
+	}
+
+	return maxSpeedLimit
+}
+
+// Estimates the remaining cost between two termini, assuming travel at the highest speed limit.
+// Never overestimates, so A* remains optimal.
+func getHeuristicCost(from, to *RoadTerminus, metric RouteMetric, maxSpeedLimit float32) float32 {
+	distance := to.location.Sub(from.location).Len()
+	if metric == Shortest {
+		return distance
+	}
+
+	return distance / maxSpeedLimit
+}
+
+type routeNode struct {
+	nodeId        int64
+	costSoFar     float32
+	estimatedCost float32
+}
+
+type routeHeap []routeNode
+
+func (h routeHeap) Len() int            { return len(h) }
+func (h routeHeap) Less(i, j int) bool  { return h[i].estimatedCost < h[j].estimatedCost }
+func (h routeHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *routeHeap) Push(x interface{}) { *h = append(*h, x.(routeNode)) }
+func (h *routeHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// Finds the lowest-cost route between two road termini with A*.
+// The max speed limit must be at least that of any road, so the A* heuristic never overestimates.
+func FindRoute(grid *graph.Graph, start, end int64, metric RouteMetric, maxSpeedLimit float32) Route {
+	startData := grid.GetNode(start)
+	endData := grid.GetNode(end)
+	if startData == nil || endData == nil {
+		return Route{Found: false}
+	}
+
+	endTerminus := endData.(*RoadTerminus)
+
+	type routeStep struct {
+		previousNode int64
+		lineId       int64
+	}
+
+	bestCost := map[int64]float32{start: 0}
+	previousSteps := make(map[int64]routeStep)
+	closed := make(map[int64]bool)
+
+	pending := &routeHeap{}
+	heap.Push(pending, routeNode{
+		nodeId:        start,
+		costSoFar:     0,
+		estimatedCost: getHeuristicCost(startData.(*RoadTerminus), endTerminus, metric, maxSpeedLimit)})
+
+	for pending.Len() > 0 {
+		current := heap.Pop(pending).(routeNode)
+		if closed[current.nodeId] {
+			continue
+		}
+
+		closed[current.nodeId] = true
+		if current.nodeId == end {
+			break
+		}
+
+		for _, neighbor := range grid.GetNeighbors(current.nodeId) {
+			if closed[neighbor.NodeId] {
+				continue
+			}
+
+			neighborData := grid.GetNode(neighbor.NodeId)
+			if neighborData == nil {
+				continue
+			}
+
+			cost := current.costSoFar + getLineCost(neighbor.ConnectionData.(*RoadLine), metric)
+			if existingCost, ok := bestCost[neighbor.NodeId]; ok && existingCost <= cost {
+				continue
+			}
+
+			bestCost[neighbor.NodeId] = cost
+			previousSteps[neighbor.NodeId] = routeStep{previousNode: current.nodeId, lineId: neighbor.ConnectionId}
+			heap.Push(pending, routeNode{
+				nodeId:        neighbor.NodeId,
+				costSoFar:     cost,
+				estimatedCost: cost + getHeuristicCost(neighborData.(*RoadTerminus), endTerminus, metric, maxSpeedLimit)})
+		}
+	}
+
+	if !closed[end] {
+		return Route{Found: false}
+	}
+
+	// Walk backwards to build up the route
+	nodes := []int64{end}
+	lines := make([]int64, 0)
+	for nodeId := end; nodeId != start; {
+		step := previousSteps[nodeId]
+		nodes = append(nodes, step.previousNode)
+		lines = append(lines, step.lineId)
+		nodeId = step.previousNode
+	}
+
+	for i, j := 0, len(nodes)-1; i < j; i, j = i+1, j-1 {
+		nodes[i], nodes[j] = nodes[j], nodes[i]
+	}
+
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+
+	return Route{Found: true, Nodes: nodes, Lines: lines, Cost: bestCost[end]}
+}
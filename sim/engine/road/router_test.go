@@ -0,0 +1,186 @@
+package road
+
+import (
+	"context"
+	"sim/config"
+	"sim/core/graph"
+	"sim/core/lifecycle"
+	"sim/core/lifecycle/lifecycletest"
+	"testing"
+	"time"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Creates a two lane road class with the given capacity
+func newTestRoadClass(capacity int64) config.RoadClass {
+	return config.RoadClass{Name: "Test Road", Lanes: 2, SpeedLimit: 15, Capacity: capacity, CostPerUnit: 10, Upkeep: 1, RenderWidth: 8}
+}
+
+func addTestLine(g *graph.Graph, first, second int64, capacity int64) *RoadLine {
+	firstPos := g.GetNode(first).(*RoadTerminus).location
+	secondPos := g.GetNode(second).(*RoadTerminus).location
+
+	line := NewRoadLine(newTestRoadClass(capacity), secondPos.Sub(firstPos).Len())
+	line.Id = g.AddConnection(first, second, line).Id
+	return line
+}
+
+// Builds a square 0 (0, 0) - 1 (100, 0) - 2 (100, 100) - 3 (0, 100), with a long detour 0 - 4 - 2.
+func newTestRoadGraph(t *testing.T) *graph.Graph {
+	g := graph.NewGraph(lifecycletest.NewSupervisor(t))
+	for _, pos := range []mgl32.Vec2{{0, 0}, {100, 0}, {100, 100}, {0, 100}, {-300, 300}} {
+		g.AddNode(NewRoadTerminus(pos))
+	}
+
+	addTestLine(g, 0, 1, 10)
+	addTestLine(g, 1, 2, 10)
+	addTestLine(g, 2, 3, 10)
+	addTestLine(g, 3, 0, 10)
+	addTestLine(g, 0, 4, 10)
+	addTestLine(g, 4, 2, 10)
+	return g
+}
+
+func TestShortestRoute(t *testing.T) {
+	g := newTestRoadGraph(t)
+
+	route := FindRoute(g, 0, 2, Shortest, getMaxSpeedLimit(g))
+	if !route.Found {
+		t.Fatal("A route should exist from 0 to 2")
+	}
+
+	if len(route.Nodes) != 3 || route.Nodes[0] != 0 || route.Nodes[2] != 2 || len(route.Lines) != 2 {
+		t.Errorf("Unexpected route %v", route)
+	}
+
+	if route.Cost < 199.9 || route.Cost > 200.1 {
+		t.Errorf("Route should be 200 units long, was %v", route.Cost)
+	}
+}
+
+func TestFastestRouteAvoidsCongestion(t *testing.T) {
+	g := newTestRoadGraph(t)
+
+	g.GetConnection(0).(*RoadLine).vehicleCount.Store(50)
+	g.GetConnection(3).(*RoadLine).vehicleCount.Store(50)
+
+	route := FindRoute(g, 0, 2, Fastest, getMaxSpeedLimit(g))
+	if !route.Found || len(route.Nodes) != 3 || route.Nodes[1] != 4 {
+		t.Errorf("Congested lines should route through the detour, found %v", route)
+	}
+
+	if FindRoute(g, 0, 2, Shortest, getMaxSpeedLimit(g)).Nodes[1] == 4 {
+		t.Error("The shortest route should ignore congestion")
+	}
+}
+
+func TestFastestRouteUsesSpeedLimits(t *testing.T) {
+	g := newTestRoadGraph(t)
+
+	g.GetConnection(4).(*RoadLine).class.SpeedLimit = 100
+	g.GetConnection(5).(*RoadLine).class.SpeedLimit = 100
+
+	route := FindRoute(g, 0, 2, Fastest, getMaxSpeedLimit(g))
+	if !route.Found || len(route.Nodes) != 3 || route.Nodes[1] != 4 {
+		t.Errorf("The faster detour should be the fastest route, found %v", route)
+	}
+}
+
+func TestMissingRoute(t *testing.T) {
+	g := newTestRoadGraph(t)
+	g.AddNode(NewRoadTerminus(mgl32.Vec2{500, 500}))
+
+	if FindRoute(g, 0, 5, Shortest, getMaxSpeedLimit(g)).Found {
+		t.Error("Disconnected nodes have no route")
+	}
+
+	if FindRoute(g, 0, 42, Shortest, getMaxSpeedLimit(g)).Found {
+		t.Error("Missing nodes have no route")
+	}
+}
+
+// Gets routes from the router until one passes the check, as edits and timer updates reach the router asynchronously
+func waitForRoute(t *testing.T, router *Router, ctx context.Context, metric RouteMetric, check func(Route) bool) Route {
+	deadline := time.Now().Add(time.Second)
+	for {
+		route, ok := router.GetRoute(ctx, 0, 2, metric)
+		if !ok {
+			t.Fatal("The router should respond while running")
+		}
+
+		if check(route) || time.Now().After(deadline) {
+			return route
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRouterCachesFastestRoutes(t *testing.T) {
+	g := newTestRoadGraph(t)
+	router := &Router{grid: g, cache: make(map[routeKey]cachedRoute), maxSpeedLimit: getMaxSpeedLimit(g)}
+
+	route := router.getRoute(0, 2, Fastest)
+	if len(route.Nodes) != 3 || route.Nodes[1] == 4 {
+		t.Fatalf("The fastest route should go around the square, found %v", route)
+	}
+
+	// Congestion does not change the cached route until it expires
+	g.GetConnection(0).(*RoadLine).vehicleCount.Store(50)
+	g.GetConnection(3).(*RoadLine).vehicleCount.Store(50)
+	router.simTime = fastestRouteLifetime - 1
+	if cached := router.getRoute(0, 2, Fastest); cached.Nodes[1] != route.Nodes[1] {
+		t.Errorf("The cached route should be reused, found %v", cached)
+	}
+
+	router.simTime = fastestRouteLifetime
+	if route := router.getRoute(0, 2, Fastest); route.Nodes[1] != 4 {
+		t.Errorf("Once expired, the fastest route should avoid congestion, found %v", route)
+	}
+
+	// Shortest routes ignore congestion, so never expire
+	router.getRoute(0, 2, Shortest)
+	router.simTime = 1000
+	router.getRoute(0, 2, Shortest)
+	if cached := router.cache[routeKey{start: 0, end: 2, metric: Shortest}]; cached.simTime != fastestRouteLifetime {
+		t.Errorf("The shortest route should stay cached, found it was found again at %v", cached.simTime)
+	}
+}
+
+func TestRouterInvalidatesOnConnectionChanges(t *testing.T) {
+	provideTestMailboxes()
+	supervisor := lifecycletest.NewSupervisor(t)
+	g := newTestRoadGraph(t)
+	router := NewRouter(supervisor, g)
+
+	if route, _ := router.GetRoute(supervisor.Context(), 0, 2, Shortest); len(route.Nodes) != 3 {
+		t.Fatalf("The shortest route should go around the square, found %v", route)
+	}
+
+	line := addTestLine(g, 0, 2, 10)
+	route := waitForRoute(t, router, supervisor.Context(), Shortest, func(route Route) bool { return len(route.Nodes) == 2 })
+	if len(route.Nodes) != 2 {
+		t.Errorf("Adding a diagonal should shorten the cached route, found %v", route)
+	}
+
+	g.DeleteConnection(0, 2)
+	route = waitForRoute(t, router, supervisor.Context(), Shortest, func(route Route) bool { return len(route.Nodes) == 3 })
+	if len(route.Nodes) != 3 || route.Lines[0] == line.Id {
+		t.Errorf("Deleting the diagonal should restore the route around the square, found %v", route)
+	}
+}
+
+func TestRouterStopped(t *testing.T) {
+	provideTestMailboxes()
+	supervisor := lifecycle.NewSupervisor(context.Background())
+	router := NewRouter(supervisor, graph.NewGraph(supervisor))
+
+	if err := supervisor.Shutdown(time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := router.GetRoute(supervisor.Context(), 0, 1, Shortest); ok {
+		t.Error("Routes cannot be found once the router has stopped")
+	}
+}
@@ -0,0 +1,335 @@
+package road
+
+import (
+	"context"
+	"sim/core/lifecycle"
+	"sim/engine/core/dto"
+	"sim/engine/vehicle"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// The number of vehicles that fit in each lane of an approach to a terminus, waiting to cross it.
+// Lines leading into a full approach hold their vehicles, so queues spill back upstream.
+const terminusCapacity int64 = 2
+
+// Defines a change to the lines leaving a terminus. A nil line disconnects the line.
+type lineConnection struct {
+	destinationId int64
+	line          *RoadLine
+
+	// The normalized direction from the destination terminus to this terminus
+	approach mgl32.Vec2
+}
+
+type RoadTerminus struct {
+	location mgl32.Vec2
+
+	// Routes vehicles through the road grid to their destinations, or nil if vehicles are not routed
+	router *Router
+
+	// True if vehicles reaching the terminus leave the map. Readable from any goroutine.
+	isMapEdge atomic.Bool
+
+	// The lines leaving the terminus, by the terminus at their other end
+	lines map[int64]lineConnection
+
+	// Vehicles that have arrived and are waiting to cross, in the order they arrived
+	waiting    []*waitingVehicle
+	controller intersectionController
+
+	// The sim time of the last timer update, or negative before the first one
+	lastSimTime float32
+
+	// Counts waiting vehicles, and those reserved space by lines handing them off, by the terminus they approach from.
+	occupancyLock sync.Mutex
+	occupancy     map[int64]int64
+
+	Id                    int64
+	AddVehicleChannel     chan VehicleAddition
+	TimerUpdateChannel    chan dto.Time
+	lineConnectionChannel chan lineConnection
+	controlChannel        chan IntersectionControl
+	handOffChannel        chan chan []int64
+
+	stop context.CancelFunc
+}
+
+func NewRoadTerminus(location mgl32.Vec2) *RoadTerminus {
+	terminus := RoadTerminus{
+		location:              location,
+		lines:                 make(map[int64]lineConnection),
+		waiting:               make([]*waitingVehicle, 0),
+		controller:            newIntersectionController(Uncontrolled),
+		lastSimTime:           -1,
+		occupancy:             make(map[int64]int64),
+		AddVehicleChannel:     make(chan VehicleAddition, 3),
+		TimerUpdateChannel:    make(chan dto.Time, 3),
+		lineConnectionChannel: make(chan lineConnection),
+		controlChannel:        make(chan IntersectionControl),
+		handOffChannel:        make(chan chan []int64)}
+
+	return &terminus
+}
+
+// Gets positions on the map that can be used to snap to the terminus
+func (r *RoadTerminus) GetSnapNodes() []mgl32.Vec2 {
+	return []mgl32.Vec2{r.location}
+}
+
+// Gets the number of vehicles waiting in the terminus to cross it
+func (r *RoadTerminus) GetWaitingCount() int64 {
+	r.occupancyLock.Lock()
+	defer r.occupancyLock.Unlock()
+
+	count := int64(0)
+	for _, occupancy := range r.occupancy {
+		count += occupancy
+	}
+
+	return count
+}
+
+// Returns true if the terminus has space for another vehicle approaching from the source terminus on a line with the given lanes
+func (r *RoadTerminus) hasSpace(sourceTerminusId, lanes int64) bool {
+	r.occupancyLock.Lock()
+	defer r.occupancyLock.Unlock()
+	return r.occupancy[sourceTerminusId] < terminusCapacity*lanes
+}
+
+// Reserves space for a vehicle about to be sent to the terminus, returning false if its approach is full
+func (r *RoadTerminus) reserveSpace(sourceTerminusId, lanes int64) bool {
+	r.occupancyLock.Lock()
+	defer r.occupancyLock.Unlock()
+
+	if r.occupancy[sourceTerminusId] >= terminusCapacity*lanes {
+		return false
+	}
+
+	r.occupancy[sourceTerminusId]++
+	return true
+}
+
+func (r *RoadTerminus) releaseSpace(sourceTerminusId int64) {
+	r.occupancyLock.Lock()
+	defer r.occupancyLock.Unlock()
+
+	r.occupancy[sourceTerminusId]--
+	if r.occupancy[sourceTerminusId] <= 0 {
+		delete(r.occupancy, sourceTerminusId)
+	}
+}
+
+// Returns true if the vehicle leaves the simulation on reaching the terminus, at its destination or the edge of the map
+func (r *RoadTerminus) isExit(vehicle *vehicle.Vehicle) bool {
+	return vehicle.Destination == r.Id || r.isMapEdge.Load()
+}
+
+// Stops the road terminus' goroutine, if it was started
+func (r *RoadTerminus) Stop() {
+	if r.stop != nil {
+		r.stop()
+	}
+}
+
+// Stops the terminus' goroutine, returning the IDs of the vehicles that were waiting in it.
+// Returns false if the terminus stopped first.
+func (r *RoadTerminus) handOffWaiting(ctx context.Context) ([]int64, bool) {
+	result := make(chan []int64)
+	if !lifecycle.Send(ctx, r.handOffChannel, result) {
+		return nil, false
+	}
+
+	return lifecycle.Receive(ctx, result)
+}
+
+// Connects the terminus to a line leading to the destination terminus, or disconnects it if the line is nil
+func (r *RoadTerminus) connectLine(ctx context.Context, destinationId int64, destination mgl32.Vec2, line *RoadLine) {
+	approach := r.location.Sub(destination)
+	if approach.Len() > 0 {
+		approach = approach.Normalize()
+	}
+
+	lifecycle.Send(ctx, r.lineConnectionChannel, lineConnection{destinationId: destinationId, line: line, approach: approach})
+}
+
+// Changes how vehicles take turns crossing the terminus
+func (r *RoadTerminus) setControl(ctx context.Context, control IntersectionControl) bool {
+	return lifecycle.Send(ctx, r.controlChannel, control)
+}
+
+// Starts looking up the fastest route from the terminus to the vehicle's destination, if it has one
+func (r *RoadTerminus) planRoute(ctx context.Context, vehicle *waitingVehicle) {
+	destination := vehicle.addition.Vehicle.Destination
+	vehicle.nextTerminusId = -1
+	vehicle.isRouting = r.router != nil && destination != -1 && destination != r.Id
+	vehicle.routeResult = nil
+	r.updateRoute(ctx, vehicle)
+}
+
+// Checks on the lookup of a vehicle's route without waiting on the router, asking again if the router was busy.
+// Returns true once the route is known, with the vehicle's next terminus set to -1 if its destination cannot be reached.
+func (r *RoadTerminus) updateRoute(ctx context.Context, vehicle *waitingVehicle) bool {
+	if !vehicle.isRouting {
+		return true
+	}
+
+	if vehicle.routeResult == nil {
+		result, ok := r.router.RequestRoute(ctx, r.Id, vehicle.addition.Vehicle.Destination, Fastest)
+		if !ok {
+			return false
+		}
+
+		vehicle.routeResult = result
+	}
+
+	select {
+	case route := <-vehicle.routeResult:
+		vehicle.isRouting = false
+		vehicle.routeResult = nil
+		if route.Found && len(route.Nodes) >= 2 {
+			vehicle.nextTerminusId = route.Nodes[1]
+		}
+
+		return true
+	default:
+		return false
+	}
+}
+
+// Picks the line a vehicle leaves on, following the route to its destination.
+// Vehicles wait while their route is looked up. Vehicles without a route take any line other than the one they arrived from.
+func (r *RoadTerminus) pickLine(ctx context.Context, vehicle *waitingVehicle) (*RoadLine, bool) {
+	if !r.updateRoute(ctx, vehicle) {
+		return nil, false
+	}
+
+	if connection, ok := r.lines[vehicle.nextTerminusId]; ok {
+		return connection.line, true
+	}
+
+	// The route is out of date if the line leading along it was removed, so the vehicle waits for a new one.
+	// The router may not have seen the removal yet, so the new route is only checked on the next update.
+	if vehicle.nextTerminusId != -1 {
+		r.planRoute(ctx, vehicle)
+		return nil, false
+	}
+
+	for destinationId, connection := range r.lines {
+		if destinationId != vehicle.addition.SourceTerminusId {
+			return connection.line, true
+		}
+	}
+
+	// The vehicle has no where else to go so it bounces to the first result
+	for _, connection := range r.lines {
+		return connection.line, true
+	}
+
+	return nil, false
+}
+
+// Adds a vehicle arriving at the terminus to those waiting to cross it
+func (r *RoadTerminus) addWaiting(ctx context.Context, addition VehicleAddition) {
+	vehicle := waitingVehicle{addition: addition, waitTime: 0}
+	if connection, ok := r.lines[addition.SourceTerminusId]; ok {
+		vehicle.approach = connection.approach
+	}
+
+	r.planRoute(ctx, &vehicle)
+
+	r.waiting = append(r.waiting, &vehicle)
+}
+
+// Sends waiting vehicles the controller lets cross on to their next line, in the order they arrived, at most one per line per update.
+// Vehicles stop when they cannot cross, holding up those behind them from the same approach.
+func (r *RoadTerminus) crossWaiting(ctx context.Context, elapsed float32) {
+	r.controller.update(elapsed)
+
+	entered := make(map[*RoadLine]bool)
+	blockedApproaches := make(map[int64]bool)
+	stillWaiting := make([]*waitingVehicle, 0, len(r.waiting))
+	for _, vehicle := range r.waiting {
+		vehicle.waitTime += elapsed
+		sourceTerminusId := vehicle.addition.SourceTerminusId
+		if blockedApproaches[sourceTerminusId] || !r.tryCross(ctx, vehicle, entered) {
+			vehicle.addition.Speed = 0
+			blockedApproaches[sourceTerminusId] = true
+			stillWaiting = append(stillWaiting, vehicle)
+		}
+	}
+
+	r.waiting = stillWaiting
+}
+
+// Sends a waiting vehicle on to its next line if the controller lets it cross and the line has space, returning true if it crossed
+func (r *RoadTerminus) tryCross(ctx context.Context, vehicle *waitingVehicle, entered map[*RoadLine]bool) bool {
+	line, ok := r.pickLine(ctx, vehicle)
+	if !ok || entered[line] || !line.HasEntrySpace(r.Id) || !r.controller.canCross(vehicle) {
+		return false
+	}
+
+	// Lines are never waited on, as they may be waiting on this terminus
+	select {
+	case line.AddVehicleChannel <- VehicleAddition{
+		VehicleId:        vehicle.addition.VehicleId,
+		Vehicle:          vehicle.addition.Vehicle,
+		Speed:            vehicle.addition.Speed,
+		SourceTerminusId: r.Id}:
+	default:
+		return false
+	}
+
+	entered[line] = true
+	r.controller.crossed()
+	r.releaseSpace(vehicle.addition.SourceTerminusId)
+	return true
+}
+
+func (r *RoadTerminus) run(ctx context.Context) {
+	for {
+		select {
+		case connection := <-r.lineConnectionChannel:
+			if connection.line == nil {
+				delete(r.lines, connection.destinationId)
+			} else {
+				r.lines[connection.destinationId] = connection
+			}
+		case control := <-r.controlChannel:
+			r.controller = newIntersectionController(control)
+		case vehicle := <-r.AddVehicleChannel:
+			// Vehicles cross the terminus on the next update, or wait until they can
+			r.addWaiting(ctx, vehicle)
+		case time := <-r.TimerUpdateChannel:
+			elapsed := float32(0)
+			if r.lastSimTime >= 0 {
+				elapsed = time.SimTime - r.lastSimTime
+			}
+
+			r.lastSimTime = time.SimTime
+			r.crossWaiting(ctx, elapsed)
+		case result := <-r.handOffChannel:
+			for drained := false; !drained; {
+				select {
+				case vehicle := <-r.AddVehicleChannel:
+					r.addWaiting(ctx, vehicle)
+				default:
+					drained = true
+				}
+			}
+
+			vehicleIds := make([]int64, len(r.waiting))
+			for idx, vehicle := range r.waiting {
+				vehicleIds[idx] = vehicle.addition.VehicleId
+			}
+
+			result <- vehicleIds
+			close(result)
+			return
+		case _ = <-ctx.Done():
+			return
+		}
+	}
+}
@@ -2,8 +2,11 @@ package engine
 
 import (
 	"common/commonmath"
+	"context"
+	"math"
 	"sim/config"
 	"sim/core/dto/editorengdto"
+	"sim/core/lifecycle"
 	"sim/core/mailroom"
 	"sim/engine/finder"
 
@@ -14,6 +17,8 @@ type Snap struct {
 	snappedToNode    bool
 	lastSnapPosition mgl32.Vec2
 	lastSnapId       int64
+	lastSnapLineId   int64
+	lastBoardPos     mgl32.Vec2
 
 	elementFinder *finder.ElementFinder
 
@@ -27,12 +32,25 @@ type Snap struct {
 	snapToAngle    bool
 	snapToElements bool
 
+	anchor SnapAnchor
+
 	editorModeChannel     chan editorengdto.EditorMode
 	editorAddModeChannel  chan editorengdto.EditorAddMode
 	editorDrawModeChannel chan editorengdto.EditorDrawMode
 	snapSettingsChannel   chan editorengdto.SnapSetting
 
 	SnapQueryChannel chan SnapQuery
+	AnchorChannel    chan SnapAnchor
+}
+
+// Defines the node new line segments are drawn from, which angles are snapped around
+type SnapAnchor struct {
+	HasAnchor bool
+	Position  mgl32.Vec2
+
+	// The normalized direction of the segment drawn into the anchor, if any
+	HasPreviousDirection bool
+	PreviousDirection    mgl32.Vec2
 }
 
 type SnapQuery struct {
@@ -43,13 +61,18 @@ type SnapResult struct {
 	IsItemSnapped bool
 	Id            int64
 	Position      mgl32.Vec2
+
+	// If not -1, the position is partway along this line, which must be split to connect to it
+	LineId int64
 }
 
-func NewSnap(elementFinder *finder.ElementFinder) *Snap {
+func NewSnap(supervisor *lifecycle.Supervisor, elementFinder *finder.ElementFinder) *Snap {
 	s := Snap{
 		snappedToNode:         false,
 		lastSnapId:            -1,
+		lastSnapLineId:        -1,
 		lastSnapPosition:      mgl32.Vec2{0, 0},
+		lastBoardPos:          mgl32.Vec2{0, 0},
 		elementFinder:         elementFinder,
 		mouseBoardPosChannel:  make(chan mgl32.Vec2, 10),
 		editorMode:            editorengdto.Select,
@@ -58,37 +81,104 @@ func NewSnap(elementFinder *finder.ElementFinder) *Snap {
 		snapToGrid:            false,
 		snapToAngle:           false,
 		snapToElements:        true,
+		anchor:                SnapAnchor{HasAnchor: false},
 		editorModeChannel:     make(chan editorengdto.EditorMode),
 		editorAddModeChannel:  make(chan editorengdto.EditorAddMode),
 		editorDrawModeChannel: make(chan editorengdto.EditorDrawMode),
 		snapSettingsChannel:   make(chan editorengdto.SnapSetting),
-		SnapQueryChannel:      make(chan SnapQuery, 3)}
+		SnapQueryChannel:      make(chan SnapQuery, 3),
+		AnchorChannel:         make(chan SnapAnchor)}
 
-	mailroom.BoardPosChangeRegChannel <- s.mouseBoardPosChannel
-	mailroom.EngineModeRegChannel <- s.editorModeChannel
-	mailroom.EngineAddModeRegChannel <- s.editorAddModeChannel
-	mailroom.EngineDrawModeRegChannel <- s.editorDrawModeChannel
-	mailroom.SnapSettingsRegChannel <- s.snapSettingsChannel
+	mailroom.BoardPosChangeRegChannel.Use("engine.Snap").Send(s.mouseBoardPosChannel)
+	mailroom.EngineModeRegChannel.Use("engine.Snap").Send(s.editorModeChannel)
+	mailroom.EngineAddModeRegChannel.Use("engine.Snap").Send(s.editorAddModeChannel)
+	mailroom.EngineDrawModeRegChannel.Use("engine.Snap").Send(s.editorDrawModeChannel)
+	mailroom.SnapSettingsRegChannel.Use("engine.Snap").Send(s.snapSettingsChannel)
+	mailroom.SnappedNodesUpdateChannel.Use("engine.Snap")
 
-	go s.run()
+	supervisor.Go("engine.Snap", s.run)
 	return &s
 }
 
-func (s *Snap) computeSnaps(boardPos mgl32.Vec2) {
+// Locks the position to the nearest angle around the anchor that is a multiple of the division from the reference angle.
+// The distance along that angle is the projected distance of the position, rounded to the length resolution if non-zero.
+func snapToAngle(anchor, pos mgl32.Vec2, referenceAngle, divisionDegrees, lengthResolution float32) mgl32.Vec2 {
+	offset := pos.Sub(anchor)
+	if offset.Len() == 0 || divisionDegrees <= 0 {
+		return pos
+	}
+
+	division := float64(mgl32.DegToRad(divisionDegrees))
+	angle := math.Atan2(float64(offset.Y()), float64(offset.X())) - float64(referenceAngle)
+	snappedAngle := math.Round(angle/division)*division + float64(referenceAngle)
+
+	direction := mgl32.Vec2{float32(math.Cos(snappedAngle)), float32(math.Sin(snappedAngle))}
+	length := offset.Dot(direction)
+	if lengthResolution > 0 {
+		length = float32(math.Round(float64(length/lengthResolution))) * lengthResolution
+	}
+
+	return anchor.Add(direction.Mul(length))
+}
+
+// Snaps to the nearest edge of a line, or of an element lines can connect to
+func (s *Snap) snapToEdges(ctx context.Context, boardPos mgl32.Vec2) (mgl32.Vec2, bool) {
+	lineType := finder.RoadLine
+	edgeTypes := []finder.ItemType{finder.RoadLine}
+	if s.editorAddMode == editorengdto.PowerLine {
+		lineType = finder.PowerLine
+		edgeTypes = []finder.ItemType{finder.PowerLine, finder.PowerPlant}
+	}
+
+	results := make(chan []*finder.EdgeWithDistance)
+	lifecycle.Send(ctx, s.elementFinder.EdgeSearchChannel, finder.NewNearestEdgesQuery(boardPos, edgeTypes, config.Config.Draw.MinSnapNodeDistance, results))
+	edges, _ := lifecycle.Receive(ctx, results)
+	if len(edges) == 0 {
+		return boardPos, false
+	}
+
+	// Lines are split to connect partway along them, but other elements are connected to directly.
+	s.lastSnapId = edges[0].Id
+	s.lastSnapLineId = -1
+	if edges[0].Type == lineType {
+		s.lastSnapId = -1
+		s.lastSnapLineId = edges[0].Id
+	}
+
+	s.lastSnapPosition = edges[0].Pos
+	s.snappedToNode = true
+	return edges[0].Pos, true
+}
+
+// Computes where the cursor snaps to. In priority order, the cursor snaps:
+// 1. To nearby elements, so that new lines connect to existing ones.
+// 2. To nearby edges of elements, so that new lines can connect partway along existing ones.
+// 3. To angles around the anchor, when drawing a line from it. If grid snapping is on, it sets the length resolution.
+// 4. To the grid.
+func (s *Snap) computeSnaps(ctx context.Context, boardPos mgl32.Vec2) {
+	s.lastBoardPos = boardPos
 	displayedSnappedNodes := make([]mgl32.Vec2, 0)
 
-	s.snappedToNode = false
-	if s.snapToElements && s.editorMode == editorengdto.Add &&
-		s.editorAddMode == editorengdto.PowerLine || s.editorAddMode == editorengdto.RoadLine {
+	isDrawingLine := s.editorMode == editorengdto.Add &&
+		(s.editorAddMode == editorengdto.PowerLine || s.editorAddMode == editorengdto.RoadLine)
 
-		itemType := finder.RoadTerminus
+	s.snappedToNode = false
+	s.lastSnapLineId = -1
+	if s.snapToElements && isDrawingLine {
+		// Powerlines can also connect directly to power plants
+		itemTypes := []finder.ItemType{finder.RoadTerminus}
 		if s.editorAddMode == editorengdto.PowerLine {
-			itemType = finder.PowerTerminus
+			itemTypes = []finder.ItemType{finder.PowerTerminus, finder.PowerPlant}
 		}
 
 		results := make(chan []*finder.NodeWithDistance)
-		s.elementFinder.KNearestSearchChannel <- finder.NewKNNQuery(boardPos, itemType, config.Config.Draw.SnapNodeCount, results)
-		elements := <-results
+		query := finder.KNearestNodesQuery{
+			Pos:     boardPos,
+			Types:   itemTypes,
+			Count:   config.Config.Draw.SnapNodeCount,
+			Results: results}
+		lifecycle.Send(ctx, s.elementFinder.KNearestSearchChannel, query)
+		elements, _ := lifecycle.Receive(ctx, results)
 		for _, elem := range elements {
 			if elem.Distance < config.Config.Draw.MinSnapNodeDistance {
 				if !s.snappedToNode {
@@ -100,10 +190,33 @@ func (s *Snap) computeSnaps(boardPos mgl32.Vec2) {
 				displayedSnappedNodes = append(displayedSnappedNodes, elem.Pos)
 			}
 		}
+
+		if !s.snappedToNode {
+			if edgePos, ok := s.snapToEdges(ctx, boardPos); ok {
+				displayedSnappedNodes = append(displayedSnappedNodes, edgePos)
+			}
+		}
 	}
 
-	if s.snapToGrid {
-		snapGridResolution := float32(config.Config.Snap.SnapGridResolution)
+	snapGridResolution := float32(config.Config.Snap.SnapGridResolution)
+	if s.snapToAngle && isDrawingLine && s.anchor.HasAnchor && !s.snappedToNode {
+		referenceAngle := float32(0)
+		if config.Config.Snap.SnapAngleToPreviousSegment && s.anchor.HasPreviousDirection {
+			referenceAngle = float32(math.Atan2(float64(s.anchor.PreviousDirection.Y()), float64(s.anchor.PreviousDirection.X())))
+		}
+
+		lengthResolution := float32(0)
+		if s.snapToGrid {
+			lengthResolution = snapGridResolution
+		}
+
+		elementPos := snapToAngle(s.anchor.Position, boardPos, referenceAngle, float32(config.Config.Snap.SnapAngleDivision), lengthResolution)
+
+		displayedSnappedNodes = append(displayedSnappedNodes, elementPos)
+		s.lastSnapId = -1
+		s.lastSnapPosition = elementPos
+		s.snappedToNode = true
+	} else if s.snapToGrid {
 		offsetBoardPos := boardPos.Add(mgl32.Vec2{snapGridResolution / 2, snapGridResolution / 2})
 		snappedIntPosition := commonMath.IntVec2{int(offsetBoardPos.X() / snapGridResolution), int(offsetBoardPos.Y() / snapGridResolution)}
 		elementPos := mgl32.Vec2{float32(snappedIntPosition.X()), float32(snappedIntPosition.Y())}.Mul(snapGridResolution)
@@ -117,18 +230,19 @@ func (s *Snap) computeSnaps(boardPos mgl32.Vec2) {
 	}
 
 	// Send to be rendered.
-	mailroom.SnappedNodesUpdateChannel <- displayedSnappedNodes
+	mailroom.SnappedNodesUpdateChannel.SendContext(ctx, displayedSnappedNodes)
 }
 
-func (s *Snap) run() {
+func (s *Snap) run(ctx context.Context) {
 	for {
 		select {
 		case boardPos := <-s.mouseBoardPosChannel:
 			// TODO drain to the last position update
-			s.computeSnaps(boardPos)
+			s.computeSnaps(ctx, boardPos)
 		case s.editorMode = <-s.editorModeChannel:
 		case s.editorAddMode = <-s.editorAddModeChannel:
 			s.snappedToNode = false
+		case s.anchor = <-s.AnchorChannel:
 		case s.editorDrawMode = <-s.editorDrawModeChannel:
 		case snapSetting := <-s.snapSettingsChannel:
 			switch snapSetting.Setting {
@@ -141,11 +255,16 @@ func (s *Snap) run() {
 			default:
 			}
 		case query := <-s.SnapQueryChannel:
+			// Elements may have been removed or moved since the mouse last moved, so snap against the live finder.
+			s.computeSnaps(ctx, s.lastBoardPos)
 			query.Result <- SnapResult{
 				IsItemSnapped: s.snappedToNode,
 				Id:            s.lastSnapId,
+				LineId:        s.lastSnapLineId,
 				Position:      s.lastSnapPosition}
 			close(query.Result)
+		case _ = <-ctx.Done():
+			return
 		}
 	}
 }
@@ -0,0 +1,40 @@
+package engine
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func checkSnappedPosition(t *testing.T, name string, actual, expected mgl32.Vec2) {
+	if actual.Sub(expected).Len() > 0.001 {
+		t.Errorf("%v: expected %v, found %v", name, expected, actual)
+	}
+}
+
+func TestSnapToAngle(t *testing.T) {
+	anchor := mgl32.Vec2{10, 10}
+
+	checkSnappedPosition(t, "Near the X axis", snapToAngle(anchor, mgl32.Vec2{20, 11}, 0, 45, 0), mgl32.Vec2{20, 10})
+	checkSnappedPosition(t, "Near the Y axis", snapToAngle(anchor, mgl32.Vec2{9, 30}, 0, 45, 0), mgl32.Vec2{10, 30})
+	checkSnappedPosition(t, "Near a diagonal", snapToAngle(anchor, mgl32.Vec2{0, 1}, 0, 45, 0), mgl32.Vec2{0.5, 0.5})
+	checkSnappedPosition(t, "Negative angles", snapToAngle(anchor, mgl32.Vec2{30, 9}, 0, 45, 0), mgl32.Vec2{30, 10})
+	checkSnappedPosition(t, "At the anchor", snapToAngle(anchor, anchor, 0, 45, 0), anchor)
+}
+
+func TestSnapToAngleRelative(t *testing.T) {
+	// Relative to a 30 degree segment, 45 degree divisions are at 30, 75, -15, ... degrees
+	reference := float32(math.Pi / 6)
+	pos := snapToAngle(mgl32.Vec2{0, 0}, mgl32.Vec2{10, 6}, reference, 45, 0)
+
+	angle := math.Atan2(float64(pos.Y()), float64(pos.X()))
+	if math.Abs(angle-math.Pi/6) > 0.001 {
+		t.Errorf("Expected the position to snap to the previous segment direction, found %v radians", angle)
+	}
+}
+
+func TestSnapToAngleLengthResolution(t *testing.T) {
+	checkSnappedPosition(t, "Rounded length", snapToAngle(mgl32.Vec2{0, 0}, mgl32.Vec2{17, 1}, 0, 90, 10), mgl32.Vec2{20, 0})
+	checkSnappedPosition(t, "Rounded diagonal length", snapToAngle(mgl32.Vec2{0, 0}, mgl32.Vec2{7.2, 7}, 0, 45, 10), mgl32.Vec2{7.0711, 7.0711})
+}
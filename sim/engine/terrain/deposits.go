@@ -0,0 +1,14 @@
+package terrain
+
+import "github.com/go-gl/mathgl/mgl32"
+
+// Geothermal deposits are where low-frequency noise, offset away from the height noise, is high.
+// TODO: Configurable
+const geothermalScale float32 = 300
+const geothermalThreshold float32 = 0.45
+const geothermalOffset int = 1000000
+
+// Returns true if the position is on a geothermal deposit
+func IsGeothermalDeposit(pos mgl32.Vec2) bool {
+	return getNoise(int(pos.X())+geothermalOffset, int(pos.Y())+geothermalOffset, geothermalScale) > geothermalThreshold
+}
@@ -2,9 +2,13 @@ package terrain
 
 import (
 	"common/commonmath"
+	"context"
+	"math"
 	"sim/config"
+	"sim/core/broadcast"
 	"sim/core/dto/terraindto"
 	"sim/core/gamegrid"
+	"sim/core/lifecycle"
 	"sim/core/mailroom"
 	"sim/engine/subtile"
 
@@ -14,38 +18,62 @@ import (
 var FORCE_REFRESH int = 2
 
 type TerrainMap struct {
-	hasDoneFirstTimePopulation   bool
-	cameraOffset                 mgl32.Vec2
-	cameraScale                  float32
-	offsetChangeChannel          chan mgl32.Vec2
-	scaleChangeChannel           chan float32
-	registeredNewTerrainChannels []chan *terraindto.TerrainUpdate
-	registeredNewRegionChannels  []chan commonMath.IntVec2
+	hasDoneFirstTimePopulation bool
+	cameraOffset               mgl32.Vec2
+	cameraScale                float32
+	offsetChangeChannel        chan mgl32.Vec2
+	scaleChangeChannel         chan float32
+	newTerrains                *broadcast.Broadcaster[*terraindto.TerrainUpdate]
+	newRegions                 *broadcast.Broadcaster[commonMath.IntVec2]
 
-	SubMaps              map[int]map[int]*terraindto.TerrainSubMap
-	NewTerrainRegChannel chan chan *terraindto.TerrainUpdate
-	NewRegionRegChannel  chan chan commonMath.IntVec2
-	ControlChannel       chan int
+	SubMaps                 map[int]map[int]*terraindto.TerrainSubMap
+	NewTerrainRegChannel    chan chan *terraindto.TerrainUpdate
+	NewRegionRegChannel     chan chan commonMath.IntVec2
+	GroundValidationChannel chan GroundValidationQuery
+	WaterProximityChannel   chan WaterProximityQuery
+	LineTerrainChannel      chan LineTerrainQuery
 }
 
-func NewTerrainMap() *TerrainMap {
+// Defines a query to check if a region is entirely on buildable ground
+type GroundValidationQuery struct {
+	Region commonMath.Region
+	Result chan bool
+}
+
+// Defines a query to check if there is water within a distance of a position
+type WaterProximityQuery struct {
+	Position mgl32.Vec2
+	Distance float32
+	Result   chan bool
+}
+
+// Defines a query for the length of a line crossing each terrain type
+type LineTerrainQuery struct {
+	Start  mgl32.Vec2
+	End    mgl32.Vec2
+	Result chan map[terraindto.TerrainType]float32
+}
+
+func NewTerrainMap(supervisor *lifecycle.Supervisor) *TerrainMap {
 	terrainMap := TerrainMap{
-		hasDoneFirstTimePopulation:   false,
-		cameraOffset:                 mgl32.Vec2{0, 0},
-		cameraScale:                  1.0,
-		offsetChangeChannel:          make(chan mgl32.Vec2, 3),
-		scaleChangeChannel:           make(chan float32, 3),
-		registeredNewTerrainChannels: make([]chan *terraindto.TerrainUpdate, 0),
-		registeredNewRegionChannels:  make([]chan commonMath.IntVec2, 0),
-		NewTerrainRegChannel:         make(chan chan *terraindto.TerrainUpdate),
-		NewRegionRegChannel:          make(chan chan commonMath.IntVec2),
-		ControlChannel:               make(chan int),
-		SubMaps:                      make(map[int]map[int]*terraindto.TerrainSubMap)}
-
-	mailroom.CameraOffsetRegChannel <- terrainMap.offsetChangeChannel
-	mailroom.CameraScaleRegChannel <- terrainMap.scaleChangeChannel
-
-	go terrainMap.run()
+		hasDoneFirstTimePopulation: false,
+		cameraOffset:               mgl32.Vec2{0, 0},
+		cameraScale:                1.0,
+		offsetChangeChannel:        make(chan mgl32.Vec2, 3),
+		scaleChangeChannel:         make(chan float32, 3),
+		newTerrains:                broadcast.NewBroadcaster[*terraindto.TerrainUpdate]("New terrain", broadcast.BlockWithTimeout, broadcast.DefaultTimeout),
+		newRegions:                 broadcast.NewBroadcaster[commonMath.IntVec2]("New regions", broadcast.BlockWithTimeout, broadcast.DefaultTimeout),
+		NewTerrainRegChannel:       make(chan chan *terraindto.TerrainUpdate),
+		NewRegionRegChannel:        make(chan chan commonMath.IntVec2),
+		GroundValidationChannel:    make(chan GroundValidationQuery),
+		WaterProximityChannel:      make(chan WaterProximityQuery),
+		LineTerrainChannel:         make(chan LineTerrainQuery),
+		SubMaps:                    make(map[int]map[int]*terraindto.TerrainSubMap)}
+
+	mailroom.CameraOffsetRegChannel.Use("terrain.TerrainMap").Send(terrainMap.offsetChangeChannel)
+	mailroom.CameraScaleRegChannel.Use("terrain.TerrainMap").Send(terrainMap.scaleChangeChannel)
+
+	supervisor.Go("terrain.TerrainMap", terrainMap.run)
 
 	return &terrainMap
 }
@@ -65,7 +93,7 @@ func (t *TerrainMap) precacheRegions() {
 	}
 }
 
-func (t *TerrainMap) run() {
+func (t *TerrainMap) run(ctx context.Context) {
 	for {
 		select {
 		case t.cameraOffset = <-t.offsetChangeChannel:
@@ -75,11 +103,20 @@ func (t *TerrainMap) run() {
 			t.precacheRegions()
 			break
 		case reg := <-t.NewTerrainRegChannel:
-			t.registeredNewTerrainChannels = append(t.registeredNewTerrainChannels, reg)
+			t.newTerrains.Register(reg)
 			break
 		case reg := <-t.NewRegionRegChannel:
-			t.registeredNewRegionChannels = append(t.registeredNewRegionChannels, reg)
-		case _ = <-t.ControlChannel:
+			t.newRegions.Register(reg)
+		case query := <-t.GroundValidationChannel:
+			query.Result <- t.ValidateGroundLocation(query.Region)
+			close(query.Result)
+		case query := <-t.WaterProximityChannel:
+			query.Result <- t.HasWaterNearby(query.Position, query.Distance)
+			close(query.Result)
+		case query := <-t.LineTerrainChannel:
+			query.Result <- t.GetTerrainAlongLine(query.Start, query.End)
+			close(query.Result)
+		case _ = <-ctx.Done():
 			return
 		}
 	}
@@ -93,18 +130,15 @@ func (t *TerrainMap) GetOrAddRegion(x, y int) *terraindto.TerrainSubMap {
 	if _, ok := t.SubMaps[x][y]; !ok {
 		t.SubMaps[x][y] = terraindto.NewTerrainSubMap(x, y, Generate)
 		terrainUpdate := terraindto.NewTerrainUpdate(t.SubMaps[x][y], x, y)
-		for _, reg := range t.registeredNewTerrainChannels {
-			reg <- terrainUpdate
-		}
+		t.newTerrains.Send(terrainUpdate)
 
-		for _, reg := range t.registeredNewRegionChannels {
-			reg <- commonMath.IntVec2{x, y}
-		}
+		t.newRegions.Send(commonMath.IntVec2{x, y})
 	}
 
 	return t.SubMaps[x][y]
 }
 
+// Returns true if no part of the region is on water
 func (t *TerrainMap) ValidateGroundLocation(reg commonMath.Region) bool {
 
 	iterate := func(x, y int) bool {
@@ -117,6 +151,37 @@ func (t *TerrainMap) ValidateGroundLocation(reg commonMath.Region) bool {
 	return !reg.IterateIntWithEarlyExit(iterate)
 }
 
+// Returns true if any part of the map within the distance of the position is water
+func (t *TerrainMap) HasWaterNearby(pos mgl32.Vec2, distance float32) bool {
+	region := commonMath.Region{
+		RegionType: commonMath.CircleRegion,
+		Position:   pos,
+		Scale:      distance * 2}
+
+	return region.IterateIntWithEarlyExit(func(x, y int) bool {
+		texel, _ := t.getTexel(mgl32.Vec2{float32(x), float32(y)})
+		return texel.TerrainType == terraindto.Water
+	})
+}
+
+// Returns the length of the line from start to end crossing each terrain type, sampled each unit along the line
+func (t *TerrainMap) GetTerrainAlongLine(start, end mgl32.Vec2) map[terraindto.TerrainType]float32 {
+	lengths := make(map[terraindto.TerrainType]float32)
+
+	length := end.Sub(start).Len()
+	steps := int(math.Ceil(float64(length)))
+	for i := 0; i < steps; i++ {
+		// Each step is sampled at its midpoint, with the last step covering what remains of the line
+		stepLength := min(1, length-float32(i))
+		pos := start.Add(end.Sub(start).Mul((float32(i) + stepLength/2) / length))
+
+		texel, _ := t.getTexel(pos)
+		lengths[texel.TerrainType] += stepLength
+	}
+
+	return lengths
+}
+
 func (t *TerrainMap) Flatten(region commonMath.Region, amount float32) {
 	t.performRegionBasedUpdate(region, amount, flatten)
 }
@@ -143,9 +208,7 @@ func (t *TerrainMap) performRegionBasedUpdate(region commonMath.Region, amount f
 
 		update(region.Position, modifiedPos, texel, centralHeight, amount, region.Scale)
 		terrainUpdate := terraindto.NewTerrainUpdate(texelRegion, x, y)
-		for _, reg := range t.registeredNewTerrainChannels {
-			reg <- terrainUpdate
-		}
+		t.newTerrains.Send(terrainUpdate)
 
 		// Never early exit
 		return false
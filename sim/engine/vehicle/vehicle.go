@@ -7,10 +7,20 @@ import (
 	"github.com/go-gl/mathgl/mgl32"
 )
 
+// How quickly vehicles speed up and slow down, in units / second^2
+const defaultAcceleration float32 = 3.0
+const defaultDeceleration float32 = 5.0
+
 type Vehicle struct {
 	VehicleType string
 	Length      float32
 
+	Acceleration float32 // units / second^2
+	Deceleration float32 // units / second^2
+
+	// The road terminus the vehicle is traveling to, or -1 if it has none
+	Destination int64
+
 	// Autogenerated
 	Color mgl32.Vec3
 
@@ -19,16 +29,19 @@ type Vehicle struct {
 
 func NewVehicle() *Vehicle {
 	return &Vehicle{
-		Length: 10.0}
+		Length:       10.0,
+		Acceleration: defaultAcceleration,
+		Deceleration: defaultDeceleration,
+		Destination:  -1}
 }
 
 type VehicleManager struct {
-	vehicles *cmap.Map
+	vehicles *cmap.Map[*Vehicle]
 }
 
 func NewVehicleManager() *VehicleManager {
 	manager := &VehicleManager{
-		vehicles: cmap.NewMap()}
+		vehicles: cmap.NewMap[*Vehicle]()}
 
 	return manager
 }
@@ -38,3 +51,23 @@ func (v *VehicleManager) NewVehicle() (*Vehicle, int64) {
 	vehicleId := v.vehicles.IterativeAdd(vehicle)
 	return vehicle, vehicleId
 }
+
+// Gets a vehicle, returning false if it does not exist
+func (v *VehicleManager) GetVehicle(vehicleId int64) (*Vehicle, bool) {
+	return v.vehicles.Get(vehicleId)
+}
+
+// Despawns a vehicle, returning true if despawned, false if it was already gone
+func (v *VehicleManager) DeleteVehicle(vehicleId int64) bool {
+	return v.vehicles.Delete(vehicleId)
+}
+
+// Returns the number of vehicles in the simulation
+func (v *VehicleManager) Count() int {
+	return v.vehicles.Len()
+}
+
+// Visits each vehicle in ID order, stopping early if visit returns false
+func (v *VehicleManager) Range(visit func(vehicleId int64, vehicle *Vehicle) bool) {
+	v.vehicles.Range(visit)
+}
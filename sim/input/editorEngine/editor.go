@@ -1,11 +1,16 @@
 package editorEngine
 
 import (
+	"context"
 	"fmt"
+	"sim/config"
+	"sim/core/broadcast"
 	"sim/core/dto/editorengdto"
+	"sim/core/lifecycle"
 	"sim/input"
 
 	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/go-gl/mathgl/mgl32"
 )
 
 type State struct {
@@ -15,45 +20,59 @@ type State struct {
 	ItemSubSelection editorengdto.ItemSubSelection
 
 	SnapSettings map[editorengdto.SnapToggle]bool
+	IsOverpass   bool
+	Placement    editorengdto.PlacementSetting
 }
 
 type EditorEngine struct {
-	engineModeRegs     []chan editorengdto.EditorMode
-	engineAddModeRegs  []chan editorengdto.EditorAddMode
-	engineDrawModeRegs []chan editorengdto.EditorDrawMode
-	snapSettingRegs    []chan editorengdto.SnapSetting
-	cancellationRegs   []chan bool
-
-	engineState              State
-	keyPressChannel          chan glfw.Key
-	EngineModeRegChannel     chan chan editorengdto.EditorMode
-	EngineAddModeRegChannel  chan chan editorengdto.EditorAddMode
-	EngineDrawModeRegChannel chan chan editorengdto.EditorDrawMode
-	SnapSettingsRegChannel   chan chan editorengdto.SnapSetting
-	CancellationRegChannel   chan chan bool
-	ControlChannel           chan int
+	engineModes       *broadcast.Broadcaster[editorengdto.EditorMode]
+	engineAddModes    *broadcast.Broadcaster[editorengdto.EditorAddMode]
+	engineDrawModes   *broadcast.Broadcaster[editorengdto.EditorDrawMode]
+	itemSubSelections *broadcast.Broadcaster[editorengdto.ItemSubSelection]
+	snapSettings      *broadcast.Broadcaster[editorengdto.SnapSetting]
+	overpasses        *broadcast.Broadcaster[bool]
+	placementSettings *broadcast.Broadcaster[editorengdto.PlacementSetting]
+	cancellations     *broadcast.Broadcaster[bool]
+
+	engineState                State
+	keyPressChannel            chan glfw.Key
+	EngineModeRegChannel       chan chan editorengdto.EditorMode
+	EngineAddModeRegChannel    chan chan editorengdto.EditorAddMode
+	EngineDrawModeRegChannel   chan chan editorengdto.EditorDrawMode
+	ItemSubSelectionRegChannel chan chan editorengdto.ItemSubSelection
+	SnapSettingsRegChannel     chan chan editorengdto.SnapSetting
+	OverpassRegChannel         chan chan bool
+	PlacementRegChannel        chan chan editorengdto.PlacementSetting
+	CancellationRegChannel     chan chan bool
 }
 
-func NewEditorEngine(keyPressRegChannel chan chan glfw.Key) *EditorEngine {
+func NewEditorEngine(supervisor *lifecycle.Supervisor, keyPressRegChannel chan chan glfw.Key) *EditorEngine {
 	engine := EditorEngine{
 		engineState: State{
 			Mode:             editorengdto.Select,
 			InAddMode:        editorengdto.PowerPlant,
 			InDrawMode:       editorengdto.TerrainFlatten,
 			ItemSubSelection: editorengdto.Item1,
-			SnapSettings:     make(map[editorengdto.SnapToggle]bool)},
-		keyPressChannel:          make(chan glfw.Key, 2),
-		engineModeRegs:           make([]chan editorengdto.EditorMode, 0),
-		engineAddModeRegs:        make([]chan editorengdto.EditorAddMode, 0),
-		engineDrawModeRegs:       make([]chan editorengdto.EditorDrawMode, 0),
-		snapSettingRegs:          make([]chan editorengdto.SnapSetting, 0),
-		cancellationRegs:         make([]chan bool, 0),
-		EngineModeRegChannel:     make(chan chan editorengdto.EditorMode),
-		EngineAddModeRegChannel:  make(chan chan editorengdto.EditorAddMode),
-		EngineDrawModeRegChannel: make(chan chan editorengdto.EditorDrawMode),
-		SnapSettingsRegChannel:   make(chan chan editorengdto.SnapSetting),
-		CancellationRegChannel:   make(chan chan bool),
-		ControlChannel:           make(chan int)}
+			SnapSettings:     make(map[editorengdto.SnapToggle]bool),
+			IsOverpass:       false,
+			Placement:        editorengdto.PlacementSetting{IsLarge: false, Orientation: 0}},
+		keyPressChannel:            make(chan glfw.Key, 2),
+		engineModes:                broadcast.NewBroadcaster[editorengdto.EditorMode]("Editor modes", broadcast.BlockWithTimeout, broadcast.DefaultTimeout),
+		engineAddModes:             broadcast.NewBroadcaster[editorengdto.EditorAddMode]("Editor add modes", broadcast.BlockWithTimeout, broadcast.DefaultTimeout),
+		engineDrawModes:            broadcast.NewBroadcaster[editorengdto.EditorDrawMode]("Editor draw modes", broadcast.BlockWithTimeout, broadcast.DefaultTimeout),
+		itemSubSelections:          broadcast.NewBroadcaster[editorengdto.ItemSubSelection]("Item sub-selections", broadcast.BlockWithTimeout, broadcast.DefaultTimeout),
+		snapSettings:               broadcast.NewBroadcaster[editorengdto.SnapSetting]("Snap settings", broadcast.BlockWithTimeout, broadcast.DefaultTimeout),
+		overpasses:                 broadcast.NewBroadcaster[bool]("Overpass settings", broadcast.BlockWithTimeout, broadcast.DefaultTimeout),
+		placementSettings:          broadcast.NewBroadcaster[editorengdto.PlacementSetting]("Placement settings", broadcast.BlockWithTimeout, broadcast.DefaultTimeout),
+		cancellations:              broadcast.NewBroadcaster[bool]("Editor cancellations", broadcast.BlockWithTimeout, broadcast.DefaultTimeout),
+		EngineModeRegChannel:       make(chan chan editorengdto.EditorMode),
+		EngineAddModeRegChannel:    make(chan chan editorengdto.EditorAddMode),
+		EngineDrawModeRegChannel:   make(chan chan editorengdto.EditorDrawMode),
+		ItemSubSelectionRegChannel: make(chan chan editorengdto.ItemSubSelection),
+		SnapSettingsRegChannel:     make(chan chan editorengdto.SnapSetting),
+		OverpassRegChannel:         make(chan chan bool),
+		PlacementRegChannel:        make(chan chan editorengdto.PlacementSetting),
+		CancellationRegChannel:     make(chan chan bool)}
 
 	engine.engineState.SnapSettings[editorengdto.SnapToGrid] = true
 	engine.engineState.SnapSettings[editorengdto.SnapToElements] = false
@@ -61,27 +80,36 @@ func NewEditorEngine(keyPressRegChannel chan chan glfw.Key) *EditorEngine {
 
 	keyPressRegChannel <- engine.keyPressChannel
 
-	go engine.run()
+	supervisor.Go("editorEngine.EditorEngine", engine.run)
 	return &engine
 }
 
-func (e *EditorEngine) run() {
+func (e *EditorEngine) run(ctx context.Context) {
 	for {
 		select {
 		case reg := <-e.EngineModeRegChannel:
-			e.engineModeRegs = append(e.engineModeRegs, reg)
+			e.engineModes.Register(reg)
 			break
 		case reg := <-e.EngineAddModeRegChannel:
-			e.engineAddModeRegs = append(e.engineAddModeRegs, reg)
+			e.engineAddModes.Register(reg)
 			break
 		case reg := <-e.EngineDrawModeRegChannel:
-			e.engineDrawModeRegs = append(e.engineDrawModeRegs, reg)
+			e.engineDrawModes.Register(reg)
+			break
+		case reg := <-e.ItemSubSelectionRegChannel:
+			e.itemSubSelections.Register(reg)
 			break
 		case reg := <-e.SnapSettingsRegChannel:
-			e.snapSettingRegs = append(e.snapSettingRegs, reg)
+			e.snapSettings.Register(reg)
+			break
+		case reg := <-e.OverpassRegChannel:
+			e.overpasses.Register(reg)
+			break
+		case reg := <-e.PlacementRegChannel:
+			e.placementSettings.Register(reg)
 			break
 		case reg := <-e.CancellationRegChannel:
-			e.cancellationRegs = append(e.cancellationRegs, reg)
+			e.cancellations.Register(reg)
 			break
 		case key := <-e.keyPressChannel:
 			// updated => used to avoid duplicate checks.
@@ -90,17 +118,19 @@ func (e *EditorEngine) run() {
 			if e.engineState.Mode == editorengdto.Add {
 				updated = updated || e.checkAddMode(key)
 				updated = updated || e.checkAddModeSubSelections(key)
+				updated = updated || e.checkPlacementSettings(key)
 			} else if e.engineState.Mode == editorengdto.Draw {
 				updated = updated || e.checkDrawModeSubSelections(key)
+			} else if e.engineState.Mode == editorengdto.Select {
+				// Sub-selections pick the intersection control of the selected road terminus
+				updated = updated || e.checkAddModeSubSelections(key)
 			}
 
 			if key == input.GetKeyCode(input.CancelKey) {
-				for _, reg := range e.cancellationRegs {
-					reg <- true
-				}
+				e.cancellations.Send(true)
 			}
 			break
-		case _ = <-e.ControlChannel:
+		case _ = <-ctx.Done():
 			return
 		}
 	}
@@ -118,24 +148,22 @@ func (e *EditorEngine) checkEditorMode(key glfw.Key) bool {
 		fmt.Println("Entered addition mode.")
 		selectionChanged = true
 
-		for _, reg := range e.engineAddModeRegs {
-			reg <- e.engineState.InAddMode
-		}
+		e.engineAddModes.Send(e.engineState.InAddMode)
 	case input.GetKeyCode(input.DrawModeKey):
 		e.engineState.Mode = editorengdto.Draw
 		fmt.Println("Entered draw mode.")
 		selectionChanged = true
 
-		for _, reg := range e.engineDrawModeRegs {
-			reg <- e.engineState.InDrawMode
-		}
+		e.engineDrawModes.Send(e.engineState.InDrawMode)
+	case input.GetKeyCode(input.DeleteModeKey):
+		e.engineState.Mode = editorengdto.Delete
+		fmt.Println("Entered delete mode.")
+		selectionChanged = true
 	default:
 	}
 
 	if selectionChanged {
-		for _, reg := range e.engineModeRegs {
-			reg <- e.engineState.Mode
-		}
+		e.engineModes.Send(e.engineState.Mode)
 	}
 
 	return selectionChanged
@@ -149,9 +177,7 @@ func (e *EditorEngine) checkEditorToggles(key glfw.Key) bool {
 
 		fmt.Printf("Toggled snap-to-grid to %v.\n", state)
 
-		for _, reg := range e.snapSettingRegs {
-			reg <- editorengdto.SnapSetting{Setting: editorengdto.SnapToGrid, State: state}
-		}
+		e.snapSettings.Send(editorengdto.SnapSetting{Setting: editorengdto.SnapToGrid, State: state})
 		return true
 	case input.GetKeyCode(input.SnapToAngleKey):
 		state := !e.engineState.SnapSettings[editorengdto.SnapToAngle]
@@ -159,9 +185,7 @@ func (e *EditorEngine) checkEditorToggles(key glfw.Key) bool {
 
 		fmt.Printf("Toggled snap-to-angle to %v.\n", state)
 
-		for _, reg := range e.snapSettingRegs {
-			reg <- editorengdto.SnapSetting{Setting: editorengdto.SnapToAngle, State: state}
-		}
+		e.snapSettings.Send(editorengdto.SnapSetting{Setting: editorengdto.SnapToAngle, State: state})
 		return true
 	case input.GetKeyCode(input.SnapToElementsKey):
 		state := !e.engineState.SnapSettings[editorengdto.SnapToElements]
@@ -169,9 +193,14 @@ func (e *EditorEngine) checkEditorToggles(key glfw.Key) bool {
 
 		fmt.Printf("Toggled snap-to-elements to %v.\n", state)
 
-		for _, reg := range e.snapSettingRegs {
-			reg <- editorengdto.SnapSetting{Setting: editorengdto.SnapToElements, State: state}
-		}
+		e.snapSettings.Send(editorengdto.SnapSetting{Setting: editorengdto.SnapToElements, State: state})
+		return true
+	case input.GetKeyCode(input.OverpassKey):
+		e.engineState.IsOverpass = !e.engineState.IsOverpass
+
+		fmt.Printf("Toggled overpasses to %v.\n", e.engineState.IsOverpass)
+
+		e.overpasses.Send(e.engineState.IsOverpass)
 		return true
 	default:
 		return false
@@ -193,47 +222,80 @@ func (e *EditorEngine) checkAddMode(key glfw.Key) bool {
 		e.engineState.InAddMode = editorengdto.RoadLine
 		fmt.Println("Entered roadline add mode.")
 		selectionChanged = true
+	case input.GetKeyCode(input.BuildingAddModeKey):
+		e.engineState.InAddMode = editorengdto.Building
+		fmt.Println("Entered building add mode.")
+		selectionChanged = true
 	default:
 	}
 
 	if selectionChanged {
-		for _, reg := range e.engineAddModeRegs {
-			reg <- e.engineState.InAddMode
-		}
+		e.engineAddModes.Send(e.engineState.InAddMode)
 	}
 
 	return selectionChanged
 }
 
 func (e *EditorEngine) checkAddModeSubSelections(key glfw.Key) bool {
+	selectionChanged := false
 	switch key {
 	case input.GetKeyCode(input.ItemAdd1Key):
 		e.engineState.ItemSubSelection = editorengdto.Item1
 		fmt.Println("Selected sub-selection 1")
-		return true
+		selectionChanged = true
 	case input.GetKeyCode(input.ItemAdd2Key):
 		e.engineState.ItemSubSelection = editorengdto.Item2
 		fmt.Println("Selected sub-selection 2")
-		return true
+		selectionChanged = true
 	case input.GetKeyCode(input.ItemAdd3Key):
 		e.engineState.ItemSubSelection = editorengdto.Item3
 		fmt.Println("Selected sub-selection 3")
-		return true
+		selectionChanged = true
 	case input.GetKeyCode(input.ItemAdd4Key):
 		e.engineState.ItemSubSelection = editorengdto.Item4
 		fmt.Println("Selected sub-selection 4")
-		return true
+		selectionChanged = true
 	case input.GetKeyCode(input.ItemAdd5Key):
 		e.engineState.ItemSubSelection = editorengdto.Item5
 		fmt.Println("Selected sub-selection 5")
-		return true
+		selectionChanged = true
 	case input.GetKeyCode(input.ItemAdd6Key):
 		e.engineState.ItemSubSelection = editorengdto.Item6
 		fmt.Println("Selected sub-selection 6")
-		return true
+		selectionChanged = true
+	default:
+	}
+
+	if selectionChanged {
+		e.itemSubSelections.Send(e.engineState.ItemSubSelection)
+	}
+
+	return selectionChanged
+}
+
+func (e *EditorEngine) checkPlacementSettings(key glfw.Key) bool {
+	rotationStep := mgl32.DegToRad(config.Config.Draw.RotationStep)
+
+	selectionChanged := false
+	switch key {
+	case input.GetKeyCode(input.ItemSizeKey):
+		e.engineState.Placement.IsLarge = !e.engineState.Placement.IsLarge
+		fmt.Printf("Toggled large items to %v.\n", e.engineState.Placement.IsLarge)
+		selectionChanged = true
+	case input.GetKeyCode(input.RotateLeftKey):
+		e.engineState.Placement.Orientation += rotationStep
+		selectionChanged = true
+	case input.GetKeyCode(input.RotateRightKey):
+		e.engineState.Placement.Orientation -= rotationStep
+		selectionChanged = true
 	default:
-		return false
 	}
+
+	if selectionChanged {
+		e.placementSettings.Send(e.engineState.Placement)
+	}
+
+	return selectionChanged
 }
 
 func (e *EditorEngine) checkDrawModeSubSelections(key glfw.Key) bool {
@@ -267,9 +329,7 @@ func (e *EditorEngine) checkDrawModeSubSelections(key glfw.Key) bool {
 	}
 
 	if selectionChanged {
-		for _, reg := range e.engineDrawModeRegs {
-			reg <- e.engineState.InDrawMode
-		}
+		e.engineDrawModes.Send(e.engineState.InDrawMode)
 	}
 
 	return selectionChanged
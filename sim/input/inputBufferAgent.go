@@ -1,6 +1,10 @@
 package input
 
 import (
+	"context"
+	"sim/core/broadcast"
+	"sim/core/lifecycle"
+
 	"github.com/go-gl/glfw/v3.2/glfw"
 	"github.com/go-gl/mathgl/mgl32"
 )
@@ -10,14 +14,12 @@ var InputBuffer *InputBufferAgent
 // The input buffer agent separates the GLFW input system from a channel / agent
 // based agent design.
 type InputBufferAgent struct {
-	ControlChannel chan int
-
-	mouseMoveRegistrations     []chan mgl32.Vec2
-	mousePressedRegistrations  []chan glfw.MouseButton
-	mouseReleasedRegistrations []chan glfw.MouseButton
-	mouseScrollRegistrations   []chan float32
-	keyPressedRegistrations    []chan glfw.Key
-	keyReleasedRegistrations   []chan glfw.Key
+	mouseMoves    *broadcast.Broadcaster[mgl32.Vec2]
+	mousePresses  *broadcast.Broadcaster[glfw.MouseButton]
+	mouseReleases *broadcast.Broadcaster[glfw.MouseButton]
+	mouseScrolls  *broadcast.Broadcaster[float32]
+	keyPresses    *broadcast.Broadcaster[glfw.Key]
+	keyReleases   *broadcast.Broadcaster[glfw.Key]
 
 	MouseMoveChannel        chan mgl32.Vec2
 	MouseMoveRegChannel     chan chan mgl32.Vec2
@@ -33,82 +35,71 @@ type InputBufferAgent struct {
 	ReleasedKeysRegChannel  chan chan glfw.Key
 }
 
-func SetupInputBufferAgent() {
+func SetupInputBufferAgent(supervisor *lifecycle.Supervisor) {
 	agent := InputBufferAgent{
-		ControlChannel:           make(chan int),
-		mouseMoveRegistrations:   make([]chan mgl32.Vec2, 0),
-		mouseScrollRegistrations: make([]chan float32, 0),
-		keyPressedRegistrations:  make([]chan glfw.Key, 0),
-		keyReleasedRegistrations: make([]chan glfw.Key, 0),
-		MouseMoveChannel:         make(chan mgl32.Vec2, 30),
-		MouseMoveRegChannel:      make(chan chan mgl32.Vec2),
-		MousePressedChannel:      make(chan glfw.MouseButton, 3),
-		MousePressedRegChannel:   make(chan chan glfw.MouseButton),
-		MouseReleasedChannel:     make(chan glfw.MouseButton, 3),
-		MouseReleasedRegChannel:  make(chan chan glfw.MouseButton),
-		MouseScrollChannel:       make(chan float32, 30),
-		MouseScrollRegChannel:    make(chan chan float32),
-		PressedKeysChannel:       make(chan glfw.Key, 50),
-		ReleasedKeysChannel:      make(chan glfw.Key, 50),
-		PressedKeysRegChannel:    make(chan chan glfw.Key),
-		ReleasedKeysRegChannel:   make(chan chan glfw.Key)}
+		mouseMoves:              broadcast.NewBroadcaster[mgl32.Vec2]("Mouse moves", broadcast.CoalesceLatest, broadcast.DefaultTimeout),
+		mousePresses:            broadcast.NewBroadcaster[glfw.MouseButton]("Mouse presses", broadcast.BlockWithTimeout, broadcast.DefaultTimeout),
+		mouseReleases:           broadcast.NewBroadcaster[glfw.MouseButton]("Mouse releases", broadcast.BlockWithTimeout, broadcast.DefaultTimeout),
+		mouseScrolls:            broadcast.NewBroadcaster[float32]("Mouse scrolls", broadcast.DropOldest, broadcast.DefaultTimeout),
+		keyPresses:              broadcast.NewBroadcaster[glfw.Key]("Key presses", broadcast.BlockWithTimeout, broadcast.DefaultTimeout),
+		keyReleases:             broadcast.NewBroadcaster[glfw.Key]("Key releases", broadcast.BlockWithTimeout, broadcast.DefaultTimeout),
+		MouseMoveChannel:        make(chan mgl32.Vec2, 30),
+		MouseMoveRegChannel:     make(chan chan mgl32.Vec2),
+		MousePressedChannel:     make(chan glfw.MouseButton, 3),
+		MousePressedRegChannel:  make(chan chan glfw.MouseButton),
+		MouseReleasedChannel:    make(chan glfw.MouseButton, 3),
+		MouseReleasedRegChannel: make(chan chan glfw.MouseButton),
+		MouseScrollChannel:      make(chan float32, 30),
+		MouseScrollRegChannel:   make(chan chan float32),
+		PressedKeysChannel:      make(chan glfw.Key, 50),
+		ReleasedKeysChannel:     make(chan glfw.Key, 50),
+		PressedKeysRegChannel:   make(chan chan glfw.Key),
+		ReleasedKeysRegChannel:  make(chan chan glfw.Key)}
 
-	go agent.run()
+	supervisor.Go("input.InputBufferAgent", agent.run)
 	InputBuffer = &agent
 }
 
-func (i *InputBufferAgent) run() {
+func (i *InputBufferAgent) run(ctx context.Context) {
 	for {
 		select {
 		case key := <-i.PressedKeysChannel:
-			for _, agent := range i.keyPressedRegistrations {
-				agent <- key
-			}
+			i.keyPresses.Send(key)
 			break
 		case reg := <-i.PressedKeysRegChannel:
-			i.keyPressedRegistrations = append(i.keyPressedRegistrations, reg)
+			i.keyPresses.Register(reg)
 			break
 		case key := <-i.ReleasedKeysChannel:
-			for _, agent := range i.keyReleasedRegistrations {
-				agent <- key
-			}
+			i.keyReleases.Send(key)
 			break
 		case reg := <-i.ReleasedKeysRegChannel:
-			i.keyReleasedRegistrations = append(i.keyReleasedRegistrations, reg)
+			i.keyReleases.Register(reg)
 			break
 		case input := <-i.MouseMoveChannel:
-			for _, agent := range i.mouseMoveRegistrations {
-				agent <- input
-			}
+			i.mouseMoves.Send(input)
 			break
 		case reg := <-i.MouseMoveRegChannel:
-			i.mouseMoveRegistrations = append(i.mouseMoveRegistrations, reg)
+			i.mouseMoves.Register(reg)
 			break
 		case input := <-i.MousePressedChannel:
-			for _, agent := range i.mousePressedRegistrations {
-				agent <- input
-			}
+			i.mousePresses.Send(input)
 			break
 		case reg := <-i.MousePressedRegChannel:
-			i.mousePressedRegistrations = append(i.mousePressedRegistrations, reg)
+			i.mousePresses.Register(reg)
 			break
 		case input := <-i.MouseReleasedChannel:
-			for _, agent := range i.mouseReleasedRegistrations {
-				agent <- input
-			}
+			i.mouseReleases.Send(input)
 			break
 		case reg := <-i.MouseReleasedRegChannel:
-			i.mouseReleasedRegistrations = append(i.mouseReleasedRegistrations, reg)
+			i.mouseReleases.Register(reg)
 			break
 		case input := <-i.MouseScrollChannel:
-			for _, agent := range i.mouseScrollRegistrations {
-				agent <- input
-			}
+			i.mouseScrolls.Send(input)
 			break
 		case reg := <-i.MouseScrollRegChannel:
-			i.mouseScrollRegistrations = append(i.mouseScrollRegistrations, reg)
+			i.mouseScrolls.Register(reg)
 			break
-		case _ = <-i.ControlChannel:
+		case _ = <-ctx.Done():
 			return
 		}
 	}
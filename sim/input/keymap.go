@@ -18,14 +18,17 @@ const (
 	SnapToGridKey
 	SnapToAngleKey
 	SnapToElementsKey
+	OverpassKey
 
 	SelectModeKey
 	AddModeKey
 	DrawModeKey
+	DeleteModeKey
 
 	PowerPlantAddModeKey
 	PowerLineAddModeKey
 	RoadLineAddModeKey
+	BuildingAddModeKey
 
 	ItemAdd1Key
 	ItemAdd2Key
@@ -34,6 +37,10 @@ const (
 	ItemAdd5Key
 	ItemAdd6Key
 
+	ItemSizeKey
+	RotateLeftKey
+	RotateRightKey
+
 	TerrainFlattenKey
 	TerrainSharpenKey
 	TerrainTreesKey
@@ -75,14 +82,21 @@ func CreateDefaultKeyMap() {
 	keyMap[SnapToGridKey] = glfw.Key8
 	keyMap[SnapToAngleKey] = glfw.Key9
 	keyMap[SnapToElementsKey] = glfw.Key0
+	keyMap[OverpassKey] = glfw.KeyO
 
 	keyMap[SelectModeKey] = glfw.KeyS
 	keyMap[AddModeKey] = glfw.KeyA
 	keyMap[DrawModeKey] = glfw.KeyD
+	keyMap[DeleteModeKey] = glfw.KeyX
 
 	keyMap[PowerPlantAddModeKey] = glfw.KeyP
 	keyMap[PowerLineAddModeKey] = glfw.KeyL
 	keyMap[RoadLineAddModeKey] = glfw.KeyR
+	keyMap[BuildingAddModeKey] = glfw.KeyB
+
+	keyMap[ItemSizeKey] = glfw.KeyZ
+	keyMap[RotateLeftKey] = glfw.KeyQ
+	keyMap[RotateRightKey] = glfw.KeyE
 
 	createSubOptionsKeyMap()
 }
@@ -4,11 +4,15 @@ import (
 	commonColor "common/commoncolor"
 	commonConfig "common/commonconfig"
 	commonOpenGl "common/commonopengl"
+	"context"
+	"fmt"
 	"log"
 	"net/http"
 	_ "net/http/pprof"
 	"runtime"
 	"sim/config"
+	"sim/core/dto/powerdto"
+	"sim/core/lifecycle"
 	"sim/core/mailroom"
 	"sim/engine"
 	"sim/engine/core"
@@ -22,6 +26,9 @@ import (
 	"github.com/go-gl/glfw/v3.2/glfw"
 )
 
+// How long to wait for all agents to stop when exiting
+const shutdownTimeout = 2 * time.Second
+
 func init() {
 	runtime.LockOSThread()
 }
@@ -38,6 +45,25 @@ func setInputCallbacks(window *glfw.Window) {
 
 func main() {
 	// Navigate to http://localhost:8765/debug/pprof/goroutine?debug=1 to see the current goroutines
+	// Navigate to http://localhost:8765/debug/mailroom to see how the mailroom is wired up
+	http.HandleFunc("/debug/mailroom", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, mailroom.DumpWiring())
+	})
+
+	// Navigate to http://localhost:8765/debug/power to see the status of each power network
+	mailroom.PowerReportChannel.Use("main")
+	http.HandleFunc("/debug/power", func(w http.ResponseWriter, r *http.Request) {
+		query := powerdto.NewPowerReportQuery()
+		if !mailroom.PowerReportChannel.SendContext(r.Context(), query) {
+			http.Error(w, "The power grid is not running.", http.StatusServiceUnavailable)
+			return
+		}
+
+		if report, ok := lifecycle.Receive(r.Context(), query.Result); ok {
+			fmt.Fprint(w, report)
+		}
+	})
+
 	go func() {
 		log.Println("Starting performance diagnostics on localhost:8765...")
 		log.Println(http.ListenAndServe("localhost:8765", nil))
@@ -59,11 +85,11 @@ func main() {
 
 	window.MakeContextCurrent()
 
-	mailroom.Init()
+	supervisor := lifecycle.NewSupervisor(context.Background())
 
-	input.SetupInputBufferAgent()
-	mailroom.MousePressedRegChannel = input.InputBuffer.MousePressedRegChannel
-	mailroom.MouseReleasedRegChannel = input.InputBuffer.MouseReleasedRegChannel
+	input.SetupInputBufferAgent(supervisor)
+	mailroom.MousePressedRegChannel.Provide("input.InputBufferAgent", input.InputBuffer.MousePressedRegChannel)
+	mailroom.MouseReleasedRegChannel.Provide("input.InputBufferAgent", input.InputBuffer.MouseReleasedRegChannel)
 
 	setInputCallbacks(window)
 	commonOpenGl.ConfigureOpenGl()
@@ -73,55 +99,72 @@ func main() {
 		commonConfig.Config.ColorGradient.Saturation,
 		commonConfig.Config.ColorGradient.Luminosity)
 
-	editorEngine := editorEngine.NewEditorEngine(input.InputBuffer.PressedKeysRegChannel)
-	mailroom.EngineModeRegChannel = editorEngine.EngineModeRegChannel
-	mailroom.EngineAddModeRegChannel = editorEngine.EngineAddModeRegChannel
-	mailroom.EngineDrawModeRegChannel = editorEngine.EngineDrawModeRegChannel
-	mailroom.SnapSettingsRegChannel = editorEngine.SnapSettingsRegChannel
-	mailroom.EngineCancelChannel = editorEngine.CancellationRegChannel
+	editorEngine := editorEngine.NewEditorEngine(supervisor, input.InputBuffer.PressedKeysRegChannel)
+	mailroom.EngineModeRegChannel.Provide("editorEngine.EditorEngine", editorEngine.EngineModeRegChannel)
+	mailroom.EngineAddModeRegChannel.Provide("editorEngine.EditorEngine", editorEngine.EngineAddModeRegChannel)
+	mailroom.EngineDrawModeRegChannel.Provide("editorEngine.EditorEngine", editorEngine.EngineDrawModeRegChannel)
+	mailroom.EngineItemSubSelectionRegChannel.Provide("editorEngine.EditorEngine", editorEngine.ItemSubSelectionRegChannel)
+	mailroom.SnapSettingsRegChannel.Provide("editorEngine.EditorEngine", editorEngine.SnapSettingsRegChannel)
+	mailroom.EnginePlacementRegChannel.Provide("editorEngine.EditorEngine", editorEngine.PlacementRegChannel)
+	mailroom.EngineOverpassRegChannel.Provide("editorEngine.EditorEngine", editorEngine.OverpassRegChannel)
+	mailroom.EngineCancelChannel.Provide("editorEngine.EditorEngine", editorEngine.CancellationRegChannel)
 
 	ui.Init(window)
-	customCursors := ui.NewCustomCursors()
+	customCursors := ui.NewCustomCursors(supervisor)
 	defer customCursors.Delete()
 	defer ui.Delete()
 
-	core.Init()
+	core.Init(supervisor)
 
 	camera := flat.NewCamera(
+		supervisor,
 		input.InputBuffer.MouseMoveRegChannel,
 		input.InputBuffer.MouseScrollRegChannel,
 		input.InputBuffer.PressedKeysRegChannel,
 		input.InputBuffer.ReleasedKeysRegChannel)
 
-	mailroom.CameraOffsetRegChannel = camera.OffsetChangeRegChannel
-	mailroom.CameraScaleRegChannel = camera.ScaleChangeRegChannel
-	mailroom.BoardPosChangeRegChannel = camera.BoardPosRegChannel
+	mailroom.CameraOffsetRegChannel.Provide("flat.Camera", camera.OffsetChangeRegChannel)
+	mailroom.CameraScaleRegChannel.Provide("flat.Camera", camera.ScaleChangeRegChannel)
+	mailroom.BoardPosChangeRegChannel.Provide("flat.Camera", camera.BoardPosRegChannel)
 
 	// Setup simulation
-	_ = engine.NewEngine()
+	_ = engine.NewEngine(supervisor)
 
 	powerGridRenderer := flat.NewPowerGridRenderer()
-	mailroom.NewPowerLineChannel = powerGridRenderer.LineRenderer.NewLineChannel
-	mailroom.DeletePowerLineChannel = powerGridRenderer.LineRenderer.DeleteLineChannel
+	mailroom.NewPowerLineChannel.Provide("flat.PowerGridRenderer", powerGridRenderer.LineRenderer.NewLineChannel)
+	mailroom.DeletePowerLineChannel.Provide("flat.PowerGridRenderer", powerGridRenderer.LineRenderer.DeleteLineChannel)
+
+	mailroom.NewPowerPlantChannel.Provide("flat.PowerGridRenderer", powerGridRenderer.PlantRenderer.NewRegionChannel)
+	mailroom.DeletePowerPlantChannel.Provide("flat.PowerGridRenderer", powerGridRenderer.PlantRenderer.DeleteRegionChannel)
 
-	mailroom.NewPowerPlantChannel = powerGridRenderer.PlantRenderer.NewRegionChannel
-	mailroom.DeletePowerPlantChannel = powerGridRenderer.PlantRenderer.DeleteRegionChannel
+	buildingRenderer := flat.NewBuildingRenderer()
+	mailroom.NewBuildingChannel.Provide("flat.BuildingRenderer", buildingRenderer.NewBuildingChannel)
+	mailroom.DeleteBuildingChannel.Provide("flat.BuildingRenderer", buildingRenderer.DeleteBuildingChannel)
+	mailroom.BuildingPowerStateChannel.Provide("flat.BuildingRenderer", buildingRenderer.PowerStateChannel)
 
 	roadGridRenderer := flat.NewRoadGridRenderer()
-	mailroom.NewRoadLineChannel = roadGridRenderer.Renderer.NewLineChannel
-	mailroom.DeleteRoadLineChannel = roadGridRenderer.Renderer.DeleteLineChannel
+	mailroom.NewRoadLineChannel.Provide("flat.RoadGridRenderer", roadGridRenderer.Renderer.NewLineChannel)
+	mailroom.DeleteRoadLineChannel.Provide("flat.RoadGridRenderer", roadGridRenderer.Renderer.DeleteLineChannel)
 
-	vehicleRenderer := flat.NewVehicleRenderer()
-	mailroom.NewRoadLineIdChannel = vehicleRenderer.RoadLineRegChannel
-	mailroom.NewRoadTerminusChannel = vehicleRenderer.TerminusChannel
-	mailroom.VehicleUpdateChannel = vehicleRenderer.VehicleUpdateChannel
+	vehicleRenderer := flat.NewVehicleRenderer(supervisor)
+	mailroom.NewRoadLineIdChannel.Provide("flat.VehicleRenderer", vehicleRenderer.RoadLineRegChannel)
+	mailroom.NewRoadTerminusChannel.Provide("flat.VehicleRenderer", vehicleRenderer.TerminusChannel)
+	mailroom.VehicleUpdateChannel.Provide("flat.VehicleRenderer", vehicleRenderer.VehicleUpdateChannel)
+	mailroom.VehicleDeletionChannel.Provide("flat.VehicleRenderer", vehicleRenderer.VehicleDeletionChannel)
 
-	snapRenderer := flat.NewSnapRenderer()
-	mailroom.SnappedNodesUpdateChannel = snapRenderer.SnappedNodesUpdateChannel
+	previewRenderer := flat.NewPreviewRenderer()
+	mailroom.PlacementPreviewChannel.Provide("flat.PreviewRenderer", previewRenderer.PreviewChannel)
+
+	snapRenderer := flat.NewSnapRenderer(supervisor)
+	mailroom.SnappedNodesUpdateChannel.Provide("flat.SnapRenderer", snapRenderer.SnappedNodesUpdateChannel)
 
 	terrainOverlayManager := flat.NewTerrainOverlayManager()
 	defer terrainOverlayManager.Delete()
 
+	if err := mailroom.Validate(); err != nil {
+		panic(err)
+	}
+
 	// paused := false
 
 	startTime := time.Now()
@@ -157,7 +200,9 @@ func main() {
 		ui.Ui.RegionProgram.PreRender()
 
 		powerGridRenderer.PlantRenderer.Render()
+		buildingRenderer.Render()
 		snapRenderer.NodeRenderer.Render()
+		previewRenderer.Render()
 		// for _, hypotheticalRegion := range engine.Hypotheticals.Regions {
 		// 	mappedRegion := camera.MapEngineRegionToScreen(&hypotheticalRegion.Region)
 		// 	ui.Ui.RegionProgram.Render(mappedRegion, hypotheticalRegion.Color)
@@ -176,6 +221,10 @@ func main() {
 	}
 
 	RenderLoop(update, render, window)
+
+	if err := supervisor.Shutdown(shutdownTimeout); err != nil {
+		fmt.Printf("Unclean shutdown: %v\n", err)
+	}
 }
 
 func RenderLoop(update, render func(), window *glfw.Window) {
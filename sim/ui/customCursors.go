@@ -2,7 +2,9 @@ package ui
 
 import (
 	"common/commonio"
+	"context"
 	"sim/core/dto/editorengdto"
+	"sim/core/lifecycle"
 	"sim/core/mailroom"
 
 	"github.com/go-gl/glfw/v3.2/glfw"
@@ -12,6 +14,7 @@ type CustomCursorType int
 
 const (
 	Selection CustomCursorType = iota
+	Demolish
 	PowerPlantAdd
 	PowerLineAdd
 	RoadLineAdd
@@ -32,14 +35,13 @@ type CustomCursors struct {
 	globalEditEngineChan chan editorengdto.EditorMode
 	addModeEngineChan    chan editorengdto.EditorAddMode
 	drawModeEngineChan   chan editorengdto.EditorDrawMode
-	ControlChannel       chan int
 
 	// Cursor updates can only be applied on the main thread
 	cursorUpdate  bool
 	currentCursor CustomCursorType
 }
 
-func NewCustomCursors() *CustomCursors {
+func NewCustomCursors(supervisor *lifecycle.Supervisor) *CustomCursors {
 
 	cursors := CustomCursors{
 		cursors:              make(map[CustomCursorType]*glfw.Cursor),
@@ -48,28 +50,30 @@ func NewCustomCursors() *CustomCursors {
 		globalEditEngineChan: make(chan editorengdto.EditorMode, 2),
 		addModeEngineChan:    make(chan editorengdto.EditorAddMode, 2),
 		drawModeEngineChan:   make(chan editorengdto.EditorDrawMode, 2),
-		ControlChannel:       make(chan int),
 		cursorUpdate:         true,
 		currentCursor:        Selection}
 
 	cursors.loadCursors()
 
-	mailroom.EngineModeRegChannel <- cursors.globalEditEngineChan
-	mailroom.EngineAddModeRegChannel <- cursors.addModeEngineChan
-	mailroom.EngineDrawModeRegChannel <- cursors.drawModeEngineChan
+	mailroom.EngineModeRegChannel.Use("ui.CustomCursors").Send(cursors.globalEditEngineChan)
+	mailroom.EngineAddModeRegChannel.Use("ui.CustomCursors").Send(cursors.addModeEngineChan)
+	mailroom.EngineDrawModeRegChannel.Use("ui.CustomCursors").Send(cursors.drawModeEngineChan)
 
-	go cursors.run()
+	supervisor.Go("ui.CustomCursors", cursors.run)
 
 	return &cursors
 }
 
-func (c *CustomCursors) run() {
+func (c *CustomCursors) run(ctx context.Context) {
 	for {
 		select {
 		case newMode := <-c.globalEditEngineChan:
 			if newMode == editorengdto.Select {
 				c.currentCursor = Selection
 				c.cursorUpdate = true
+			} else if newMode == editorengdto.Delete {
+				c.currentCursor = Demolish
+				c.cursorUpdate = true
 			}
 			break
 		case drawMode := <-c.drawModeEngineChan:
@@ -80,7 +84,7 @@ func (c *CustomCursors) run() {
 			c.currentCursor = c.addModeCursors[addMode]
 			c.cursorUpdate = true
 			break
-		case _ = <-c.ControlChannel:
+		case _ = <-ctx.Done():
 			return
 		}
 	}
@@ -88,6 +92,7 @@ func (c *CustomCursors) run() {
 
 func (c *CustomCursors) loadCursors() {
 	c.cursors[Selection] = glfw.CreateStandardCursor(glfw.ArrowCursor)
+	c.cursors[Demolish] = glfw.CreateStandardCursor(glfw.CrosshairCursor)
 
 	// Load all additional cursors
 	type CursorPair struct {
@@ -0,0 +1,73 @@
+package flat
+
+import (
+	"sim/core/dto/buildingdto"
+	"sim/core/dto/geometry"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Renders buildings colored by how well they are powered, so areas without power stand out
+type BuildingRenderer struct {
+	stateRenderers map[buildingdto.PowerState]*RegionRenderer
+
+	buildings   map[int64]geometry.IdRegion
+	powerStates map[int64]buildingdto.PowerState
+
+	NewBuildingChannel    chan geometry.IdRegion
+	DeleteBuildingChannel chan int64
+	PowerStateChannel     chan buildingdto.PowerStateUpdate
+}
+
+func NewBuildingRenderer() *BuildingRenderer {
+	return &BuildingRenderer{
+		stateRenderers: map[buildingdto.PowerState]*RegionRenderer{
+			buildingdto.Powered:  NewRegionRenderer(mgl32.Vec3{0.2, 0.4, 0.8}),
+			buildingdto.Brownout: NewRegionRenderer(mgl32.Vec3{0.6, 0.4, 0.2}),
+			buildingdto.Blackout: NewRegionRenderer(mgl32.Vec3{0.15, 0.15, 0.15})},
+		buildings:             make(map[int64]geometry.IdRegion),
+		powerStates:           make(map[int64]buildingdto.PowerState),
+		NewBuildingChannel:    make(chan geometry.IdRegion, 50),
+		DeleteBuildingChannel: make(chan int64, 50),
+		PowerStateChannel:     make(chan buildingdto.PowerStateUpdate, 50)}
+}
+
+func (r *BuildingRenderer) drainInputChannels() {
+	inputLeft := true
+	for inputLeft {
+		select {
+		case idRegion := <-r.NewBuildingChannel:
+			// Buildings have no power until the power grid says otherwise
+			r.buildings[idRegion.Id] = idRegion
+			if _, ok := r.powerStates[idRegion.Id]; !ok {
+				r.powerStates[idRegion.Id] = buildingdto.Blackout
+			}
+
+			r.stateRenderers[r.powerStates[idRegion.Id]].setRegion(idRegion)
+		case deletionId := <-r.DeleteBuildingChannel:
+			if state, ok := r.powerStates[deletionId]; ok {
+				r.stateRenderers[state].deleteRegion(deletionId)
+			}
+
+			delete(r.buildings, deletionId)
+			delete(r.powerStates, deletionId)
+		case update := <-r.PowerStateChannel:
+			if building, ok := r.buildings[update.Id]; ok {
+				r.stateRenderers[r.powerStates[update.Id]].deleteRegion(update.Id)
+				r.stateRenderers[update.State].setRegion(building)
+			}
+
+			r.powerStates[update.Id] = update.State
+		default:
+			inputLeft = false
+		}
+	}
+}
+
+func (r *BuildingRenderer) Render() {
+	r.drainInputChannels()
+
+	for _, state := range []buildingdto.PowerState{buildingdto.Powered, buildingdto.Brownout, buildingdto.Blackout} {
+		r.stateRenderers[state].Render()
+	}
+}
@@ -2,10 +2,13 @@ package flat
 
 import (
 	"common/commonopengl"
+	"context"
 	"time"
 
 	"sim/config"
+	"sim/core/broadcast"
 	"sim/core/gamegrid"
+	"sim/core/lifecycle"
 	"sim/input"
 
 	"github.com/go-gl/glfw/v3.2/glfw"
@@ -13,17 +16,16 @@ import (
 )
 
 type Camera struct {
-	mouseMoves     chan mgl32.Vec2
-	mouseScrolls   chan float32
-	keyPresses     chan glfw.Key
-	keyReleases    chan glfw.Key
-	highResTicks   chan time.Time
-	ControlChannel chan int
-
-	offsetChangeRegs       []chan mgl32.Vec2
+	mouseMoves   chan mgl32.Vec2
+	mouseScrolls chan float32
+	keyPresses   chan glfw.Key
+	keyReleases  chan glfw.Key
+	highResTicks chan time.Time
+
+	offsetChanges          *broadcast.Broadcaster[mgl32.Vec2]
 	OffsetChangeRegChannel chan chan mgl32.Vec2
 
-	scaleChangeRegs       []chan float32
+	scaleChanges          *broadcast.Broadcaster[float32]
 	ScaleChangeRegChannel chan chan float32
 
 	isLeftPressed  bool
@@ -31,7 +33,7 @@ type Camera struct {
 	isUpPressed    bool
 	isDownPressed  bool
 
-	boardPosRegs       []chan mgl32.Vec2
+	boardPosChanges    *broadcast.Broadcaster[mgl32.Vec2]
 	BoardPosRegChannel chan chan mgl32.Vec2
 
 	Scale  float32
@@ -42,6 +44,7 @@ type Camera struct {
 }
 
 func NewCamera(
+	supervisor *lifecycle.Supervisor,
 	mouseMoveRegChannel chan chan mgl32.Vec2,
 	mouseScrollRegChannel chan chan float32,
 	keyPressedRegChannel chan chan glfw.Key,
@@ -54,13 +57,12 @@ func NewCamera(
 		mouseScrolls:           make(chan float32, 2),
 		keyPresses:             make(chan glfw.Key, 2),
 		keyReleases:            make(chan glfw.Key, 2),
-		ControlChannel:         make(chan int),
 		lastUpdateTicks:        0,
-		offsetChangeRegs:       make([]chan mgl32.Vec2, 0),
+		offsetChanges:          broadcast.NewBroadcaster[mgl32.Vec2]("Camera offsets", broadcast.CoalesceLatest, broadcast.DefaultTimeout),
 		OffsetChangeRegChannel: make(chan chan mgl32.Vec2),
-		scaleChangeRegs:        make([]chan float32, 0),
+		scaleChanges:           broadcast.NewBroadcaster[float32]("Camera scales", broadcast.CoalesceLatest, broadcast.DefaultTimeout),
 		ScaleChangeRegChannel:  make(chan chan float32),
-		boardPosRegs:           make([]chan mgl32.Vec2, 0),
+		boardPosChanges:        broadcast.NewBroadcaster[mgl32.Vec2]("Board positions", broadcast.CoalesceLatest, broadcast.DefaultTimeout),
 		BoardPosRegChannel:     make(chan chan mgl32.Vec2)}
 
 	mouseMoveRegChannel <- camera.mouseMoves
@@ -68,7 +70,7 @@ func NewCamera(
 	keyPressedRegChannel <- camera.keyPresses
 	keyReleasedRegChannel <- camera.keyReleases
 
-	go camera.run()
+	supervisor.Go("flat.Camera", camera.run)
 
 	return &camera
 }
@@ -116,36 +118,30 @@ func (c *Camera) StepUpdate(interval float32) {
 	}
 
 	if offsetChanged {
-		for _, reg := range c.offsetChangeRegs {
-			reg <- c.Offset
-		}
+		c.offsetChanges.Send(c.Offset)
 	}
 }
 
-func (c *Camera) run() {
+func (c *Camera) run(ctx context.Context) {
 	for {
 		select {
 		case reg := <-c.BoardPosRegChannel:
-			c.boardPosRegs = append(c.boardPosRegs, reg)
+			c.boardPosChanges.Register(reg)
 		case reg := <-c.OffsetChangeRegChannel:
-			c.offsetChangeRegs = append(c.offsetChangeRegs, reg)
+			c.offsetChanges.Register(reg)
 		case reg := <-c.ScaleChangeRegChannel:
-			c.scaleChangeRegs = append(c.scaleChangeRegs, reg)
+			c.scaleChanges.Register(reg)
 		case mousePos := <-c.mouseMoves:
 			boardPos := c.MapPixelPosToBoard(mousePos)
-			for _, reg := range c.boardPosRegs {
-				reg <- boardPos
-			}
+			c.boardPosChanges.Send(boardPos)
 		case scrollAmount := <-c.mouseScrolls:
 			c.Scale *= (1.0 + scrollAmount*config.Config.Ui.Camera.MouseScrollFactor)
-			for _, reg := range c.scaleChangeRegs {
-				reg <- c.Scale
-			}
+			c.scaleChanges.Send(c.Scale)
 		case keyCode := <-c.keyPresses:
 			c.parseKeyCode(keyCode, true)
 		case keyCode := <-c.keyReleases:
 			c.parseKeyCode(keyCode, false)
-		case _ = <-c.ControlChannel:
+		case _ = <-ctx.Done():
 			return
 		}
 	}
@@ -9,6 +9,10 @@ import (
 	"github.com/go-gl/mathgl/mgl32"
 )
 
+// Wide lines are drawn as parallel strokes about a pixel apart, as OpenGL core profiles only draw lines a pixel wide.
+// At most this many strokes are drawn, so wide lines far zoomed in stay cheap to draw.
+const maxLineStrokes = 32
+
 // Defines how to render generic lines in a channel-based manner
 type LineRenderer struct {
 	offsetChangeChannel chan mgl32.Vec2
@@ -19,7 +23,7 @@ type LineRenderer struct {
 
 	lineColor         mgl32.Vec3
 	lastRenderedLines [][2]mgl32.Vec2
-	lines             map[int64][2]mgl32.Vec2
+	lines             map[int64]geometry.IdLine
 	newInput          bool
 
 	NewLineChannel    chan geometry.IdLine
@@ -34,13 +38,13 @@ func NewLineRenderer(lineColor mgl32.Vec3) *LineRenderer {
 		cameraScale:         1.0,
 		lineColor:           lineColor,
 		lastRenderedLines:   make([][2]mgl32.Vec2, 0),
-		lines:               make(map[int64][2]mgl32.Vec2),
+		lines:               make(map[int64]geometry.IdLine),
 		newInput:            false,
 		NewLineChannel:      make(chan geometry.IdLine, 50),
 		DeleteLineChannel:   make(chan int64, 50)}
 
-	mailroom.CameraOffsetRegChannel <- renderer.offsetChangeChannel
-	mailroom.CameraScaleRegChannel <- renderer.scaleChangeChannel
+	mailroom.CameraOffsetRegChannel.Use("flat.LineRenderer").Send(renderer.offsetChangeChannel)
+	mailroom.CameraScaleRegChannel.Use("flat.LineRenderer").Send(renderer.scaleChangeChannel)
 
 	return &renderer
 }
@@ -58,7 +62,7 @@ func (r *LineRenderer) drainInputChannels() {
 			delete(r.lines, deletionId)
 			r.newInput = true
 		case idLine := <-r.NewLineChannel:
-			r.lines[idLine.Id] = idLine.Line
+			r.lines[idLine.Id] = idLine
 			r.newInput = true
 		default:
 			inputLeft = false
@@ -66,16 +70,36 @@ func (r *LineRenderer) drainInputChannels() {
 	}
 }
 
+// Gets the strokes, on the map, that draw a line at its width with the current zoom
+func (r *LineRenderer) getStrokes(line geometry.IdLine) [][2]mgl32.Vec2 {
+	direction := line.Line[1].Sub(line.Line[0])
+	strokeCount := min(maxLineStrokes, int(line.Width*r.cameraScale))
+	if strokeCount <= 1 || direction.Len() == 0 {
+		return [][2]mgl32.Vec2{line.Line}
+	}
+
+	normal := mgl32.Vec2{-direction.Y(), direction.X()}.Normalize()
+	strokes := make([][2]mgl32.Vec2, strokeCount)
+	for i := range strokes {
+		offset := normal.Mul(line.Width * (float32(i)/float32(strokeCount-1) - 0.5))
+		strokes[i] = [2]mgl32.Vec2{line.Line[0].Add(offset), line.Line[1].Add(offset)}
+	}
+
+	return strokes
+}
+
 func (r *LineRenderer) Render() {
 	r.drainInputChannels()
 
 	if r.newInput {
 		r.lastRenderedLines = make([][2]mgl32.Vec2, 0)
 		for _, line := range r.lines {
-			mappedLine := [2]mgl32.Vec2{
-				gamegrid.MapPositionToScreen(line[0], r.cameraScale, r.cameraOffset),
-				gamegrid.MapPositionToScreen(line[1], r.cameraScale, r.cameraOffset)}
-			r.lastRenderedLines = append(r.lastRenderedLines, mappedLine)
+			for _, stroke := range r.getStrokes(line) {
+				mappedLine := [2]mgl32.Vec2{
+					gamegrid.MapPositionToScreen(stroke[0], r.cameraScale, r.cameraOffset),
+					gamegrid.MapPositionToScreen(stroke[1], r.cameraScale, r.cameraOffset)}
+				r.lastRenderedLines = append(r.lastRenderedLines, mappedLine)
+			}
 		}
 	}
 
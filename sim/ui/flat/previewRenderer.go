@@ -0,0 +1,48 @@
+package flat
+
+import (
+	"sim/core/dto/editorengdto"
+	"sim/core/dto/geometry"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Renders a preview of where an item would be placed, colored by whether it can be placed there
+type PreviewRenderer struct {
+	validRenderer   *RegionRenderer
+	invalidRenderer *RegionRenderer
+
+	PreviewChannel chan editorengdto.PlacementPreview
+}
+
+func NewPreviewRenderer() *PreviewRenderer {
+	return &PreviewRenderer{
+		validRenderer:   NewRegionRenderer(mgl32.Vec3{0, 1, 0}),
+		invalidRenderer: NewRegionRenderer(mgl32.Vec3{1, 0, 0}),
+		PreviewChannel:  make(chan editorengdto.PlacementPreview, 10)}
+}
+
+func (r *PreviewRenderer) drainInputChannels() {
+	inputLeft := true
+	for inputLeft {
+		select {
+		case preview := <-r.PreviewChannel:
+			r.validRenderer.deleteRegion(0)
+			r.invalidRenderer.deleteRegion(0)
+			if preview.IsVisible && preview.IsValid {
+				r.validRenderer.setRegion(geometry.NewIdRegion(0, preview.Region))
+			} else if preview.IsVisible {
+				r.invalidRenderer.setRegion(geometry.NewIdRegion(0, preview.Region))
+			}
+		default:
+			inputLeft = false
+		}
+	}
+}
+
+func (r *PreviewRenderer) Render() {
+	r.drainInputChannels()
+
+	r.validRenderer.Render()
+	r.invalidRenderer.Render()
+}
@@ -40,15 +40,30 @@ func NewRegionRenderer(regionColor mgl32.Vec3) *RegionRenderer {
 		NewRegionChannel:      make(chan geometry.IdRegion, 50),
 		DeleteRegionChannel:   make(chan int64, 50)}
 
-	mailroom.CameraOffsetRegChannel <- renderer.offsetChangeChannel
-	mailroom.CameraScaleRegChannel <- renderer.scaleChangeChannel
+	mailroom.CameraOffsetRegChannel.Use("flat.RegionRenderer").Send(renderer.offsetChangeChannel)
+	mailroom.CameraScaleRegChannel.Use("flat.RegionRenderer").Send(renderer.scaleChangeChannel)
 
 	return &renderer
 }
 
+func (r *RegionRenderer) setRegion(idRegion geometry.IdRegion) {
+	if idRegion.Id == -1 {
+		// Special case -- if someone sends an invalid ID, we reset EVERYTHING
+		r.regions = make(map[int64]commonMath.Region)
+	} else {
+		r.regions[idRegion.Id] = idRegion.Region
+	}
+
+	r.newInput = true
+}
+
+func (r *RegionRenderer) deleteRegion(id int64) {
+	delete(r.regions, id)
+	r.newInput = true
+}
+
 func (r *RegionRenderer) drainInputChannels() {
 	inputLeft := true
-	r.newInput = false
 	for inputLeft {
 		select {
 		case r.cameraOffset = <-r.offsetChangeChannel:
@@ -56,17 +71,9 @@ func (r *RegionRenderer) drainInputChannels() {
 		case r.cameraScale = <-r.scaleChangeChannel:
 			r.newInput = true
 		case deletionId := <-r.DeleteRegionChannel:
-			delete(r.regions, deletionId)
-			r.newInput = true
+			r.deleteRegion(deletionId)
 		case idRegion := <-r.NewRegionChannel:
-			if idRegion.Id == -1 {
-				// Special case -- if someone sends an invalid ID, we reset EVERYTHING
-				r.regions = make(map[int64]commonMath.Region)
-			} else {
-				r.regions[idRegion.Id] = idRegion.Region
-			}
-
-			r.newInput = true
+			r.setRegion(idRegion)
 		default:
 			inputLeft = false
 		}
@@ -82,6 +89,8 @@ func (r *RegionRenderer) Render() {
 			mappedRegion := gamegrid.MapEngineRegionToScreen(&region, r.cameraScale, r.cameraOffset)
 			r.lastRendereredRegions = append(r.lastRendereredRegions, *mappedRegion)
 		}
+
+		r.newInput = false
 	}
 
 	// TODO: Update region renderer to support caching buffers,
@@ -2,7 +2,9 @@ package flat
 
 import (
 	"common/commonmath"
+	"context"
 	"sim/core/dto/geometry"
+	"sim/core/lifecycle"
 
 	"github.com/go-gl/mathgl/mgl32"
 )
@@ -12,21 +14,24 @@ type SnapRenderer struct {
 	NodeRenderer              *RegionRenderer
 }
 
-func NewSnapRenderer() *SnapRenderer {
+func NewSnapRenderer(supervisor *lifecycle.Supervisor) *SnapRenderer {
 	renderer := SnapRenderer{
 		SnappedNodesUpdateChannel: make(chan []mgl32.Vec2),
 		NodeRenderer:              NewRegionRenderer(mgl32.Vec3{0.0, 1.0, 0.0})}
 
-	go renderer.run()
+	supervisor.Go("flat.SnapRenderer", renderer.run)
 	return &renderer
 }
 
-func (r *SnapRenderer) run() {
+func (r *SnapRenderer) run(ctx context.Context) {
 	for {
-		positions := <-r.SnappedNodesUpdateChannel
+		positions, ok := lifecycle.Receive(ctx, r.SnappedNodesUpdateChannel)
+		if !ok {
+			return
+		}
 
 		// Reset the renderer
-		r.NodeRenderer.NewRegionChannel <- geometry.NewIdRegion(-1, commonMath.Region{})
+		lifecycle.Send(ctx, r.NodeRenderer.NewRegionChannel, geometry.NewIdRegion(-1, commonMath.Region{}))
 		for idx, pos := range positions {
 			region := commonMath.Region{
 				RegionType:  commonMath.CircleRegion,
@@ -34,7 +39,7 @@ func (r *SnapRenderer) run() {
 				Scale:       50,
 				Orientation: 0}
 
-			r.NodeRenderer.NewRegionChannel <- geometry.NewIdRegion(int64(idx), region)
+			lifecycle.Send(ctx, r.NodeRenderer.NewRegionChannel, geometry.NewIdRegion(int64(idx), region))
 		}
 	}
 }
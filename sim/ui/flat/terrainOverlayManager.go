@@ -43,9 +43,9 @@ func NewTerrainOverlayManager() *TerrainOverlayManager {
 		newTerrainChannel:   make(chan *terraindto.TerrainUpdate, 10),
 		TerrainOverlays:     make(map[int]map[int]*TerrainOverlay)}
 
-	mailroom.CameraOffsetRegChannel <- manager.offsetChangeChannel
-	mailroom.CameraScaleRegChannel <- manager.scaleChangeChannel
-	mailroom.NewTerrainRegChannel <- manager.newTerrainChannel
+	mailroom.CameraOffsetRegChannel.Use("flat.TerrainOverlayManager").Send(manager.offsetChangeChannel)
+	mailroom.CameraScaleRegChannel.Use("flat.TerrainOverlayManager").Send(manager.scaleChangeChannel)
+	mailroom.NewTerrainRegChannel.Use("flat.TerrainOverlayManager").Send(manager.newTerrainChannel)
 
 	return &manager
 }
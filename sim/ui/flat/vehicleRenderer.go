@@ -1,8 +1,10 @@
 package flat
 
 import (
+	"context"
 	"sim/core/dto/geometry"
 	"sim/core/dto/vehicledto"
+	"sim/core/lifecycle"
 
 	"github.com/go-gl/mathgl/mgl32"
 )
@@ -18,7 +20,7 @@ type VehicleRenderer struct {
 	Renderer               *LineRenderer
 }
 
-func NewVehicleRenderer() *VehicleRenderer {
+func NewVehicleRenderer(supervisor *lifecycle.Supervisor) *VehicleRenderer {
 	renderer := VehicleRenderer{
 		roadTerminii:           make(map[int64]mgl32.Vec2),
 		roadLines:              make(map[int64]geometry.IdOnlyLine),
@@ -28,11 +30,40 @@ func NewVehicleRenderer() *VehicleRenderer {
 		TerminusChannel:        make(chan geometry.IdPoint, 3),
 		Renderer:               NewLineRenderer(mgl32.Vec3{1, 1, 0})}
 
-	go renderer.run()
+	supervisor.Go("flat.VehicleRenderer", renderer.run)
 	return &renderer
 }
 
-func (r *VehicleRenderer) run() {
+// Moves a vehicle to its position along its road
+func (r *VehicleRenderer) updateVehicle(ctx context.Context, vehicleUpdate vehicledto.VehicleUpdate) {
+	if road, ok := r.roadLines[vehicleUpdate.RoadId]; ok {
+		if startPos, ok := r.roadTerminii[road.Start]; ok {
+			if endPos, ok := r.roadTerminii[road.End]; ok {
+				// Swap so the percentage we take is always from start to end.
+				if (road.End < road.Start && vehicleUpdate.TravelLength > 0) ||
+					(road.End > road.Start && vehicleUpdate.TravelLength < 0) {
+					temp := startPos
+					startPos = endPos
+					endPos = temp
+				}
+
+				if vehicleUpdate.TravelLength < 0 {
+					vehicleUpdate.TravelLength = -vehicleUpdate.TravelLength
+				}
+
+				// Compute start and end
+				roadSegment := endPos.Sub(startPos)
+				vehicleLengthPercent := vehicleUpdate.VehicleLength / roadSegment.Len()
+				start := roadSegment.Mul(vehicleUpdate.TravelLength).Add(startPos)
+				end := roadSegment.Mul(vehicleUpdate.TravelLength + vehicleLengthPercent).Add(startPos)
+
+				lifecycle.Send(ctx, r.Renderer.NewLineChannel, geometry.NewIdLine(vehicleUpdate.Id, [2]mgl32.Vec2{start, end}))
+			}
+		}
+	}
+}
+
+func (r *VehicleRenderer) run(ctx context.Context) {
 	for {
 		select {
 		case roadLine := <-r.RoadLineRegChannel:
@@ -40,33 +71,21 @@ func (r *VehicleRenderer) run() {
 		case terminus := <-r.TerminusChannel:
 			r.roadTerminii[terminus.Id] = terminus.Point
 		case vehicleUpdate := <-r.VehicleUpdateChannel:
-			if road, ok := r.roadLines[vehicleUpdate.RoadId]; ok {
-				if startPos, ok := r.roadTerminii[road.Start]; ok {
-					if endPos, ok := r.roadTerminii[road.End]; ok {
-						// Swap so the percentage we take is always from start to end.
-						if (road.End < road.Start && vehicleUpdate.TravelLength > 0) ||
-							(road.End > road.Start && vehicleUpdate.TravelLength < 0) {
-							temp := startPos
-							startPos = endPos
-							endPos = temp
-						}
-
-						if vehicleUpdate.TravelLength < 0 {
-							vehicleUpdate.TravelLength = -vehicleUpdate.TravelLength
-						}
-
-						// Compute start and end
-						roadSegment := endPos.Sub(startPos)
-						vehicleLengthPercent := vehicleUpdate.VehicleLength / roadSegment.Len()
-						start := roadSegment.Mul(vehicleUpdate.TravelLength).Add(startPos)
-						end := roadSegment.Mul(vehicleUpdate.TravelLength + vehicleLengthPercent).Add(startPos)
-
-						r.Renderer.NewLineChannel <- geometry.NewIdLine(vehicleUpdate.Id, [2]mgl32.Vec2{start, end})
-					}
+			r.updateVehicle(ctx, vehicleUpdate)
+		case vehicleId := <-r.VehicleDeletionChannel:
+			// Updates sent before the vehicle was despawned may still be queued, and would otherwise draw it again afterwards
+			for drained := false; !drained; {
+				select {
+				case vehicleUpdate := <-r.VehicleUpdateChannel:
+					r.updateVehicle(ctx, vehicleUpdate)
+				default:
+					drained = true
 				}
 			}
-		case vehicleId := <-r.VehicleDeletionChannel:
-			r.Renderer.DeleteLineChannel <- vehicleId
+
+			lifecycle.Send(ctx, r.Renderer.DeleteLineChannel, vehicleId)
+		case _ = <-ctx.Done():
+			return
 		}
 	}
 }